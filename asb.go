@@ -0,0 +1,103 @@
+package escpos
+
+import "fmt"
+
+// ASBFlags selects which changes make the printer send an automatic status
+type ASBFlags int
+
+const (
+	// ASBDrawer sends the status when the drawer kick-out connector changes
+	ASBDrawer ASBFlags = 1 << iota
+	// ASBOnline sends the status when the printer goes online or offline
+	ASBOnline
+	// ASBError sends the status when an error happens or is cleared
+	ASBError
+	// ASBPaper sends the status when the roll paper sensor changes
+	ASBPaper
+
+	// ASBAll sends the status for every change
+	ASBAll = ASBDrawer | ASBOnline | ASBError | ASBPaper
+)
+
+// EnableASB turns on Automatic Status Back with GS a so the printer sends a 4
+// byte status by itself when anything in flags changes.  The status is also
+// sent once right after it is enabled.  Flags of 0 turns it off.
+//
+// While ASB is on the printer can send a status at any time, so the status
+// bytes will show up in the middle of anything else read from the printer,
+// like the responses of RealtimeStatus.  Either only use ReadASB while it is
+// on, or turn it off before reading other responses.
+func (p Printer) EnableASB(flags ASBFlags) error {
+	errMsg := "could not enable automatic status back: %w"
+
+	if flags&^ASBAll != 0 {
+		return fmt.Errorf(errMsg, fmt.Errorf("%04b is not a valid ASB flag", flags))
+	}
+
+	_, err := p.Write([]byte{GS, 'a', byte(flags)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// isASBHeader reports if b can be the first byte of an ASB status.  The first
+// byte always has bit 4 set and bits 0, 1, and 7 cleared.
+func isASBHeader(b byte) bool {
+	return b&0b1001_0011 == 0b0001_0000
+}
+
+// decodeASB decodes a 4 byte ASB status into a Status with all the fields set
+func decodeASB(data [4]byte) Status {
+	return Status{
+		DrawerOpen: data[0]&0b0000_0100 == 0b0000_0100,
+		Offline:    data[0]&0b0000_1000 == 0b0000_1000,
+		CoverOpen:  data[0]&0b0010_0000 == 0b0010_0000,
+		FeedButton: data[0]&0b0100_0000 == 0b0100_0000,
+
+		Mechanical:      data[1]&0b0000_0100 == 0b0000_0100,
+		AutoCutter:      data[1]&0b0000_1000 == 0b0000_1000,
+		UnRecoverable:   data[1]&0b0010_0000 == 0b0010_0000,
+		AutoRecoverable: data[1]&0b0100_0000 == 0b0100_0000,
+
+		PaperNearEnd: data[2]&0b0000_0011 == 0b0000_0011,
+		PaperEnd:     data[2]&0b0000_1100 == 0b0000_1100,
+	}
+}
+
+// ReadASB waits for the next automatic status sent after EnableASB.  Bytes
+// before the start of a status are skipped.  Unlike RealtimeStatus every
+// field of the status is set, and Type and Raw are left at 0 since the status
+// isn't a single StatusType.
+//
+// The read timeout from SetReadTimeout applies to the whole status.
+func (p Printer) ReadASB() (Status, error) {
+	errMsg := "could not read automatic status back: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	deadline := p.readDeadline()
+
+	var data [4]byte
+	b := make([]byte, 1)
+	for i := 0; i < len(data); {
+		_, err := p.readBefore(b, deadline)
+		if err != nil {
+			return Status{}, fmt.Errorf(errMsg, err)
+		}
+
+		switch {
+		case isASBHeader(b[0]):
+			// A header in the middle means the last status was cut off
+			data[0], i = b[0], 1
+		case i > 0 && b[0]&0b1001_0000 == 0:
+			data[i] = b[0]
+			i++
+		default:
+			i = 0
+		}
+	}
+
+	return decodeASB(data), nil
+}
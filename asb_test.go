@@ -0,0 +1,89 @@
+package escpos_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// readerConn answers reads from a fixed stream and drops writes
+type readerConn struct {
+	*bytes.Reader
+}
+
+func (readerConn) Write(b []byte) (int, error) { return len(b), nil }
+func (readerConn) Close() error                { return nil }
+
+func TestEnableASB(t *testing.T) {
+	cases := []struct {
+		flags escpos.ASBFlags
+		want  byte
+	}{
+		{0, 0},
+		{escpos.ASBDrawer, 1},
+		{escpos.ASBOnline | escpos.ASBPaper, 0b1010},
+		{escpos.ASBAll, 0b1111},
+	}
+
+	for _, c := range cases {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.EnableASB(c.flags)
+		if err != nil {
+			t.Fatalf("could not enable ASB %04b: %v", c.flags, err)
+		}
+
+		want := []byte{escpos.GS, 'a', c.want}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+		}
+	}
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.EnableASB(escpos.ASBAll + 1)
+	if err == nil || len(sink.Bytes()) > 0 {
+		t.Fatalf("flag 10000 did not fail without sending anything: %v", err)
+	}
+}
+
+func TestReadASB(t *testing.T) {
+	drawerCover := escpos.Status{DrawerOpen: true, CoverOpen: true, AutoCutter: true, AutoRecoverable: true, PaperNearEnd: true, PaperEnd: true}
+
+	cases := []struct {
+		name string
+		data []byte
+		want escpos.Status
+	}{
+		{"clear", []byte{0x10, 0, 0, 0}, escpos.Status{}},
+		{"drawer and cover", []byte{0x34, 0x48, 0x0F, 0}, drawerCover},
+		{"offline and feed", []byte{0x58, 0x04, 0, 0}, escpos.Status{Offline: true, FeedButton: true, Mechanical: true}},
+		{"near end", []byte{0x10, 0x20, 0x03, 0}, escpos.Status{UnRecoverable: true, PaperNearEnd: true}},
+		{"skips other bytes", []byte{0x16, 0xFF, 0x34, 0x48, 0x0F, 0}, drawerCover},
+		{"cut off status", []byte{0x10, 0, 0x34, 0x48, 0x0F, 0}, drawerCover},
+		{"starts again after a bad byte", []byte{0x10, 0x90, 0, 0, 0x34, 0x48, 0x0F, 0}, drawerCover},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			printer := escpos.NewPrinter(readerConn{bytes.NewReader(c.data)})
+
+			status, err := printer.ReadASB()
+			if err != nil {
+				t.Fatalf("could not read ASB: %v", err)
+			}
+			if status != c.want {
+				t.Fatalf("decoded %+v instead of %+v", status, c.want)
+			}
+		})
+	}
+}
+
+func TestReadASBShort(t *testing.T) {
+	printer := escpos.NewPrinter(readerConn{bytes.NewReader([]byte{0x10, 0, 0})})
+
+	_, err := printer.ReadASB()
+	if err == nil {
+		t.Fatal("3 bytes did not fail")
+	}
+}
@@ -0,0 +1,200 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// BarCodeImageOptions controls how PrintBarCodeImage draws the bar code.  Zero
+// values use the defaults.
+type BarCodeImageOptions struct {
+	// Height is the height of the bars in dots, the default is 162 like
+	// the GS k bar codes
+	Height int
+	// ModuleWidth is the width of the narrowest bar in dots, the default is 2
+	ModuleWidth int
+	// QuietZone is the white space on each side in modules, the default is 10
+	QuietZone int
+	// HRI prints the data as text below the bar code
+	HRI bool
+}
+
+// code39 has the modules of each character with wide elements as 2 modules,
+// 1 is a bar and 0 is a space
+var code39 = map[rune]string{
+	'0': "101001101101", '1': "110100101011", '2': "101100101011", '3': "110110010101",
+	'4': "101001101011", '5': "110100110101", '6': "101100110101", '7': "101001011011",
+	'8': "110100101101", '9': "101100101101", 'A': "110101001011", 'B': "101101001011",
+	'C': "110110100101", 'D': "101011001011", 'E': "110101100101", 'F': "101101100101",
+	'G': "101010011011", 'H': "110101001101", 'I': "101101001101", 'J': "101011001101",
+	'K': "110101010011", 'L': "101101010011", 'M': "110110101001", 'N': "101011010011",
+	'O': "110101101001", 'P': "101101101001", 'Q': "101010110011", 'R': "110101011001",
+	'S': "101101011001", 'T': "101011011001", 'U': "110010101011", 'V': "100110101011",
+	'W': "110011010101", 'X': "100101101011", 'Y': "110010110101", 'Z': "100110110101",
+	'-': "100101011011", '.': "110010101101", ' ': "100110101101", '$': "100100100101",
+	'/': "100100101001", '+': "100101001001", '%': "101001001001", '*': "100101101101",
+}
+
+// code128 has the bar and space widths of each symbol value
+var code128 = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// code39Modules encodes the data with the * start and stop characters and a
+// narrow space between each character
+func code39Modules(data string) (string, error) {
+	var modules []string
+	for i, r := range "*" + data + "*" {
+		pattern, ok := code39[r]
+		if !ok || (r == '*' && i != 0 && i != len(data)+1) {
+			return "", fmt.Errorf("%q can't be encoded in CODE39", r)
+		}
+		modules = append(modules, pattern)
+	}
+	return strings.Join(modules, "0"), nil
+}
+
+// code128Modules encodes the data with code set B, which has all the
+// printable ASCII characters
+func code128Modules(data string) (string, error) {
+	values := []int{code128StartB}
+	checksum := code128StartB
+	for i, r := range data {
+		if r < 32 || r > 127 {
+			return "", fmt.Errorf("%q can't be encoded in CODE128 code set B", r)
+		}
+		values = append(values, int(r)-32)
+		checksum += (i + 1) * (int(r) - 32)
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var modules strings.Builder
+	for _, v := range values {
+		for i, w := range code128[v] {
+			module := "1"
+			if i%2 == 1 {
+				module = "0"
+			}
+			modules.WriteString(strings.Repeat(module, int(w-'0')))
+		}
+	}
+	return modules.String(), nil
+}
+
+// barCodeImage draws the bar code with each module as opts.ModuleWidth dots
+func barCodeImage(barcodeType BarCode, data string, opts BarCodeImageOptions) (*image.Gray, error) {
+	if opts.Height == 0 {
+		opts.Height = 162
+	}
+	if opts.ModuleWidth == 0 {
+		opts.ModuleWidth = 2
+	}
+	if opts.QuietZone == 0 {
+		opts.QuietZone = 10
+	}
+
+	err := checkRange(opts.Height, 1, rasterMaxHeight, "height")
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRange(opts.ModuleWidth, 1, 8, "module width")
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRange(opts.QuietZone, 0, 100, "quiet zone")
+	if err != nil {
+		return nil, err
+	}
+
+	var modules string
+	switch barcodeType {
+	case BcCODE39:
+		modules, err = code39Modules(data)
+	case BcCODE128:
+		modules, err = code128Modules(data)
+	default:
+		return nil, fmt.Errorf("%v can't be drawn as an image, only CODE39 and CODE128 can", barcodeType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	modules = strings.Repeat("0", opts.QuietZone) + modules + strings.Repeat("0", opts.QuietZone)
+
+	img := image.NewGray(image.Rect(0, 0, len(modules)*opts.ModuleWidth, opts.Height))
+	for x := 0; x < img.Bounds().Dx(); x++ {
+		c := color.White
+		if modules[x/opts.ModuleWidth] == '1' {
+			c = color.Black
+		}
+		for y := 0; y < opts.Height; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+// PrintBarCodeImage draws the bar code as an image and prints it with
+// PrintImageRaster.  This works on printers that don't have the bar code in
+// their firmware, but only CODE39 and CODE128 can be drawn.  CODE128 is
+// drawn with code set B.
+//
+// The HRI text is printed as normal text below the image, so it uses the
+// current font and justification.
+func (p Printer) PrintBarCodeImage(barcodeType BarCode, data string, opts BarCodeImageOptions) error {
+	errMsg := "could not print bar code image: %w"
+
+	img, err := barCodeImage(barcodeType, data, opts)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err = p.PrintImageRaster(img, RasterNormal)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if opts.HRI {
+		err = p.Println(data)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// SmartBarCode prints the bar code with PrintBarCode when the SupportsBarCode
+// of the profile is set, and otherwise with PrintBarCodeImage, so the same
+// code works on printers without bar codes.  The opts are only used for the
+// image, the printer uses the bar code settings that were sent to it.
+// ProfileUnknown doesn't set SupportsBarCode so it gets the image, since that
+// works on every printer, but only CODE39 and CODE128 can be drawn.
+func (p Printer) SmartBarCode(barcodeType BarCode, data string, opts BarCodeImageOptions) error {
+	if p.Profile().SupportsBarCode {
+		return p.PrintBarCode(barcodeType, data)
+	}
+	return p.PrintBarCodeImage(barcodeType, data, opts)
+}
@@ -0,0 +1,139 @@
+package escpos_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// rasterTopRow returns the dots of the top row of the first GS v 0 image in
+// the data, with 1 for black and 0 for white
+func rasterTopRow(t *testing.T, data []byte) string {
+	t.Helper()
+
+	i := bytes.Index(data, []byte{escpos.GS, 'v', '0'})
+	if i < 0 || i+8 > len(data) {
+		t.Fatalf("sent % x without a raster image", data)
+	}
+
+	width := int(data[i+4]) | int(data[i+5])<<8
+	var row strings.Builder
+	for _, b := range data[i+8 : i+8+width] {
+		for bit := 7; bit >= 0; bit-- {
+			row.WriteByte('0' + b>>bit&1)
+		}
+	}
+	return row.String()
+}
+
+func TestPrintBarCodeImage(t *testing.T) {
+	// * A B * with a narrow space between each character
+	star, a, b := "100101101101", "110101001011", "101101001011"
+	modules := strings.Join([]string{star, a, b, star}, "0")
+
+	cases := []struct {
+		name string
+		opts escpos.BarCodeImageOptions
+		want string
+	}{
+		{"narrow", escpos.BarCodeImageOptions{Height: 8, ModuleWidth: 1, QuietZone: 4}, strings.Repeat("0", 4) + modules + strings.Repeat("0", 4)},
+		{"default quiet zone", escpos.BarCodeImageOptions{Height: 8, ModuleWidth: 1}, strings.Repeat("0", 10) + modules + strings.Repeat("0", 10)},
+		{"wide", escpos.BarCodeImageOptions{Height: 8, ModuleWidth: 3, QuietZone: 1}, strings.Repeat("000", 1) + strings.NewReplacer("0", "000", "1", "111").Replace(modules) + strings.Repeat("000", 1)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintBarCodeImage(escpos.BcCODE39, "AB", c.opts)
+			if err != nil {
+				t.Fatalf("could not print bar code: %v", err)
+			}
+
+			// The row is padded with white to a whole byte
+			row := rasterTopRow(t, sink.Bytes())
+			want := c.want + strings.Repeat("0", (8-len(c.want)%8)%8)
+			if row != want {
+				t.Fatalf("drew\n%s\ninstead of\n%s", row, want)
+			}
+		})
+	}
+}
+
+func TestPrintBarCodeImageHRI(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.PrintBarCodeImage(escpos.BcCODE128, "AB", escpos.BarCodeImageOptions{ModuleWidth: 1, HRI: true})
+	if err != nil {
+		t.Fatalf("could not print bar code: %v", err)
+	}
+
+	// The bar code is 57 modules and a quiet zone of 10 on each side
+	want := []string{"RASTER IMAGE mode 0 80x162", "STATUS 3", `PRINT "AB"`, "LF"}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %q instead of %q", got, want)
+	}
+}
+
+func TestPrintBarCodeImageErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		barcodeType escpos.BarCode
+		data        string
+		opts        escpos.BarCodeImageOptions
+	}{
+		{"not drawn", escpos.BcEAN13, "4006381333931", escpos.BarCodeImageOptions{}},
+		{"lower case CODE39", escpos.BcCODE39, "ab", escpos.BarCodeImageOptions{}},
+		{"star in CODE39", escpos.BcCODE39, "A*B", escpos.BarCodeImageOptions{}},
+		{"not ASCII CODE128", escpos.BcCODE128, "café", escpos.BarCodeImageOptions{}},
+		{"negative height", escpos.BcCODE39, "AB", escpos.BarCodeImageOptions{Height: -1}},
+		{"too tall", escpos.BcCODE39, "AB", escpos.BarCodeImageOptions{Height: 2304}},
+		{"module too wide", escpos.BcCODE39, "AB", escpos.BarCodeImageOptions{ModuleWidth: 9}},
+		{"quiet zone too wide", escpos.BcCODE39, "AB", escpos.BarCodeImageOptions{QuietZone: 101}},
+		// 188 modules at 4 dots each is wider than the paper
+		{"wider than the paper", escpos.BcCODE39, "ABCDEFGHIJK", escpos.BarCodeImageOptions{ModuleWidth: 4}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintBarCodeImage(c.barcodeType, c.data, c.opts)
+			if err == nil {
+				t.Fatalf("bar code did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestSmartBarCode(t *testing.T) {
+	cases := []struct {
+		profile escpos.Profile
+		want    []string
+	}{
+		{escpos.ProfileHoin, []string{`BARCODE 73 "{BAB"`}},
+		// The bar code is 57 modules and a quiet zone of 10 on each side
+		{noCodes(), []string{"RASTER IMAGE mode 0 80x162", "STATUS 3"}},
+		{escpos.ProfileUnknown, []string{"RASTER IMAGE mode 0 80x162", "STATUS 3"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.profile.Name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.SmartBarCode(escpos.BcCODE128, "AB", escpos.BarCodeImageOptions{ModuleWidth: 1})
+			if err != nil {
+				t.Fatalf("could not print bar code: %v", err)
+			}
+			if got := sink.Commands(); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("sent %q instead of %q", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package escpos
+
+import "fmt"
+
+// Beep is a single buzzer command of Count beeps each lasting Duration.  Both
+// must be between 1 and 9, see Printer.Beep.
+type Beep struct {
+	Count, Duration int
+}
+
+// BeepPreset is a named beep sequence for common alerts
+type BeepPreset int
+
+const (
+	// BeepReady is two short beeps, like for an order being ready
+	BeepReady BeepPreset = iota
+	// BeepAttention is one medium beep
+	BeepAttention
+	// BeepError is three long beeps
+	BeepError
+)
+
+var beepPresets = map[BeepPreset][]Beep{
+	BeepReady:     {{Count: 2, Duration: 1}},
+	BeepAttention: {{Count: 1, Duration: 3}},
+	BeepError:     {{Count: 3, Duration: 5}},
+}
+
+// BeepSequence sends each beep in order.  All the beeps are checked before
+// anything is sent, and like Beep printers without a buzzer return
+// ErrUnsupported.
+func (p Printer) BeepSequence(beeps []Beep) error {
+	errMsg := "could not beep sequence: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := p.supports("BeepSequence", p.Profile().SupportsBuzzer)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	for i, beep := range beeps {
+		err := checkRange(beep.Count, 1, 9, fmt.Sprintf("beep %d count", i))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		err = checkRange(beep.Duration, 1, 9, fmt.Sprintf("beep %d duration", i))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	for _, beep := range beeps {
+		err := p.Beep(beep.Count, beep.Duration)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// BeepPattern beeps one of the preset patterns.  Like Beep printers without a
+// buzzer return ErrUnsupported.
+func (p Printer) BeepPattern(preset BeepPreset) error {
+	err := p.supports("BeepPattern", p.Profile().SupportsBuzzer)
+	if err != nil {
+		return fmt.Errorf("could not beep pattern: %w", err)
+	}
+
+	beeps, ok := beepPresets[preset]
+	if !ok {
+		return fmt.Errorf("could not beep pattern: %v is not a beep preset", preset)
+	}
+	return p.BeepSequence(beeps)
+}
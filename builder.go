@@ -0,0 +1,118 @@
+package escpos
+
+import "image"
+
+// ReceiptBuilder calls printer methods in a chain and keeps the first error.
+// Once a call fails the rest of the calls do nothing, so the error only needs
+// to be checked at the end with Err or Flush.
+//
+//	err := p.Builder().
+//		Justify(CenterJustify).
+//		Bold(true).
+//		Println("Receipt").
+//		Bold(false).
+//		FeedLines(3).
+//		Cut().
+//		Flush()
+type ReceiptBuilder struct {
+	p   Printer
+	err error
+}
+
+// Builder returns a ReceiptBuilder for the printer
+func (p Printer) Builder() *ReceiptBuilder {
+	return &ReceiptBuilder{p: p}
+}
+
+// Do calls f with the printer if there hasn't been an error yet.  It can be
+// used for printer methods that the builder doesn't have.
+func (b *ReceiptBuilder) Do(f func(p Printer) error) *ReceiptBuilder {
+	if b.err == nil {
+		b.err = f(b.p)
+	}
+	return b
+}
+
+func (b *ReceiptBuilder) Print(a ...any) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.Print(a...) })
+}
+
+func (b *ReceiptBuilder) Println(a ...any) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.Println(a...) })
+}
+
+func (b *ReceiptBuilder) Printf(format string, a ...any) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.Printf(format, a...) })
+}
+
+func (b *ReceiptBuilder) Bold(on bool) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.SetBold(on) })
+}
+
+func (b *ReceiptBuilder) Underline(mode UnderlineMode) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.SetUnderline(mode) })
+}
+
+func (b *ReceiptBuilder) Font(f Font) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.SetFont(f) })
+}
+
+func (b *ReceiptBuilder) Size(width, height int) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.SetCharacterSize(width, height) })
+}
+
+func (b *ReceiptBuilder) Justify(j Justification) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.Justify(j) })
+}
+
+func (b *ReceiptBuilder) Columns(width int, cols ...Column) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.PrintColumns(width, cols...) })
+}
+
+func (b *ReceiptBuilder) Wrapped(text string, width int) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.PrintWrapped(text, width) })
+}
+
+func (b *ReceiptBuilder) Table(t Table) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.PrintTable(t) })
+}
+
+func (b *ReceiptBuilder) Document(doc Document) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.PrintDocument(doc) })
+}
+
+// Image prints the image with PrintImageRaster
+func (b *ReceiptBuilder) Image(img image.Image) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.PrintImageRaster(img, RasterNormal) })
+}
+
+func (b *ReceiptBuilder) BarCode(barcodeType BarCode, data string) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.PrintBarCode(barcodeType, data) })
+}
+
+func (b *ReceiptBuilder) QRCode(data string, size int) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.QRCode(data, QRModel2, size, QRErrorM) })
+}
+
+func (b *ReceiptBuilder) Feed(n int) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.Feed(n) })
+}
+
+func (b *ReceiptBuilder) FeedLines(n int) *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.FeedLines(n) })
+}
+
+func (b *ReceiptBuilder) Cut() *ReceiptBuilder {
+	return b.Do(func(p Printer) error { return p.Cut() })
+}
+
+// Err returns the first error from the chain
+func (b *ReceiptBuilder) Err() error {
+	return b.err
+}
+
+// Flush flushes the printer if there hasn't been an error and returns the
+// first error from the chain
+func (b *ReceiptBuilder) Flush() error {
+	return b.Do(func(p Printer) error { return p.Flush() }).err
+}
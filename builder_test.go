@@ -0,0 +1,41 @@
+package escpos_test
+
+import (
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestBuilder(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.Builder().
+		Bold(true).
+		Println("Built").
+		Bold(false).
+		Flush()
+	if err != nil {
+		t.Fatalf("could not build receipt: %v", err)
+	}
+
+	want := "\x1bE\x01Built\n\x1bE\x00"
+	if string(sink.Bytes()) != want {
+		t.Fatalf("sent %q instead of %q", sink.Bytes(), want)
+	}
+}
+
+func TestBuilderErr(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	// Feeding -1 lines is out of range so the line after is not printed
+	err := printer.Builder().
+		FeedLines(-1).
+		Println("Not printed").
+		Err()
+	if err == nil {
+		t.Fatalf("builder did not return the feed lines error")
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent %q after the error", sink.Bytes())
+	}
+}
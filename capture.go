@@ -0,0 +1,330 @@
+package escpos
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CaptureSink records everything written to a printer so it can be looked at
+// without a real printer
+type CaptureSink struct {
+	data []byte
+}
+
+// NewCapturePrinter returns a printer that writes to a CaptureSink.  Reading
+// from the printer returns zeros, so status commands report that everything
+// is fine.
+func NewCapturePrinter() (*CaptureSink, Printer) {
+	sink := &CaptureSink{}
+	return sink, NewPrinter(sink)
+}
+
+func (c *CaptureSink) Write(b []byte) (int, error) {
+	c.data = append(c.data, b...)
+	return len(b), nil
+}
+
+func (c *CaptureSink) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+func (c *CaptureSink) Close() error {
+	return nil
+}
+
+// Bytes returns everything that was written
+func (c *CaptureSink) Bytes() []byte {
+	return c.data
+}
+
+// Reset clears everything that was written
+func (c *CaptureSink) Reset() {
+	c.data = nil
+}
+
+// Commands decodes what was written into a readable list of commands like
+// "BOLD on", "PRINT \"hello\"", and "CUT".  Commands that are not known are
+// listed as RAW with the bytes in hex.
+func (c *CaptureSink) Commands() []string {
+	return decodeCommands(c.data)
+}
+
+func onOff(b byte) string {
+	if b&1 == 1 {
+		return "on"
+	}
+	return "off"
+}
+
+// fixedCommand is a command with a set number of argument bytes
+type fixedCommand struct {
+	args   int
+	format func(args []byte) string
+}
+
+var fixedCommands = map[[2]byte]fixedCommand{
+	{ESC, '@'}: {0, func([]byte) string { return "INITIALIZE" }},
+	{ESC, '2'}: {0, func([]byte) string { return "LINE SPACING default" }},
+	{ESC, '3'}: {1, func(a []byte) string { return fmt.Sprintf("LINE SPACING %d", a[0]) }},
+	{ESC, ' '}: {1, func(a []byte) string { return fmt.Sprintf("CHARACTER SPACING %d", a[0]) }},
+	{ESC, 'E'}: {1, func(a []byte) string { return "BOLD " + onOff(a[0]) }},
+	{ESC, 'G'}: {1, func(a []byte) string { return "DOUBLE-STRIKE " + onOff(a[0]) }},
+	{ESC, '-'}: {1, func(a []byte) string { return fmt.Sprintf("UNDERLINE %d", a[0]) }},
+	{ESC, 'V'}: {1, func(a []byte) string { return "ROTATE90 " + onOff(a[0]) }},
+	{ESC, '{'}: {1, func(a []byte) string { return "UPSIDE-DOWN " + onOff(a[0]) }},
+	{ESC, 'M'}: {1, func(a []byte) string { return fmt.Sprintf("FONT %c", 'A'+a[0]) }},
+	{ESC, '!'}: {1, func(a []byte) string { return fmt.Sprintf("PRINT MODE %08b", a[0]) }},
+	{ESC, 'J'}: {1, func(a []byte) string { return fmt.Sprintf("FEED %d", a[0]) }},
+	{ESC, 'd'}: {1, func(a []byte) string { return fmt.Sprintf("FEED LINES %d", a[0]) }},
+	{ESC, 'K'}: {1, func(a []byte) string { return fmt.Sprintf("REVERSE FEED %d", a[0]) }},
+	{ESC, 'e'}: {1, func(a []byte) string { return fmt.Sprintf("REVERSE FEED LINES %d", a[0]) }},
+	{ESC, 't'}: {1, func(a []byte) string { return fmt.Sprintf("CODE PAGE %d", a[0]) }},
+	{ESC, 'R'}: {1, func(a []byte) string { return fmt.Sprintf("CHARSET %d", a[0]) }},
+	{ESC, 'B'}: {2, func(a []byte) string { return fmt.Sprintf("BEEP %d %d", a[0], a[1]) }},
+	{ESC, '='}: {1, func(a []byte) string { return fmt.Sprintf("PERIPHERAL %d", a[0]) }},
+	{ESC, 'p'}: {3, func(a []byte) string { return fmt.Sprintf("PULSE %d %d %d", a[0], a[1], a[2]) }},
+	{ESC, 'a'}: {1, func(a []byte) string {
+		switch a[0] {
+		case 1:
+			return "JUSTIFY center"
+		case 2:
+			return "JUSTIFY right"
+		}
+		return "JUSTIFY left"
+	}},
+	{GS, 'B'}:   {1, func(a []byte) string { return "REVERSE " + onOff(a[0]) }},
+	{GS, 'P'}:   {2, func(a []byte) string { return fmt.Sprintf("MOTION UNITS %d %d", a[0], a[1]) }},
+	{GS, 'L'}:   {2, func(a []byte) string { return fmt.Sprintf("LEFT MARGIN %d", int(a[0])|int(a[1])<<8) }},
+	{GS, 'H'}:   {1, func(a []byte) string { return fmt.Sprintf("HRI POSITION %d", a[0]) }},
+	{GS, 'f'}:   {1, func(a []byte) string { return fmt.Sprintf("HRI FONT %c", 'A'+a[0]) }},
+	{GS, 'h'}:   {1, func(a []byte) string { return fmt.Sprintf("BARCODE HEIGHT %d", a[0]) }},
+	{GS, 'w'}:   {1, func(a []byte) string { return fmt.Sprintf("BARCODE WIDTH %d", a[0]) }},
+	{GS, '!'}:   {1, func(a []byte) string { return fmt.Sprintf("CHARACTER SIZE %dx%d", a[0]>>4, a[0]&0x0F) }},
+	{GS, 'I'}:   {1, func(a []byte) string { return fmt.Sprintf("PRINTER ID %d", a[0]) }},
+	{DLE, 0x04}: {1, func(a []byte) string { return fmt.Sprintf("STATUS %d", a[0]) }},
+	{DLE, 0x05}: {1, func(a []byte) string { return fmt.Sprintf("REQUEST %d", a[0]) }},
+}
+
+// decodeCommand decodes the command at the start of data and returns the
+// number of bytes it used.  A length of 0 means the command is not known.
+func decodeCommand(data []byte) (string, int) {
+	if len(data) < 2 {
+		return "", 0
+	}
+
+	if cmd, ok := fixedCommands[[2]byte{data[0], data[1]}]; ok {
+		if len(data) < 2+cmd.args {
+			return "", 0
+		}
+		return cmd.format(data[2 : 2+cmd.args]), 2 + cmd.args
+	}
+
+	u16 := func(i int) int { return int(data[i]) | int(data[i+1])<<8 }
+
+	switch {
+	case data[0] == ESC && data[1] == 'D':
+		end := strings.IndexByte(string(data), 0)
+		if end < 0 {
+			return "", 0
+		}
+		return fmt.Sprintf("TABS %v", data[2:end]), end + 1
+
+	case data[0] == ESC && data[1] == '*' && len(data) >= 5:
+		n := u16(3)
+		if data[2] >= 32 {
+			n *= 3
+		}
+		if len(data) < 5+n {
+			return "", 0
+		}
+		return fmt.Sprintf("IMAGE mode %d width %d", data[2], u16(3)), 5 + n
+
+	case data[0] == GS && data[1] == 'V' && len(data) >= 3:
+		switch data[2] {
+		case 0, '0':
+			return "CUT", 3
+		case 1, '1':
+			return "PARTIAL CUT", 3
+		case 65, 66:
+			if len(data) < 4 {
+				return "", 0
+			}
+			if data[2] == 65 {
+				return fmt.Sprintf("CUT feed %d", data[3]), 4
+			}
+			return fmt.Sprintf("PARTIAL CUT feed %d", data[3]), 4
+		}
+
+	case data[0] == GS && data[1] == 'k' && len(data) >= 3:
+		if data[2] < 65 {
+			end := strings.IndexByte(string(data[3:]), 0)
+			if end < 0 {
+				return "", 0
+			}
+			return fmt.Sprintf("BARCODE %d %q", data[2], data[3:3+end]), 3 + end + 1
+		}
+		if len(data) < 4 || len(data) < 4+int(data[3]) {
+			return "", 0
+		}
+		return fmt.Sprintf("BARCODE %d %q", data[2], data[4:4+int(data[3])]), 4 + int(data[3])
+
+	case data[0] == GS && data[1] == 'v' && len(data) >= 8 && data[2] == '0':
+		n := u16(4) * u16(6)
+		if len(data) < 8+n {
+			return "", 0
+		}
+		return fmt.Sprintf("RASTER IMAGE mode %d %dx%d", data[3], u16(4)*8, u16(6)), 8 + n
+
+	case data[0] == GS && data[1] == '(' && len(data) >= 7 && (data[2] == 'k' || data[2] == 'L'):
+		n := u16(3)
+		if len(data) < 5+n {
+			return "", 0
+		}
+		name := "SYMBOL"
+		if data[2] == 'L' {
+			name = "GRAPHICS"
+		}
+		return fmt.Sprintf("%s %d function %d", name, data[5], data[6]), 5 + n
+
+	case data[0] == GS && data[1] == '(' && len(data) >= 7 && data[2] == 'N' && data[5] == '0':
+		return fmt.Sprintf("COLOR %d", data[6]-'0'), 7
+
+	case data[0] == ESC && data[1] == 'c' && len(data) >= 4 && data[2] == '5':
+		return "PANEL BUTTONS " + onOff(^data[3]), 4
+
+	case data[0] == ESC && data[1] == 'c' && len(data) >= 4 && (data[2] == '3' || data[2] == '4'):
+		name := "PAPER END SENSORS"
+		if data[2] == '4' {
+			name = "STOP PRINTING SENSORS"
+		}
+		return fmt.Sprintf("%s %04b", name, data[3]), 4
+
+	case data[0] == GS && data[1] == '8' && len(data) >= 9 && data[2] == 'L':
+		n := u16(3) | u16(5)<<16
+		if len(data) < 7+n {
+			return "", 0
+		}
+		return fmt.Sprintf("GRAPHICS %d function %d", data[7], data[8]), 7 + n
+	}
+
+	return "", 0
+}
+
+// CapturedCommand is a command written to a CaptureSink
+type CapturedCommand struct {
+	// Name is the readable name from Commands, like "BOLD on"
+	Name string
+	// Data is the bytes of the command
+	Data []byte
+}
+
+// Decoded decodes what was written like Commands, but also keeps the bytes of
+// each command
+func (c *CaptureSink) Decoded() []CapturedCommand {
+	return decodeCapture(c.data)
+}
+
+func decodeCapture(data []byte) []CapturedCommand {
+	var cmds []CapturedCommand
+	textStart := -1
+
+	flushText := func(end int) {
+		if textStart >= 0 {
+			text := data[textStart:end]
+			cmds = append(cmds, CapturedCommand{fmt.Sprintf("PRINT %q", text), text})
+			textStart = -1
+		}
+	}
+
+	for i := 0; i < len(data); {
+		b := data[i]
+
+		switch b {
+		case HT, LF, CR, CAN:
+			flushText(i)
+			name := map[byte]string{HT: "HT", LF: "LF", CR: "CR", CAN: "CAN"}[b]
+			cmds = append(cmds, CapturedCommand{name, data[i : i+1]})
+			i++
+			continue
+		case ESC, GS, DLE:
+			flushText(i)
+			cmd, n := decodeCommand(data[i:])
+			if n == 0 {
+				end := i + 2
+				if end > len(data) {
+					end = len(data)
+				}
+				cmds = append(cmds, CapturedCommand{fmt.Sprintf("RAW % x", data[i:end]), data[i:end]})
+				i = end
+				continue
+			}
+			cmds = append(cmds, CapturedCommand{cmd, data[i : i+n]})
+			i += n
+			continue
+		}
+
+		if b < 0x20 {
+			flushText(i)
+			cmds = append(cmds, CapturedCommand{fmt.Sprintf("RAW %02x", b), data[i : i+1]})
+		} else if textStart < 0 {
+			textStart = i
+		}
+		i++
+	}
+	flushText(len(data))
+
+	return cmds
+}
+
+func decodeCommands(data []byte) []string {
+	var names []string
+	for _, cmd := range decodeCapture(data) {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
+
+// discardConn sends writes to w.  Reads return io.EOF, or zeros when zeros is
+// set so status commands report that everything is fine like CaptureSink.
+type discardConn struct {
+	w     io.Writer
+	zeros bool
+}
+
+func (d discardConn) Write(b []byte) (int, error) {
+	return d.w.Write(b)
+}
+
+func (d discardConn) Read(b []byte) (int, error) {
+	if !d.zeros {
+		return 0, io.EOF
+	}
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+func (d discardConn) Close() error {
+	return nil
+}
+
+// NewNullPrinter returns a printer that throws away everything written to it.
+// Reading from the printer returns io.EOF, so status commands fail.
+func NewNullPrinter() Printer {
+	return NewPrinter(discardConn{w: io.Discard})
+}
+
+// NewBufferPrinter returns a printer that writes to the buffer.  Reading from
+// the printer returns zeros instead of reading back the buffer, so status
+// commands and the image methods that wait on them work, and closing it does
+// nothing.
+func NewBufferPrinter() (*bytes.Buffer, Printer) {
+	buf := &bytes.Buffer{}
+	return buf, NewPrinter(discardConn{w: buf, zeros: true})
+}
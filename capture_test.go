@@ -0,0 +1,103 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestNewBufferPrinterImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+
+	buf, printer := escpos.NewBufferPrinter()
+	err := printer.PrintImageRaster(img, escpos.RasterNormal)
+	if err != nil {
+		t.Fatalf("could not print image on a buffer printer: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatalf("image was not written to the buffer")
+	}
+
+	err = printer.Sync()
+	if err != nil {
+		t.Fatalf("could not sync a buffer printer: %v", err)
+	}
+}
+
+func TestNewNullPrinter(t *testing.T) {
+	printer := escpos.NewNullPrinter()
+
+	n, err := printer.Write([]byte("discarded"))
+	if err != nil || n != len("discarded") {
+		t.Fatalf("write returned %d, %v instead of %d, nil", n, err, len("discarded"))
+	}
+
+	_, err = printer.Read(make([]byte, 1))
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("read returned %v instead of io.EOF", err)
+	}
+
+	err = printer.Close()
+	if err != nil {
+		t.Fatalf("close returned %v", err)
+	}
+}
+
+func TestCaptureCommands(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	steps := []func() error{
+		printer.Initialize,
+		func() error { return printer.SetBold(true) },
+		func() error { return printer.Justify(escpos.CenterJustify) },
+		func() error { return printer.Println("Cafe") },
+		func() error { return printer.SetBold(false) },
+		func() error { return printer.Justify(escpos.LeftJustify) },
+		func() error { return printer.Print("Coffee\t3.50") },
+		func() error { return printer.FeedLines(3) },
+		// ESC Z isn't known so it is shown as hex
+		func() error { _, err := printer.Write([]byte{escpos.ESC, 'Z', 0x02}); return err },
+		printer.PartialCut,
+	}
+	for _, step := range steps {
+		err := step()
+		if err != nil {
+			t.Fatalf("could not print the receipt: %v", err)
+		}
+	}
+
+	want := []string{
+		"INITIALIZE",
+		"BOLD on",
+		"JUSTIFY center",
+		`PRINT "Cafe"`,
+		"LF",
+		"BOLD off",
+		"JUSTIFY left",
+		`PRINT "Coffee"`,
+		"HT",
+		`PRINT "3.50"`,
+		"FEED LINES 3",
+		"RAW 1b 5a",
+		"RAW 02",
+		"PARTIAL CUT",
+	}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("decoded\n%q\ninstead of\n%q", got, want)
+	}
+
+	decoded := sink.Decoded()
+	if len(decoded) != len(want) || !bytes.Equal(decoded[1].Data, []byte{escpos.ESC, 'E', 1}) {
+		t.Fatalf("decoded bytes were %v", decoded)
+	}
+
+	sink.Reset()
+	if len(sink.Bytes()) != 0 || len(sink.Commands()) != 0 {
+		t.Fatalf("reset left %q", sink.Commands())
+	}
+}
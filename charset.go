@@ -0,0 +1,205 @@
+package escpos
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// CodePage is the character code table used for bytes 0x80 to 0xFF
+type CodePage int
+
+const (
+	CP437    CodePage = 0  // USA, Standard Europe
+	Katakana CodePage = 1  // Japanese Katakana
+	CP850    CodePage = 2  // Multilingual
+	CP860    CodePage = 3  // Portuguese
+	CP863    CodePage = 4  // Canadian-French
+	CP865    CodePage = 5  // Nordic
+	CP1252   CodePage = 16 // Windows Latin 1
+	CP866    CodePage = 17 // Cyrillic #2
+	CP852    CodePage = 18 // Latin 2
+	CP858    CodePage = 19 // Euro
+)
+
+var allCodePages = []CodePage{CP437, Katakana, CP850, CP860, CP863, CP865, CP1252, CP866, CP852, CP858}
+
+var codePageNames = map[CodePage]string{
+	CP437:    "CP437",
+	Katakana: "Katakana",
+	CP850:    "CP850",
+	CP860:    "CP860",
+	CP863:    "CP863",
+	CP865:    "CP865",
+	CP1252:   "CP1252",
+	CP866:    "CP866",
+	CP852:    "CP852",
+	CP858:    "CP858",
+}
+
+// normalizeCodePageName lower cases the name and drops the punctuation and
+// the cp, ibm, pc, or windows prefix, so "CP850", "cp-850", and "IBM850" are
+// all "850"
+func normalizeCodePageName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+
+	for _, prefix := range []string{"windows", "ibm", "pc", "cp"} {
+		if strings.HasPrefix(name, prefix) {
+			return strings.TrimPrefix(name, prefix)
+		}
+	}
+	return name
+}
+
+// InternationalCharset swaps out a few ASCII characters like # $ @ [ ] for
+// characters used in the selected country
+type InternationalCharset int
+
+const (
+	CharsetUSA          InternationalCharset = iota // 0
+	CharsetFrance                                   // 1
+	CharsetGermany                                  // 2
+	CharsetUK                                       // 3
+	CharsetDenmarkI                                 // 4
+	CharsetSweden                                   // 5
+	CharsetItaly                                    // 6
+	CharsetSpainI                                   // 7
+	CharsetJapan                                    // 8
+	CharsetNorway                                   // 9
+	CharsetDenmarkII                                // 10
+	CharsetSpainII                                  // 11
+	CharsetLatinAmerica                             // 12
+	CharsetKorea                                    // 13
+	CharsetSlovenia                                 // 14
+	CharsetChina                                    // 15
+)
+
+// SetCodePage selects the character code table with ESC t n
+//
+// This only sends the command, the text printed afterwards has to already
+// be encoded in the code page.
+//
+//	CP437: 0
+//	Katakana: 1
+//	CP850: 2
+//	CP860: 3
+//	CP863: 4
+//	CP865: 5
+//	CP1252: 16
+//	CP866: 17
+//	CP852: 18
+//	CP858: 19
+func (p Printer) SetCodePage(cp CodePage) error {
+	errMsg := "could not set code page to %v: %w"
+
+	err := checkEnum(cp, allCodePages...)
+	if err != nil {
+		return fmt.Errorf(errMsg, cp, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.Write([]byte{ESC, 't', byte(cp)})
+	if err != nil {
+		return fmt.Errorf(errMsg, cp, err)
+	}
+
+	if p.config != nil {
+		p.config.codePage = cp
+	}
+	return nil
+}
+
+// SetCodePageByName selects the code page with its name, like "CP850" or
+// "windows-1252", with SetCodePage.  Case, dashes, underscores, and spaces
+// are ignored, and the cp, IBM, PC, and windows prefixes are the same, so
+// "cp-850" and "IBM850" are CP850.
+func (p Printer) SetCodePageByName(name string) error {
+	normalized := normalizeCodePageName(name)
+
+	var names []string
+	for _, cp := range allCodePages {
+		if normalizeCodePageName(codePageNames[cp]) == normalized {
+			return p.SetCodePage(cp)
+		}
+		names = append(names, codePageNames[cp])
+	}
+
+	return fmt.Errorf("could not set code page to %q: the supported code pages are %s", name, strings.Join(names, ", "))
+}
+
+// SelectInternationalCharset selects the international character set with
+// ESC R n.  The value of n is the order of the constants starting with
+// CharsetUSA at 0 and ending with CharsetChina at 15.
+func (p Printer) SelectInternationalCharset(c InternationalCharset) error {
+	errMsg := "could not select international character set: %w"
+
+	err := checkRange(int(c), int(CharsetUSA), int(CharsetChina), "character set")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'R', byte(c)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetEncoding sets the charmap used by Print, Println, and Printf to convert
+// the UTF-8 text into the code page selected with SetCodePage, for example
+// charmap.CodePage850 for CP850.  Runes that are not in the code page are
+// replaced with the byte from SetEncodingSubstitute.
+//
+// Setting enc to nil sends text as is, which is the default.
+func (p Printer) SetEncoding(enc *charmap.Charmap) {
+	if p.config == nil {
+		return
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.encoder = enc
+}
+
+// SetEncodingSubstitute sets the byte that replaces runes the charmap from
+// SetEncoding can't encode.  A substitute of 0 uses '?', which is the
+// default.
+func (p Printer) SetEncodingSubstitute(substitute byte) {
+	if p.config == nil {
+		return
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.substitute = substitute
+}
+
+// appendEncoded converts s to bytes with the encoder set by SetEncoding and
+// appends them to data
+func (p Printer) appendEncoded(data []byte, s string) []byte {
+	if p.config == nil || p.config.encoder == nil {
+		return append(data, s...)
+	}
+
+	for _, r := range s {
+		b, ok := p.config.encoder.EncodeRune(r)
+		if !ok {
+			b = p.config.substitute
+			if b == 0 {
+				b = '?'
+			}
+		}
+		data = append(data, b)
+	}
+	return data
+}
@@ -0,0 +1,103 @@
+package escpos_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestSetEncoding(t *testing.T) {
+	var nilCharmap *charmap.Charmap
+
+	cases := []struct {
+		name       string
+		enc        *charmap.Charmap
+		substitute byte
+		text       string
+		want       []byte
+	}{
+		{"cp850", charmap.CodePage850, 0, "ñüé", []byte{0xA4, 0x81, 0x82}},
+		{"ascii", charmap.CodePage850, 0, "abc", []byte("abc")},
+		{"missing rune", charmap.CodePage850, 0, "a€b", []byte("a?b")},
+		{"missing 4 byte rune", charmap.CodePage850, 0, "a😀b", []byte("a?b")},
+		{"substitute", charmap.CodePage850, '*', "a€b", []byte("a*b")},
+		{"no encoding", nil, 0, "ñ", []byte("ñ")},
+		{"nil charmap", nilCharmap, 0, "ñ", []byte("ñ")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+			printer.SetEncoding(c.enc)
+			printer.SetEncodingSubstitute(c.substitute)
+
+			err := printer.Print(c.text)
+			if err != nil {
+				t.Fatalf("could not print: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestSetEncodingRoundTrip(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+	printer.SetEncoding(charmap.CodePage850)
+
+	err := printer.Print("ñüé")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	decoded, err := charmap.CodePage850.NewDecoder().Bytes(sink.Bytes())
+	if err != nil {
+		t.Fatalf("could not decode: %v", err)
+	}
+	if string(decoded) != "ñüé" {
+		t.Fatalf("decoded %q instead of \"ñüé\"", decoded)
+	}
+}
+
+func TestSetCodePageByName(t *testing.T) {
+	cases := []struct {
+		name string
+		want escpos.CodePage
+	}{
+		{"CP850", escpos.CP850},
+		{"cp-850", escpos.CP850},
+		{"IBM 437", escpos.CP437},
+		{"windows-1252", escpos.CP1252},
+		{"Windows_1252", escpos.CP1252},
+		{"katakana", escpos.Katakana},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetCodePageByName(c.name)
+			if err != nil {
+				t.Fatalf("could not set code page: %v", err)
+			}
+
+			want := []byte{escpos.ESC, 't', byte(c.want)}
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSetCodePageByNameUnknown(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+
+	err := printer.SetCodePageByName("EBCDIC")
+	if err == nil || !strings.Contains(err.Error(), "CP437, Katakana, CP850") {
+		t.Fatalf("unknown code page should list the supported names, got %v", err)
+	}
+}
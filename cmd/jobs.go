@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// Job prints one whole job like a receipt
+type Job func(escpos.Printer) error
+
+// jobConn collects the bytes of a job.  Reads answer with zeros so status
+// commands, like the ones the image methods wait on, report that everything
+// is fine.
+type jobConn struct {
+	*bytes.Buffer
+}
+
+func (jobConn) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+func (jobConn) Close() error { return nil }
+
+// buildJob runs the job on a printer with the profile that writes to a buffer
+// so the whole job can be sent at once
+func buildJob(profile escpos.Profile, job Job) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := job(escpos.NewPrinterWithProfile(jobConn{buf}, profile))
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RoundRobinPrinter sends each job to the next printer in turn to spread the
+// jobs out over the printers
+type RoundRobinPrinter struct {
+	mu   *sync.Mutex
+	dst  []escpos.Printer
+	next *int
+}
+
+// NewRoundRobinPrinter sends the first job to the first printer, the second
+// job to the second printer, and so on
+func NewRoundRobinPrinter(printers ...escpos.Printer) RoundRobinPrinter {
+	return RoundRobinPrinter{mu: &sync.Mutex{}, dst: printers, next: new(int)}
+}
+
+// Print builds the job and sends it to the next printer.  The job is built
+// before anything is sent, so a job that fails doesn't print anything and
+// doesn't use up a turn.
+//
+// The job is run on a printer that writes to a buffer with the profile of the
+// printer it will be sent to.  Reading from it returns zeros instead of
+// reading from the printer, and settings like the encoding from SetEncoding
+// need to be set in the job.
+func (rr RoundRobinPrinter) Print(job Job) error {
+	errMsg := "could not print round robin job: %w"
+
+	if len(rr.dst) == 0 {
+		return fmt.Errorf(errMsg, errors.New("there are no printers"))
+	}
+
+	rr.mu.Lock()
+	i := *rr.next
+	printer := rr.dst[i]
+
+	data, err := buildJob(printer.Profile(), job)
+	if err != nil {
+		rr.mu.Unlock()
+		return fmt.Errorf(errMsg, err)
+	}
+	*rr.next = (i + 1) % len(rr.dst)
+	rr.mu.Unlock()
+
+	_, err = printer.Write(data)
+	if err == nil {
+		err = printer.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf(errMsg, fmt.Errorf("printer %d: %w", i, err))
+	}
+	return nil
+}
+
+// FailoverPrinter sends each job to the first printer, and only moves on to
+// the next printer when sending the job fails
+type FailoverPrinter struct {
+	dst []escpos.Printer
+}
+
+// NewFailoverPrinter uses the first printer as the primary and the rest as
+// the fallbacks in order
+func NewFailoverPrinter(printers ...escpos.Printer) FailoverPrinter {
+	return FailoverPrinter{dst: printers}
+}
+
+// Print builds the job and sends it to the printers in order until one of
+// them takes it.  The job is built again for each printer it is tried on, the
+// same way as RoundRobinPrinter.Print(), and a job that fails to build isn't
+// tried on the other printers.  When every printer fails the errors are
+// joined together.
+//
+// A printer that fails part of the way through can print part of the job
+// before the whole job is sent to the next printer.
+func (fp FailoverPrinter) Print(job Job) error {
+	errMsg := "could not print failover job: %w"
+
+	if len(fp.dst) == 0 {
+		return fmt.Errorf(errMsg, errors.New("there are no printers"))
+	}
+
+	var errs []error
+	for i, printer := range fp.dst {
+		data, err := buildJob(printer.Profile(), job)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		_, err = printer.Write(data)
+		if err == nil {
+			err = printer.Flush()
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("printer %d: %w", i, err))
+	}
+
+	return fmt.Errorf(errMsg, errors.Join(errs...))
+}
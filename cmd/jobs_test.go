@@ -0,0 +1,86 @@
+package cmd_test
+
+import (
+	"errors"
+	"image"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+	"github.com/joeyak/go-escpos/cmd"
+)
+
+var errBroken = errors.New("broken connection")
+
+// brokenConn fails every read and write
+type brokenConn struct{}
+
+func (brokenConn) Read([]byte) (int, error)  { return 0, errBroken }
+func (brokenConn) Write([]byte) (int, error) { return 0, errBroken }
+func (brokenConn) Close() error              { return nil }
+
+func imageJob(p escpos.Printer) error {
+	return p.PrintImageRaster(image.NewGray(image.Rect(0, 0, 16, 8)), escpos.RasterNormal)
+}
+
+func TestRoundRobinPrinter(t *testing.T) {
+	job := func(p escpos.Printer) error { return p.Println("Job") }
+
+	bufA, printerA := escpos.NewBufferPrinter()
+	bufB, printerB := escpos.NewBufferPrinter()
+	rr := cmd.NewRoundRobinPrinter(printerA, printerB)
+	for i := 0; i < 3; i++ {
+		err := rr.Print(job)
+		if err != nil {
+			t.Fatalf("job %d failed: %v", i, err)
+		}
+	}
+
+	if bufA.String() != "Job\nJob\n" || bufB.String() != "Job\n" {
+		t.Fatalf("round robin jobs were %q and %q, expected 2 jobs then 1 job", bufA, bufB)
+	}
+}
+
+func TestRoundRobinPrinterImage(t *testing.T) {
+	buf, printer := escpos.NewBufferPrinter()
+	err := cmd.NewRoundRobinPrinter(printer).Print(imageJob)
+	if err != nil {
+		t.Fatalf("image job failed: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatalf("image job was not sent")
+	}
+}
+
+func TestFailoverPrinter(t *testing.T) {
+	cases := []struct {
+		name string
+		job  cmd.Job
+	}{
+		{"text", func(p escpos.Printer) error { return p.Println("Job") }},
+		{"image", imageJob},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf, printer := escpos.NewBufferPrinter()
+			failover := cmd.NewFailoverPrinter(escpos.NewPrinter(brokenConn{}), printer)
+
+			err := failover.Print(c.job)
+			if err != nil {
+				t.Fatalf("job failed: %v", err)
+			}
+			if buf.Len() == 0 {
+				t.Fatalf("job did not skip the broken printer")
+			}
+		})
+	}
+}
+
+func TestFailoverPrinterAllBroken(t *testing.T) {
+	failover := cmd.NewFailoverPrinter(escpos.NewPrinter(brokenConn{}), escpos.NewPrinter(brokenConn{}))
+
+	err := failover.Print(func(p escpos.Printer) error { return p.Println("Job") })
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("error was %v instead of the printer errors", err)
+	}
+}
@@ -1,43 +1,202 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
 	"github.com/joeyak/go-escpos"
 )
 
 type MultiPrinter struct {
-	dst []escpos.Printer
+	dst        []escpos.Printer
+	bestEffort bool
+	parallel   bool
 }
 
+// NewMultiPrinter writes to every printer and stops at the first printer that
+// fails
 func NewMultiPrinter(printers ...escpos.Printer) MultiPrinter {
 	return MultiPrinter{dst: printers}
 }
 
-func (mp MultiPrinter) Read(p []byte) (n int, err error) {
-	for _, printer := range mp.dst {
-		n, err := printer.Read(p)
-		if err != nil {
-			return n, err
+// NewMultiPrinterBestEffort writes to every printer even when some of them
+// fail.  The failures are joined together in the returned error.
+func NewMultiPrinterBestEffort(printers ...escpos.Printer) MultiPrinter {
+	return MultiPrinter{dst: printers, bestEffort: true}
+}
+
+// NewParallelMultiPrinter writes to every printer at the same time, so
+// sending a big image to many printers takes about as long as one.  Each
+// write finishes on every printer before the next write starts, so the bytes
+// still get to each printer in order.  Every printer is written to even when
+// some of them fail, and the failures are joined together in the returned
+// error.
+func NewParallelMultiPrinter(printers ...escpos.Printer) MultiPrinter {
+	return MultiPrinter{dst: printers, parallel: true}
+}
+
+// Read reads from every printer at the same time and returns the first
+// answer that has any bytes, so one slow or offline printer doesn't hold up
+// a status command like TransmitErrorStatus().
+//
+// The reads that are still going are stopped with ReadContext once a printer
+// answers, and Read waits for them so no printer is left locked by a read
+// that never finishes.  A printer that answers later has its answer returned
+// by its next read.  When no printer answers, the failures are joined
+// together in the returned error.
+func (mp MultiPrinter) Read(p []byte) (int, error) {
+	type result struct {
+		data []byte
+		err  error
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan result, len(mp.dst))
+	for i, printer := range mp.dst {
+		go func(i int, printer escpos.Printer) {
+			buf := make([]byte, len(p))
+			n, err := printer.ReadContext(ctx, buf)
+			if err != nil {
+				err = fmt.Errorf("printer %d: %w", i, err)
+			}
+			results <- result{buf[:n], err}
+		}(i, printer)
+	}
+
+	var n int
+	var answered bool
+	var errs []error
+	for range mp.dst {
+		r := <-results
+		if answered {
+			continue
+		}
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		if len(r.data) > 0 {
+			n = copy(p, r.data)
+			answered = true
+			cancel()
 		}
 	}
-	return 0, nil
+
+	if answered {
+		return n, nil
+	}
+	return 0, errors.Join(errs...)
 }
 
+// Write writes p to every printer
+//
+// In best effort mode, n is len(p) as long as one printer was written to.  In
+// parallel mode, n is len(p) only when every printer was written to.
 func (mp MultiPrinter) Write(p []byte) (n int, err error) {
-	for _, printer := range mp.dst {
+	if mp.parallel {
+		return mp.writeParallel(p)
+	}
+
+	var errs []error
+	for i, printer := range mp.dst {
 		n, err := printer.Write(p)
 		if err != nil {
-			return n, err
+			if !mp.bestEffort {
+				return n, err
+			}
+			errs = append(errs, fmt.Errorf("printer %d: %w", i, err))
 		}
 	}
-	return 0, nil
+
+	if len(errs) > 0 && len(errs) == len(mp.dst) {
+		return 0, errors.Join(errs...)
+	}
+	return len(p), errors.Join(errs...)
+}
+
+func (mp MultiPrinter) writeParallel(p []byte) (int, error) {
+	errs := make([]error, len(mp.dst))
+
+	var wg sync.WaitGroup
+	for i, printer := range mp.dst {
+		wg.Add(1)
+		go func(i int, printer escpos.Printer) {
+			defer wg.Done()
+
+			_, err := printer.Write(p)
+			if err != nil {
+				errs[i] = fmt.Errorf("printer %d: %w", i, err)
+			}
+		}(i, printer)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
+// Close closes every printer, even when some of them fail, so one bad
+// connection doesn't keep the others open.  The failures are joined together
+// in the returned error.
 func (mp MultiPrinter) Close() error {
-	for _, printer := range mp.dst {
+	var errs []error
+	for i, printer := range mp.dst {
 		err := printer.Close()
 		if err != nil {
-			return err
+			errs = append(errs, fmt.Errorf("printer %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RenderTarget is a printer and the profile documents are laid out with for
+// it.  A zero Profile uses the profile of the printer.
+type RenderTarget struct {
+	Printer escpos.Printer
+	Profile escpos.Profile
+}
+
+// MultiRender prints the document on every target.  MultiPrinter sends the
+// same bytes to every printer, so a layout made for one paper width is wrong
+// on the others.  Here the document is built separately for each target with
+// its profile, so wrapped text, dividers, and columns fit each printer.
+//
+// Each document is built before it is sent the same way as
+// RoundRobinPrinter.Print(), so a target whose document fails to build
+// doesn't print anything.  Every target is printed to even when some of them
+// fail, and the failures are joined together in the returned error.
+func MultiRender(doc escpos.Document, targets ...RenderTarget) error {
+	errMsg := "could not render document: %w"
+
+	var errs []error
+	for i, target := range targets {
+		profile := target.Profile
+		if profile == (escpos.Profile{}) {
+			profile = target.Printer.Profile()
+		}
+
+		data, err := buildJob(profile, func(p escpos.Printer) error { return p.PrintDocument(doc) })
+		if err == nil {
+			_, err = target.Printer.Write(data)
+		}
+		if err == nil {
+			err = target.Printer.Flush()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("printer %d: %w", i, err))
 		}
 	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
 	return nil
 }
@@ -0,0 +1,352 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+	"github.com/joeyak/go-escpos/cmd"
+)
+
+// bufferConn reads back what is in the buffer
+type bufferConn struct {
+	*bytes.Buffer
+}
+
+func (bufferConn) Close() error { return nil }
+
+// silentConn never answers a read until it is closed
+type silentConn struct {
+	*io.PipeReader
+}
+
+func (silentConn) Write(b []byte) (int, error) { return len(b), nil }
+
+func TestMultiPrinterRead(t *testing.T) {
+	silent, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+
+	cases := []struct {
+		name     string
+		printers []escpos.Printer
+		want     string
+		wantErr  error
+	}{
+		{
+			name: "first printer answers",
+			printers: []escpos.Printer{
+				escpos.NewPrinter(bufferConn{bytes.NewBufferString("ab")}),
+				escpos.NewPrinter(bufferConn{&bytes.Buffer{}}),
+			},
+			want: "ab",
+		},
+		{
+			name: "second printer answers",
+			printers: []escpos.Printer{
+				escpos.NewPrinter(bufferConn{&bytes.Buffer{}}),
+				escpos.NewPrinter(bufferConn{bytes.NewBufferString("cd")}),
+			},
+			want: "cd",
+		},
+		{
+			name: "silent printer is not waited on",
+			printers: []escpos.Printer{
+				escpos.NewPrinter(silentConn{silent}),
+				escpos.NewPrinter(bufferConn{bytes.NewBufferString("ef")}),
+			},
+			want: "ef",
+		},
+		{
+			name: "broken printer is skipped",
+			printers: []escpos.Printer{
+				escpos.NewPrinter(brokenConn{}),
+				escpos.NewPrinter(bufferConn{bytes.NewBufferString("gh")}),
+			},
+			want: "gh",
+		},
+		{
+			name: "every printer fails",
+			printers: []escpos.Printer{
+				escpos.NewPrinter(brokenConn{}),
+				escpos.NewPrinter(brokenConn{}),
+			},
+			wantErr: errBroken,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b := make([]byte, 4)
+			n, err := cmd.NewMultiPrinter(c.printers...).Read(b)
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("error was %v instead of %v", err, c.wantErr)
+			}
+			if string(b[:n]) != c.want {
+				t.Fatalf("read %q instead of %q", b[:n], c.want)
+			}
+		})
+	}
+}
+
+// waitingConn lets started know when a read is waiting on the pipe
+type waitingConn struct {
+	silentConn
+	started chan struct{}
+}
+
+func (c waitingConn) Read(b []byte) (int, error) {
+	close(c.started)
+	return c.silentConn.Read(b)
+}
+
+// lateConn answers a read with data once started is closed
+type lateConn struct {
+	bufferConn
+	started chan struct{}
+}
+
+func (c lateConn) Read(b []byte) (int, error) {
+	<-c.started
+	return c.bufferConn.Read(b)
+}
+
+func TestMultiPrinterReadReleasesSilentPrinter(t *testing.T) {
+	silent, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+
+	// The other printer only answers once the silent printer is locked by
+	// its read
+	started := make(chan struct{})
+	silentPrinter := escpos.NewPrinter(waitingConn{silentConn{silent}, started})
+	mp := cmd.NewMultiPrinter(silentPrinter, escpos.NewPrinter(lateConn{bufferConn{bytes.NewBufferString("ab")}, started}))
+
+	b := make([]byte, 2)
+	_, err := mp.Read(b)
+	if err != nil {
+		t.Fatalf("could not read: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := silentPrinter.Write([]byte("after"))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("could not write after the read: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("write to the silent printer blocked after the read")
+	}
+
+	// The silent printer answering late is kept for its next read
+	go w.Write([]byte("cd"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	n, err := silentPrinter.ReadContext(ctx, b)
+	if err != nil || string(b[:n]) != "cd" {
+		t.Fatalf("read %q, %v instead of the late answer", b[:n], err)
+	}
+}
+
+func TestMultiRender(t *testing.T) {
+	text := "The same document is laid out for each printer so the lines wrap to the width of its paper"
+	doc := escpos.Document{Elements: []escpos.Element{
+		escpos.TextElement{Text: text, Wrap: true},
+		escpos.DividerElement{},
+	}}
+
+	narrow, narrowPrinter := escpos.NewCapturePrinter()
+	wide, widePrinter := escpos.NewCapturePrinter()
+
+	err := cmd.MultiRender(doc,
+		cmd.RenderTarget{Printer: narrowPrinter, Profile: escpos.ProfileGeneric58},
+		cmd.RenderTarget{Printer: widePrinter, Profile: escpos.ProfileHoin},
+	)
+	if err != nil {
+		t.Fatalf("could not render document: %v", err)
+	}
+
+	lines := func(data []byte) []string {
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	narrowLines, wideLines := lines(narrow.Bytes()), lines(wide.Bytes())
+	if len(narrowLines) <= len(wideLines) {
+		t.Fatalf("58mm printer got %d lines which is not more than %d on the 80mm printer", len(narrowLines), len(wideLines))
+	}
+
+	for _, c := range []struct {
+		lines []string
+		width int
+	}{
+		{narrowLines, escpos.ProfileGeneric58.FontAColumns},
+		{wideLines, escpos.ProfileHoin.FontAColumns},
+	} {
+		for _, line := range c.lines {
+			if len(line) > c.width {
+				t.Errorf("line %q is longer than %d columns", line, c.width)
+			}
+		}
+
+		divider := c.lines[len(c.lines)-1]
+		if len(divider) != c.width {
+			t.Errorf("divider was %d columns instead of %d", len(divider), c.width)
+		}
+	}
+}
+
+func TestMultiRenderImage(t *testing.T) {
+	doc := escpos.Document{Elements: []escpos.Element{
+		escpos.ImageElement{Image: image.NewGray(image.Rect(0, 0, 16, 8))},
+	}}
+
+	narrow, narrowPrinter := escpos.NewCapturePrinter()
+	wide, widePrinter := escpos.NewCapturePrinter()
+
+	err := cmd.MultiRender(doc,
+		cmd.RenderTarget{Printer: narrowPrinter, Profile: escpos.ProfileGeneric58},
+		cmd.RenderTarget{Printer: widePrinter, Profile: escpos.ProfileHoin},
+	)
+	if err != nil {
+		t.Fatalf("could not render image: %v", err)
+	}
+
+	for _, sink := range []*escpos.CaptureSink{narrow, wide} {
+		commands := strings.Join(sink.Commands(), ", ")
+		if !strings.Contains(commands, "RASTER IMAGE mode 0 16x8") {
+			t.Errorf("image was not printed, the commands were %s", commands)
+		}
+	}
+}
+
+// slowConn takes a while for each write like a printer on a slow network
+type slowConn struct {
+	bufferConn
+	delay time.Duration
+}
+
+func (c slowConn) Write(b []byte) (int, error) {
+	time.Sleep(c.delay)
+	return c.bufferConn.Write(b)
+}
+
+func TestParallelMultiPrinter(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	var bufs []*bytes.Buffer
+	var printers []escpos.Printer
+	for i := 0; i < 5; i++ {
+		buf := &bytes.Buffer{}
+		bufs = append(bufs, buf)
+		printers = append(printers, escpos.NewPrinter(slowConn{bufferConn{buf}, delay}))
+	}
+	parallel := escpos.NewPrinter(cmd.NewParallelMultiPrinter(printers...))
+
+	start := time.Now()
+	for _, line := range []string{"first", "second"} {
+		err := parallel.Println(line)
+		if err != nil {
+			t.Fatalf("could not print %s line: %v", line, err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// Two writes to 5 printers should take about as long as two writes
+	if elapsed > 4*delay {
+		t.Fatalf("writing took %s, which is more than writing to each printer one at a time", elapsed)
+	}
+
+	for i, buf := range bufs {
+		if buf.String() != "first\nsecond\n" {
+			t.Fatalf("printer %d got %q", i, buf)
+		}
+	}
+}
+
+func TestParallelMultiPrinterErrors(t *testing.T) {
+	first, last := &bytes.Buffer{}, &bytes.Buffer{}
+	parallel := cmd.NewParallelMultiPrinter(
+		escpos.NewPrinter(bufferConn{first}),
+		escpos.NewPrinter(brokenConn{}),
+		escpos.NewPrinter(bufferConn{last}),
+	)
+
+	// One broken printer doesn't stop the others
+	n, err := parallel.Write([]byte("line\n"))
+	if !errors.Is(err, errBroken) || !strings.Contains(err.Error(), "printer 1") {
+		t.Fatalf("write returned %v instead of printer 1 being broken", err)
+	}
+	if n != 0 {
+		t.Fatalf("wrote %d bytes instead of 0", n)
+	}
+	if first.String() != "line\n" || last.String() != "line\n" {
+		t.Fatalf("printers got %q and %q", first, last)
+	}
+}
+
+// closeConn counts how many times it was closed and fails with err
+type closeConn struct {
+	bytes.Buffer
+	closes int
+	err    error
+}
+
+func (c *closeConn) Close() error {
+	c.closes++
+	return c.err
+}
+
+func TestMultiPrinterClose(t *testing.T) {
+	errClose := errors.New("connection reset")
+
+	cases := []struct {
+		name string
+		new  func(...escpos.Printer) cmd.MultiPrinter
+	}{
+		{"in order", cmd.NewMultiPrinter},
+		{"best effort", cmd.NewMultiPrinterBestEffort},
+		{"parallel", cmd.NewParallelMultiPrinter},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conns := []*closeConn{{}, {err: errClose}, {}, {err: errClose}}
+
+			var printers []escpos.Printer
+			for _, conn := range conns {
+				printers = append(printers, escpos.NewPrinter(conn))
+			}
+
+			err := c.new(printers...).Close()
+			if !errors.Is(err, errClose) {
+				t.Fatalf("close returned %v instead of the close errors", err)
+			}
+
+			for _, want := range []string{"printer 1:", "printer 3:"} {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("close error %q is missing %q", err, want)
+				}
+			}
+
+			for i, conn := range conns {
+				if conn.closes != 1 {
+					t.Fatalf("printer %d was closed %d times instead of once", i, conn.closes)
+				}
+			}
+		})
+	}
+
+	err := cmd.NewMultiPrinter(escpos.NewPrinter(&closeConn{}), escpos.NewPrinter(&closeConn{})).Close()
+	if err != nil {
+		t.Fatalf("closing good printers failed: %v", err)
+	}
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alexflint/go-arg"
 	"github.com/joeyak/go-escpos"
@@ -25,7 +23,7 @@ type CmdTabs struct {
 }
 
 type CmdImage struct {
-	Input string `arg:"positional,required" help:"Image file to print.  Currently supports PNG and JPEG image formats."`
+	Input string `arg:"positional,required" help:"Image file to print.  Supports PNG, JPEG, GIF, and BMP images no matter the file extension."`
 }
 
 type CmdCut struct{}
@@ -39,13 +37,31 @@ type CmdSizes struct {
 	Text string `arg:"positional" help:"Sample text to use instead of 'size WxH'."`
 }
 
+type CmdBarcode struct {
+	Data string `arg:"positional,required" help:"Data to put in the bar code."`
+	Type string `arg:"-t,--type" default:"code128" help:"Bar code type.  Valid values are upca, upce, ean13, ean8, code39, itf, codabar, code93, and code128."`
+}
+
+type CmdQR struct {
+	Data string `arg:"positional,required" help:"Data to put in the QR code."`
+	Size int    `arg:"-s,--size" default:"6" help:"Size of each module in dots.  Valid values are 1-16."`
+	EC   string `arg:"-e,--ec" default:"M" help:"Error correction level.  Valid values are L, M, Q, and H."`
+}
+
+type CmdStatus struct {
+	Timeout time.Duration `arg:"-t,--timeout" default:"2s" help:"How long to wait for the printer to respond"`
+}
+
 type Arguments struct {
-	Text  *CmdText  `arg:"subcommand:text"  help:"Print text"`
-	Tabs  *CmdTabs  `arg:"subcommand:tabs"  help:"Print the tabstop locations"`
-	Image *CmdImage `arg:"subcommand:image" help:"Print an image"`
-	Cut   *CmdCut   `arg:"subcommand:cut"   help:"Cut the paper"`
-	Feed  *CmdFeed  `arg:"subcommand:feed"  help:"Feed the paper"`
-	Sizes *CmdSizes `arg:"subcommand:sizes" help:"Print out all character width and height combinations"`
+	Text    *CmdText    `arg:"subcommand:text"    help:"Print text"`
+	Tabs    *CmdTabs    `arg:"subcommand:tabs"    help:"Print the tabstop locations"`
+	Image   *CmdImage   `arg:"subcommand:image"   help:"Print an image"`
+	Cut     *CmdCut     `arg:"subcommand:cut"     help:"Cut the paper"`
+	Feed    *CmdFeed    `arg:"subcommand:feed"    help:"Feed the paper"`
+	Sizes   *CmdSizes   `arg:"subcommand:sizes"   help:"Print out all character width and height combinations"`
+	Barcode *CmdBarcode `arg:"subcommand:barcode" help:"Print a bar code"`
+	QR      *CmdQR      `arg:"subcommand:qr"      help:"Print a QR code"`
+	Status  *CmdStatus  `arg:"subcommand:status"  help:"Show the printer status"`
 
 	Address string `arg:"-a,--addr" help:"IP address and port of printer"`
 	Device  string `arg:"-d,--dev" help:"USB device of printer"`
@@ -213,6 +229,72 @@ func connect(args *Arguments) (*escpos.Printer, error) {
 	return nil, fmt.Errorf("unable to determine printer address")
 }
 
+// status writes a report of the printer status to stdout
+func status(args *Arguments, printer *escpos.Printer) error {
+	printer.SetReadTimeout(args.Status.Timeout)
+
+	var statuses []escpos.Status
+	for _, typ := range []escpos.StatusType{escpos.StatusPrinter, escpos.StatusOffline, escpos.StatusError, escpos.StatusPaperSensor} {
+		s, err := printer.RealtimeStatus(typ)
+		if errors.Is(err, escpos.ErrTimeout) {
+			return fmt.Errorf("printer did not respond within %s", args.Status.Timeout)
+		}
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, s)
+	}
+
+	online, offline, errStatus, paper := statuses[0], statuses[1], statuses[2], statuses[3]
+
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	paperState := "adequate"
+	if paper.PaperEnd {
+		paperState = "end"
+	} else if paper.PaperNearEnd {
+		paperState = "near end"
+	}
+
+	fmt.Printf("Online:              %s\n", yesNo(!online.Offline))
+	fmt.Printf("Cover open:          %s\n", yesNo(offline.CoverOpen))
+	fmt.Printf("Paper:               %s\n", paperState)
+	fmt.Printf("Drawer open:         %s\n", yesNo(online.DrawerOpen))
+	fmt.Printf("Feed button pressed: %s\n", yesNo(offline.FeedButton))
+	fmt.Printf("Printing stopped:    %s\n", yesNo(offline.PrintingStopped))
+	fmt.Printf("Error:               %s\n", yesNo(offline.ErrorOccured))
+	fmt.Printf("  Mechanical:        %s\n", yesNo(errStatus.Mechanical))
+	fmt.Printf("  Auto cutter:       %s\n", yesNo(errStatus.AutoCutter))
+	fmt.Printf("  Unrecoverable:     %s\n", yesNo(errStatus.UnRecoverable))
+	fmt.Printf("  Auto recoverable:  %s\n", yesNo(errStatus.AutoRecoverable))
+
+	return nil
+}
+
+var barcodeTypes = map[string]escpos.BarCode{
+	"upca":    escpos.BcUPCA,
+	"upce":    escpos.BcUPCE,
+	"ean13":   escpos.BcEAN13,
+	"ean8":    escpos.BcEAN8,
+	"code39":  escpos.BcCODE39,
+	"itf":     escpos.BcITF,
+	"codabar": escpos.BcCODABAR,
+	"code93":  escpos.BcCODE93,
+	"code128": escpos.BcCODE128,
+}
+
+var qrErrorCorrections = map[string]escpos.QRErrorCorrection{
+	"L": escpos.QRErrorL,
+	"M": escpos.QRErrorM,
+	"Q": escpos.QRErrorQ,
+	"H": escpos.QRErrorH,
+}
+
 func run(args *Arguments, printer *escpos.Printer) error {
 	switch {
 	case args.Feed != nil:
@@ -293,29 +375,7 @@ func run(args *Arguments, printer *escpos.Printer) error {
 		}
 
 	case args.Image != nil:
-		file, err := os.Open(args.Image.Input)
-		if err != nil {
-			return err
-		}
-		defer file.Close()
-		var img image.Image
-
-		switch filepath.Ext(args.Image.Input) {
-		case ".png":
-			img, err = png.Decode(file)
-
-		case ".jpg", ".jpeg":
-			img, err = jpeg.Decode(file)
-
-		default:
-			return fmt.Errorf("unsupported image format: %s", filepath.Ext(args.Image.Input))
-		}
-
-		if err != nil {
-			return err
-		}
-
-		err = printer.PrintImage24(img, escpos.DoubleDensity)
+		err := printer.PrintImageFile(args.Image.Input, escpos.DoubleDensity)
 		if err != nil {
 			return err
 		}
@@ -344,6 +404,31 @@ func run(args *Arguments, printer *escpos.Printer) error {
 			}
 		}
 
+	case args.Barcode != nil:
+		barcodeType, ok := barcodeTypes[strings.ToLower(args.Barcode.Type)]
+		if !ok {
+			return fmt.Errorf("invalid bar code type %q", args.Barcode.Type)
+		}
+
+		err := printer.PrintBarCode(barcodeType, args.Barcode.Data)
+		if err != nil {
+			return err
+		}
+
+	case args.QR != nil:
+		ec, ok := qrErrorCorrections[strings.ToUpper(args.QR.EC)]
+		if !ok {
+			return fmt.Errorf("invalid error correction level %q", args.QR.EC)
+		}
+
+		err := printer.QRCode(args.QR.Data, escpos.QRModel2, args.QR.Size, ec)
+		if err != nil {
+			return err
+		}
+
+	case args.Status != nil:
+		return status(args, printer)
+
 	default:
 		return fmt.Errorf("Invalid command")
 	}
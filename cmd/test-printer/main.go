@@ -1,7 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
 	"os"
 	"reflect"
 	"runtime"
@@ -81,6 +84,73 @@ type Arguments struct {
 	Addresses []string `arg:"positional" help:"IP address and port of printer or USB device"`
 	Filters   []string `arg:"-f,--filter" help:"the name of the function to test - test<FILTER>"`
 	List      bool     `arg:"--list" help:"print out list of test functions"`
+	SelfTest  bool     `arg:"--self-test" help:"print the printer status self test instead of running the tests"`
+	JSON      bool     `arg:"--json" help:"print the results as JSON"`
+}
+
+type Result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// resultJSON is a Result with the duration as a string like 1.5s
+type resultJSON struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(resultJSON{r.Name, r.Passed, r.Error, r.Duration.String()})
+}
+
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var result resultJSON
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(result.Duration)
+	if err != nil {
+		return fmt.Errorf("could not parse duration of %s: %w", result.Name, err)
+	}
+
+	*r = Result{result.Name, result.Passed, result.Error, duration}
+	return nil
+}
+
+// testName is the name of the test function without the test prefix
+func testName(test func(escpos.Printer) error) string {
+	name := runtime.FuncForPC(reflect.ValueOf(test).Pointer()).Name()
+	return strings.TrimPrefix(name[strings.LastIndex(name, ".")+1:], "test")
+}
+
+// runTests runs the tests that are in filters, or all of them when filters is
+// empty, with run and returns the result of each.  It waits for pause after
+// each test, which isn't counted in the duration.
+func runTests(tests []func(escpos.Printer) error, filters []string, pause time.Duration, run func(i int, name string, test func(escpos.Printer) error) error) []Result {
+	results := []Result{}
+	for i, test := range tests {
+		name := testName(test)
+		if len(filters) > 0 && !slices.Contains(filters, name) {
+			continue
+		}
+
+		start := time.Now()
+		err := run(i, name, test)
+		result := Result{Name: name, Passed: err == nil, Duration: time.Since(start)}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+
+		time.Sleep(pause)
+	}
+	return results
 }
 
 func main() {
@@ -102,45 +172,78 @@ func main() {
 		testJustify,
 		testFeed,
 		testFeedLines,
+		testQRCode,
+		testBarCode,
+		testImage24,
+		testImageRaster,
+		testCashDrawer,
+		testCut,
+	}
+
+	if args.SelfTest {
+		printer, err := connect(args.Addresses)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer printer.Close()
+
+		err = printer.SelfTest(escpos.SelfTestStatus)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	if args.List {
 		fmt.Println("Test Filters:")
 		for _, test := range tests {
-			fmt.Println(strings.TrimPrefix(runtime.FuncForPC(reflect.ValueOf(test).Pointer()).Name(), "main.test"))
+			fmt.Println(testName(test))
 		}
 		return
 	}
 
-	var errors []error
-
-	for i, test := range tests {
-		testName := strings.TrimPrefix(runtime.FuncForPC(reflect.ValueOf(test).Pointer()).Name(), "main.test")
-
-		if len(args.Filters) > 0 && !slices.Contains(args.Filters, testName) {
-			continue
+	results := runTests(tests, args.Filters, time.Millisecond*250, func(i int, name string, test func(escpos.Printer) error) error {
+		if !args.JSON {
+			fmt.Printf("Running test [%d/%d] %s - ", i+1, len(tests), name)
 		}
 
-		fmt.Printf("Running test [%d/%d] %s - ", i+1, len(tests), testName)
-
-		err := runTest(args.Addresses, testName, test)
-		if err != nil {
-			fmt.Println("fail")
-			errors = append(errors, err)
+		err := runTest(args.Addresses, name, test)
+		if !args.JSON {
+			if err != nil {
+				fmt.Println("fail")
+			} else {
+				fmt.Println("pass")
+			}
 		}
+		return err
+	})
 
-		fmt.Println("pass")
-
-		time.Sleep(time.Millisecond * 250)
+	var failed []Result
+	for _, result := range results {
+		if !result.Passed {
+			failed = append(failed, result)
+		}
 	}
 
 	cleanup(args.Addresses)
 
-	if len(errors) > 0 {
-		fmt.Printf("%d errors occured\n", len(errors))
-		for _, err := range errors {
+	if args.JSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
 			fmt.Println(err)
+			os.Exit(1)
 		}
+		fmt.Println(string(data))
+	} else if len(failed) > 0 {
+		fmt.Printf("%d errors occured\n", len(failed))
+		for _, result := range failed {
+			fmt.Println(result.Error)
+		}
+	}
+
+	if len(failed) > 0 {
 		os.Exit(1)
 	}
 
@@ -398,3 +501,121 @@ func testFeedLines(printer escpos.Printer) error {
 
 	return nil
 }
+
+func testQRCode(printer escpos.Printer) error {
+	for _, size := range []int{3, 6} {
+		err := printer.QRCode("https://github.com/joeyak/go-escpos", escpos.QRModel2, size, escpos.QRErrorM)
+		if err != nil {
+			return err
+		}
+
+		err = printer.Printf("Size %d\n", size)
+		if err != nil {
+			return fmt.Errorf("could not print size %d label: %w", size, err)
+		}
+	}
+
+	return nil
+}
+
+func testBarCode(printer escpos.Printer) error {
+	defer printer.ResetBarCodeHeight()
+	defer printer.SetBarCodeWidth(3)
+	defer printer.SetHRIPosition(escpos.HRINone)
+
+	err := printer.SetHRIPosition(escpos.HRIBelow)
+	if err != nil {
+		return err
+	}
+
+	err = printer.SetBarCodeHeight(80)
+	if err != nil {
+		return err
+	}
+
+	for _, width := range []int{2, 3} {
+		err = printer.SetBarCodeWidth(width)
+		if err != nil {
+			return err
+		}
+
+		err = printer.PrintBarCode(escpos.BcEAN13, "400638133393")
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, data := range []string{"{BGo-escpos", "{C123456", "{BSKU{C1234"} {
+		err = printer.PrintBarCode(escpos.BcCODE128, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func testImage24(printer escpos.Printer) error {
+	defer printer.ResetLineSpacing()
+
+	for _, density := range []escpos.Density{escpos.SingleDensity, escpos.DoubleDensity} {
+		err := printer.PrintImage24(checkerboard(200, 60, 8), density)
+		if err != nil {
+			return fmt.Errorf("could not print image with density %d: %w", density, err)
+		}
+	}
+
+	return nil
+}
+
+func testImageRaster(printer escpos.Printer) error {
+	for _, mode := range []escpos.RasterMode{escpos.RasterNormal, escpos.RasterDoubleWidth, escpos.RasterDoubleHeight, escpos.RasterQuadruple} {
+		// 100 pixels wide to check the rows get padded
+		err := printer.PrintImageRaster(checkerboard(100, 40, 8), mode)
+		if err != nil {
+			return fmt.Errorf("could not print raster image with mode %d: %w", mode, err)
+		}
+	}
+
+	return nil
+}
+
+func testCashDrawer(printer escpos.Printer) error {
+	for name, pin := range map[int]escpos.DrawerPin{2: escpos.DrawerPin2, 5: escpos.DrawerPin5} {
+		err := printer.OpenCashDrawer(pin, 100*time.Millisecond, 100*time.Millisecond)
+		if err != nil {
+			return err
+		}
+
+		err = printer.Printf("Kicked drawer pin %d\n", name)
+		if err != nil {
+			return fmt.Errorf("could not print drawer pin: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func testCut(printer escpos.Printer) error {
+	err := printer.Println("Cut below this line")
+	if err != nil {
+		return fmt.Errorf("could not print cut line: %w", err)
+	}
+
+	return printer.FeedAndCut(3, escpos.CutPartial)
+}
+
+// checkerboard makes a width x height image of alternating size x size squares
+func checkerboard(width, height, size int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/size+y/size)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 0xFF})
+			}
+		}
+	}
+	return img
+}
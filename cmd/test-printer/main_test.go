@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+)
+
+var errStub = errors.New("stub failed")
+
+func testStubPass(escpos.Printer) error {
+	time.Sleep(5 * time.Millisecond)
+	return nil
+}
+
+func testStubFail(escpos.Printer) error { return errStub }
+
+func TestRunTests(t *testing.T) {
+	tests := []func(escpos.Printer) error{testStubPass, testStubFail}
+	run := func(i int, name string, test func(escpos.Printer) error) error {
+		return test(escpos.Printer{})
+	}
+
+	results := runTests(tests, nil, 0, run)
+	if len(results) != 2 {
+		t.Fatalf("got %d results instead of 2", len(results))
+	}
+
+	pass, fail := results[0], results[1]
+	if pass.Name != "StubPass" || !pass.Passed || pass.Error != "" || pass.Duration < 5*time.Millisecond {
+		t.Fatalf("passing test gave %+v", pass)
+	}
+	if fail.Name != "StubFail" || fail.Passed || fail.Error != errStub.Error() {
+		t.Fatalf("failing test gave %+v", fail)
+	}
+
+	results = runTests(tests, []string{"StubFail"}, 0, run)
+	if len(results) != 1 || results[0].Name != "StubFail" {
+		t.Fatalf("filter gave %+v", results)
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	results := []Result{
+		{Name: "Beep", Passed: true, Duration: 1500 * time.Millisecond},
+		{Name: "Cut", Error: "failed test Cut: no cutter", Duration: 250 * time.Millisecond},
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatalf("could not marshal results: %v", err)
+	}
+
+	want := `[{"name":"Beep","passed":true,"duration":"1.5s"},{"name":"Cut","passed":false,"error":"failed test Cut: no cutter","duration":"250ms"}]`
+	if string(data) != want {
+		t.Fatalf("marshaled\n%s\ninstead of\n%s", data, want)
+	}
+
+	var roundTrip []Result
+	err = json.Unmarshal(data, &roundTrip)
+	if err != nil {
+		t.Fatalf("could not unmarshal results: %v", err)
+	}
+	if !reflect.DeepEqual(roundTrip, results) {
+		t.Fatalf("unmarshaled %+v instead of %+v", roundTrip, results)
+	}
+
+	var result Result
+	if json.Unmarshal([]byte(`{"name":"Beep","passed":true,"duration":"soon"}`), &result) == nil {
+		t.Fatal("a bad duration did not fail")
+	}
+}
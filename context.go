@@ -0,0 +1,91 @@
+package escpos
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// NewPrinterWithContext creates a printer that stops every read and write to
+// the connection when the context is done, and fails with ctx.Err().  Once
+// the context is done every method that sends or reads something fails right
+// away, even while buffering, so the printer can't be used after it.
+//
+// Connections with deadline methods like net.Conn have their deadline moved
+// to the past to stop a blocked read or write.  Other connections are read
+// and written in a goroutine that is left behind when the context is done,
+// and only stops when the connection finishes or is closed.
+//
+// The context is on top of SetReadTimeout and SetWriteTimeout, whichever
+// ends first stops the read or write.  The timeouts fail with ErrTimeout and
+// the context fails with ctx.Err().
+func NewPrinterWithContext(ctx context.Context, dst io.ReadWriteCloser) Printer {
+	printer := NewPrinter(dst)
+	printer.config.ctx = ctx
+	return printer
+}
+
+// contextErr returns the error of the printer context, or nil if there is no
+// context or it isn't done
+func (p Printer) contextErr() error {
+	if p.config == nil || p.config.ctx == nil {
+		return nil
+	}
+	return p.config.ctx.Err()
+}
+
+// withContext runs op on b and stops it when the printer context is done.
+// setDeadline is the read or write deadline method of the connection, or nil
+// when it doesn't have one.
+func (p Printer) withContext(b []byte, setDeadline func(time.Time) error, op func([]byte) (int, error)) (int, error) {
+	if p.config == nil || p.config.ctx == nil {
+		return op(b)
+	}
+
+	ctx := p.config.ctx
+	err := ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	if setDeadline != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				setDeadline(time.Unix(1, 0))
+			case <-stop:
+			}
+		}()
+
+		n, err := op(b)
+		if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+		return n, err
+	}
+
+	// The goroutine gets its own copy since it can keep going after the
+	// context is done, and the bytes are copied back for reads
+	type result struct {
+		n   int
+		err error
+	}
+
+	data := append([]byte(nil), b...)
+	done := make(chan result, 1)
+	go func() {
+		n, err := op(data)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		copy(b, data[:r.n])
+		return r.n, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
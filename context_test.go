@@ -0,0 +1,128 @@
+package escpos_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestPrinterContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := &escpos.CaptureSink{}
+	printer := escpos.NewPrinterWithContext(ctx, sink)
+
+	err := printer.Print("before\n")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	cancel()
+	err = printer.Print("after\n")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("print after cancel returned %v instead of context.Canceled", err)
+	}
+	if string(sink.Bytes()) != "before\n" {
+		t.Fatalf("sent %q after the cancel", sink.Bytes())
+	}
+}
+
+// pipe returns a net.Conn whose peer never reads or writes, so everything
+// blocks until it is closed
+func pipe(t *testing.T) net.Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client
+}
+
+func TestPrinterContextStops(t *testing.T) {
+	write := func(p escpos.Printer) error { return p.Print("nobody is reading\n") }
+	read := func(p escpos.Printer) error {
+		_, err := p.TransmitErrorStatus()
+		return err
+	}
+
+	// Each case gets its own pipe since a canceled write leaves a deadline
+	// set
+	cases := []struct {
+		name string
+		run  func(escpos.Printer) error
+		conn func(t *testing.T) io.ReadWriteCloser
+	}{
+		{"write with deadline", write, func(t *testing.T) io.ReadWriteCloser { return pipe(t) }},
+		{"write without deadline", write, func(t *testing.T) io.ReadWriteCloser { return struct{ io.ReadWriteCloser }{pipe(t)} }},
+		{
+			"read with deadline",
+			func(p escpos.Printer) error {
+				_, err := p.Read(make([]byte, 1))
+				return err
+			},
+			func(t *testing.T) io.ReadWriteCloser { return pipe(t) },
+		},
+		{
+			"read without deadline",
+			read,
+			func(t *testing.T) io.ReadWriteCloser {
+				r, _ := io.Pipe()
+				t.Cleanup(func() { r.Close() })
+				return silentConn{r}
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			printer := escpos.NewPrinterWithContext(ctx, c.conn(t))
+
+			time.AfterFunc(20*time.Millisecond, cancel)
+			start := time.Now()
+			err := c.run(printer)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("returned %v instead of context.Canceled", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("took %v to stop", elapsed)
+			}
+		})
+	}
+}
+
+func TestPrinterContextReadTimeout(t *testing.T) {
+	silent, _ := io.Pipe()
+	defer silent.Close()
+
+	// The context deadline and the read timeout both stop a read, and the
+	// first one decides the error
+	cases := []struct {
+		name        string
+		ctxTimeout  time.Duration
+		readTimeout time.Duration
+		want        error
+	}{
+		{"context first", 20 * time.Millisecond, time.Second, context.DeadlineExceeded},
+		{"read timeout first", time.Second, 20 * time.Millisecond, escpos.ErrTimeout},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), c.ctxTimeout)
+			defer cancel()
+
+			printer := escpos.NewPrinterWithContext(ctx, silentConn{silent})
+			printer.SetReadTimeout(c.readTimeout)
+
+			_, err := printer.TransmitErrorStatus()
+			if !errors.Is(err, c.want) {
+				t.Fatalf("status read returned %v instead of %v", err, c.want)
+			}
+		})
+	}
+}
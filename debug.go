@@ -0,0 +1,58 @@
+package escpos
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// debugLineBytes is the number of bytes in each line of the debug dump
+const debugLineBytes = 16
+
+// SetDebugWriter writes a hex dump of every command sent to the printer to
+// w, which helps when working out what a printer is doing with the bytes.
+// Each line has the offset, the hex of up to 16 bytes, and the name of the
+// command like in CaptureSink.Commands.  Longer commands continue on the
+// next lines.
+//
+//	00000000  1b 45 01                                         BOLD on
+//
+// Nothing sent to the printer changes.  Setting w to nil turns it off, which
+// is the default.
+func (p Printer) SetDebugWriter(w io.Writer) {
+	if p.config == nil {
+		return
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.debug = w
+	p.config.debugOffset = 0
+}
+
+// dumpDebug writes the bytes that were sent to the debug writer
+func (p Printer) dumpDebug(b []byte) {
+	if p.config == nil || p.config.debug == nil || len(b) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	for _, cmd := range decodeCapture(b) {
+		name := cmd.Name
+		for data := cmd.Data; len(data) > 0; {
+			line := data
+			if len(line) > debugLineBytes {
+				line = line[:debugLineBytes]
+			}
+
+			text := fmt.Sprintf("%08x  %-*s  %s", p.config.debugOffset, debugLineBytes*3-1, fmt.Sprintf("% x", line), name)
+			sb.WriteString(strings.TrimRight(text, " ") + "\n")
+			p.config.debugOffset += len(line)
+			data = data[len(line):]
+			name = ""
+		}
+	}
+
+	p.config.debug.Write([]byte(sb.String()))
+}
@@ -0,0 +1,61 @@
+package escpos_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestSetDebugWriter(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	var debug bytes.Buffer
+	printer.SetDebugWriter(&debug)
+
+	for _, err := range []error{
+		printer.SetBold(true),
+		printer.Println("Debug dump of the commands"),
+		printer.Cut(),
+	} {
+		if err != nil {
+			t.Fatalf("could not print: %v", err)
+		}
+	}
+
+	want := strings.Join([]string{
+		"00000000  1b 45 01                                         BOLD on",
+		"00000003  44 65 62 75 67 20 64 75 6d 70 20 6f 66 20 74 68  PRINT \"Debug dump of the commands\"",
+		"00000013  65 20 63 6f 6d 6d 61 6e 64 73",
+		"0000001d  0a                                               LF",
+		"0000001e  1d 56 00                                         CUT",
+		"",
+	}, "\n")
+	if debug.String() != want {
+		t.Fatalf("debug dump was\n%s\ninstead of\n%s", debug.String(), want)
+	}
+
+	// The printer still gets the same bytes
+	sent := append([]byte{escpos.ESC, 'E', 1}, "Debug dump of the commands\n"...)
+	sent = append(sent, escpos.GS, 'V', 0)
+	if !bytes.Equal(sink.Bytes(), sent) {
+		t.Fatalf("debug mode sent % x instead of % x", sink.Bytes(), sent)
+	}
+}
+
+func TestSetDebugWriterOff(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+
+	var debug bytes.Buffer
+	printer.SetDebugWriter(&debug)
+	printer.SetDebugWriter(nil)
+
+	err := printer.SetBold(false)
+	if err != nil {
+		t.Fatalf("could not set bold: %v", err)
+	}
+	if debug.Len() != 0 {
+		t.Fatalf("debug writer got %q after it was turned off", debug.String())
+	}
+}
@@ -0,0 +1,120 @@
+// Package display writes to a two-line customer display that is connected
+// through the printer, like the Epson DM-D series.
+//
+// This is kept out of the escpos package since the display has its own small
+// command set and isn't ESC/POS printing.
+package display
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/joeyak/go-escpos"
+)
+
+const (
+	// CLR clears the display and moves the cursor home
+	CLR = 0x0C
+	// US starts the display commands
+	US = 0x1F
+)
+
+// LineDisplay is a customer display that shares the connection of a printer.
+// Every command selects the display with ESC = before it and selects the
+// printer again after it, so the printer can be used between display
+// commands.
+type LineDisplay struct {
+	// Columns and Rows are the size of the display, the default is 20x2
+	Columns, Rows int
+
+	dst io.ReadWriteCloser
+}
+
+// NewLineDisplay returns a 20x2 display that writes to dst
+func NewLineDisplay(dst io.ReadWriteCloser) *LineDisplay {
+	return &LineDisplay{
+		Columns: 20,
+		Rows:    2,
+		dst:     dst,
+	}
+}
+
+// send writes the command to the display between the peripheral selections
+func (d *LineDisplay) send(cmd ...byte) error {
+	data := []byte{escpos.ESC, '=', byte(escpos.PeripheralDisplay)}
+	data = append(data, cmd...)
+	data = append(data, escpos.ESC, '=', byte(escpos.PeripheralPrinter))
+
+	// Keep writing when the connection takes only part of the data
+	for len(data) > 0 {
+		n, err := d.dst.Write(data)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return io.ErrShortWrite
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Clear clears all the characters and moves the cursor to the top left
+func (d *LineDisplay) Clear() error {
+	err := d.send(CLR)
+	if err != nil {
+		return fmt.Errorf("could not clear display: %w", err)
+	}
+	return nil
+}
+
+// SetCursor moves the cursor to the column and row, starting from 1 at the
+// top left
+func (d *LineDisplay) SetCursor(col, row int) error {
+	errMsg := "could not set display cursor: %w"
+
+	if col < 1 || col > d.Columns {
+		return fmt.Errorf(errMsg, fmt.Errorf("column %d is not between 1 and %d", col, d.Columns))
+	}
+
+	if row < 1 || row > d.Rows {
+		return fmt.Errorf(errMsg, fmt.Errorf("row %d is not between 1 and %d", row, d.Rows))
+	}
+
+	err := d.send(US, '$', byte(col), byte(row))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// Write shows the text at the cursor.  Text past the end of a row goes on to
+// the next row.
+func (d *LineDisplay) Write(text string) error {
+	err := d.send([]byte(text)...)
+	if err != nil {
+		return fmt.Errorf("could not write to display: %w", err)
+	}
+	return nil
+}
+
+// Brightness sets the brightness of the display from 1 to 4, where 4 is the
+// brightest
+func (d *LineDisplay) Brightness(level int) error {
+	errMsg := "could not set display brightness: %w"
+
+	if level < 1 || level > 4 {
+		return fmt.Errorf(errMsg, fmt.Errorf("brightness %d is not between 1 and 4", level))
+	}
+
+	err := d.send(US, 'X', byte(level))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// Close closes the connection, which also closes it for the printer
+func (d *LineDisplay) Close() error {
+	return d.dst.Close()
+}
@@ -0,0 +1,82 @@
+package display_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+	"github.com/joeyak/go-escpos/display"
+)
+
+// bufferConn lets a bytes.Buffer be used as the display connection
+type bufferConn struct {
+	*bytes.Buffer
+}
+
+func (bufferConn) Close() error { return nil }
+
+// wrap puts the display command between selecting the display and selecting
+// the printer again
+func wrap(cmd ...byte) []byte {
+	data := []byte{escpos.ESC, '=', 2}
+	data = append(data, cmd...)
+	return append(data, escpos.ESC, '=', 1)
+}
+
+func TestLineDisplay(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(*display.LineDisplay) error
+		want []byte
+	}{
+		{"clear", (*display.LineDisplay).Clear, wrap(display.CLR)},
+		{
+			"positioned write",
+			func(d *display.LineDisplay) error {
+				err := d.SetCursor(5, 2)
+				if err != nil {
+					return err
+				}
+				return d.Write("TOTAL 9.99")
+			},
+			append(wrap(display.US, '$', 5, 2), wrap([]byte("TOTAL 9.99")...)...),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := c.run(display.NewLineDisplay(bufferConn{buf}))
+			if err != nil {
+				t.Fatalf("could not write to display: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", buf.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestLineDisplayErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(*display.LineDisplay) error
+	}{
+		{"cursor past the last column", func(d *display.LineDisplay) error { return d.SetCursor(21, 1) }},
+		{"cursor past the last line", func(d *display.LineDisplay) error { return d.SetCursor(1, 3) }},
+		{"brightness 5", func(d *display.LineDisplay) error { return d.Brightness(5) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := c.run(display.NewLineDisplay(bufferConn{buf}))
+			if err == nil {
+				t.Fatalf("command did not fail")
+			}
+			if buf.Len() > 0 {
+				t.Fatalf("sent % x after failing", buf.Bytes())
+			}
+		})
+	}
+}
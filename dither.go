@@ -0,0 +1,306 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// DitherMode selects how gray pixels are turned into black and white dots
+type DitherMode int
+
+const (
+	// DitherNone prints every pixel darker than the threshold as black
+	DitherNone DitherMode = iota
+	// DitherFloydSteinberg spreads the error of each pixel to its neighbors
+	DitherFloydSteinberg
+	// DitherOrdered uses a 4x4 Bayer matrix to offset the threshold
+	DitherOrdered
+	// DitherLogo is for logos and other two tone images.  Dark and light
+	// areas and the edges between them use the threshold so solid areas stay
+	// solid and thin lines stay sharp, and only the gray areas in between
+	// are dithered with clustered dots, which thermal heads print more evenly
+	// than single dots.  Use DitherFloydSteinberg for photos and gradients,
+	// where it keeps more of the detail.
+	DitherLogo
+)
+
+// ImageOptions controls how an image is converted to black and white before
+// printing
+type ImageOptions struct {
+	// Threshold is the gray level where pixels darker than it are printed.
+	// The zero value uses 128 like the image functions that don't take
+	// options, and 1 prints only black pixels.
+	Threshold uint8
+	Dither    DitherMode
+	// Invert prints the white parts of the image as black and the black parts
+	// as white, which is for images like white logos on a clear background
+	Invert bool
+	// TransparentBlack prints transparent pixels as black.  By default they
+	// are left white even when the image is inverted, by putting the image on
+	// white before converting it, or on black when it is inverted.
+	TransparentBlack bool
+	// ChannelWeights is how much each color adds to the gray level, which
+	// changes how colors like a red stamp print.  The zero value uses the
+	// standard Rec. 601 weights.
+	ChannelWeights ChannelWeights
+	// OnOverflow is what happens to images wider than the paper, the default
+	// is ImageOverflowError
+	OnOverflow ImageOverflow
+	// WarnFunc is called with a message when an image is cropped or scaled
+	// by OnOverflow.  It can be nil.
+	WarnFunc func(string)
+}
+
+// ImageOverflow selects what happens to images that are wider than the paper
+type ImageOverflow int
+
+const (
+	// ImageOverflowError returns an error wrapping ErrImageTooWide without
+	// printing anything
+	ImageOverflowError ImageOverflow = iota
+	// ImageOverflowCropLeft keeps the left side of the image and crops off
+	// what doesn't fit on the right
+	ImageOverflowCropLeft
+	// ImageOverflowCropCenter keeps the middle of the image and crops the
+	// same amount off both sides
+	ImageOverflowCropCenter
+	// ImageOverflowScale scales the image down to fit like PrintImageFit.
+	// The scaled image is gray with transparent pixels on white, so
+	// ChannelWeights and TransparentBlack don't change it.
+	ImageOverflowScale
+)
+
+// ChannelWeights are the weights of the red, green, and blue channels when a
+// color is turned gray.  They are scaled to add up to 1 so white stays white.
+// Raising the weight of a color makes it lighter, so with R at 1 and the rest
+// at 0 red prints as white, and lowering it makes it darker.
+type ChannelWeights struct {
+	R, G, B float64
+}
+
+// Rec601 are the standard weights used when ChannelWeights is the zero value
+var Rec601 = ChannelWeights{R: 0.299, G: 0.587, B: 0.114}
+
+// fixed returns the weights as 16 bit fixed point numbers that add up to
+// 65536
+func (w ChannelWeights) fixed() (r, g, b uint32) {
+	if w == (ChannelWeights{}) {
+		return 19595, 38470, 7471
+	}
+
+	// Rounding down keeps r+g from going over so b is never negative
+	sum := w.R + w.G + w.B
+	r = uint32(w.R / sum * 65536)
+	g = uint32(w.G / sum * 65536)
+	return r, g, 65536 - r - g
+}
+
+// checkImageOptions checks the options of the image functions
+func checkImageOptions(opts ImageOptions) error {
+	err := checkEnum(opts.Dither, DitherNone, DitherFloydSteinberg, DitherOrdered, DitherLogo)
+	if err != nil {
+		return err
+	}
+
+	err = checkEnum(opts.OnOverflow, ImageOverflowError, ImageOverflowCropLeft, ImageOverflowCropCenter, ImageOverflowScale)
+	if err != nil {
+		return err
+	}
+
+	w := opts.ChannelWeights
+	if w.R < 0 || w.G < 0 || w.B < 0 {
+		return fmt.Errorf("channel weights must not be negative")
+	}
+	return nil
+}
+
+var bayer4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// clustered4 is a 4x4 clustered dot matrix.  The highest values are in the
+// middle, so as the gray gets darker the dots grow out from the middle of
+// each cell and clump together.
+var clustered4 = [4][4]int{
+	{3, 10, 9, 2},
+	{11, 15, 14, 8},
+	{4, 12, 13, 7},
+	{0, 5, 6, 1},
+}
+
+const (
+	// logoBand is how far from the threshold a gray level has to be for
+	// DitherLogo to leave it solid
+	logoBand = 48
+	// logoEdge is the difference from a neighbor that makes a pixel an edge
+	// for DitherLogo
+	logoEdge = 64
+)
+
+// defaultThreshold is used for a Threshold of 0
+const defaultThreshold = 128
+
+// defaultImageOptions is used by the image functions that don't take options
+var defaultImageOptions = ImageOptions{Threshold: defaultThreshold}
+
+// bitmap is a black and white image where true is a black dot.  Images are
+// converted to a bitmap once before being split into rows or bands, so
+// dithering is done across the whole image instead of each band on its own.
+type bitmap struct {
+	width, height int
+	dots          []bool
+}
+
+// dot reports if x, y is a black dot.  Anything outside the bitmap is white.
+func (b *bitmap) dot(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return false
+	}
+	return b.dots[y*b.width+x]
+}
+
+// grayLevel returns the 0-255 gray level of the color after putting it on a
+// white or black background by its alpha, with the channels weighted by w
+func grayLevel(c color.Color, blackBackground bool, w ChannelWeights) int {
+	r, g, b, a := c.RGBA()
+	wr, wg, wb := w.fixed()
+	return grayLevelRGBA(r, g, b, a, blackBackground, wr, wg, wb)
+}
+
+// grayLevelRGBA is grayLevel for the 16 bit alpha multiplied channels from
+// color.Color.RGBA and the weights from ChannelWeights.fixed
+func grayLevelRGBA(r, g, b, a uint32, blackBackground bool, wr, wg, wb uint32) int {
+	// The colors are already multiplied by the alpha, which is the same as
+	// putting them on black
+	y := (uint64(wr)*uint64(r) + uint64(wg)*uint64(g) + uint64(wb)*uint64(b) + 1<<15) >> 16
+	if !blackBackground {
+		y += uint64(0xFFFF - a)
+	}
+	return int(y >> 8)
+}
+
+// grayLevels returns the gray level of every pixel of the image by row.
+// Gray and RGBA images are read from their pixels directly, which is a lot
+// faster than calling At for each pixel, and other images use At.  Both give
+// the same levels as grayLevel.
+func grayLevels(img image.Image, blackBackground bool, w ChannelWeights) []int {
+	imgRect := img.Bounds()
+	width, height := imgRect.Dx(), imgRect.Dy()
+	gray := make([]int, width*height)
+	wr, wg, wb := w.fixed()
+
+	switch img := img.(type) {
+	case *image.Gray:
+		// Gray pixels only have 256 levels, so work them out once
+		var levels [256]int
+		for i := range levels {
+			y := uint32(i) * 0x101
+			levels[i] = grayLevelRGBA(y, y, y, 0xFFFF, blackBackground, wr, wg, wb)
+		}
+
+		for y := 0; y < height; y++ {
+			pix := img.Pix[img.PixOffset(imgRect.Min.X, imgRect.Min.Y+y):]
+			row := gray[y*width : (y+1)*width]
+			for x := range row {
+				row[x] = levels[pix[x]]
+			}
+		}
+
+	case *image.RGBA:
+		for y := 0; y < height; y++ {
+			pix := img.Pix[img.PixOffset(imgRect.Min.X, imgRect.Min.Y+y):]
+			row := gray[y*width : (y+1)*width]
+			for x := range row {
+				c := pix[x*4 : x*4+4]
+				row[x] = grayLevelRGBA(uint32(c[0])*0x101, uint32(c[1])*0x101, uint32(c[2])*0x101, uint32(c[3])*0x101, blackBackground, wr, wg, wb)
+			}
+		}
+
+	default:
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				r, g, b, a := img.At(imgRect.Min.X+x, imgRect.Min.Y+y).RGBA()
+				gray[y*width+x] = grayLevelRGBA(r, g, b, a, blackBackground, wr, wg, wb)
+			}
+		}
+	}
+
+	return gray
+}
+
+// monochrome converts the image to a bitmap
+func monochrome(img image.Image, opts ImageOptions) *bitmap {
+	imgRect := img.Bounds()
+	width, height := imgRect.Dx(), imgRect.Dy()
+	out := &bitmap{width: width, height: height, dots: make([]bool, width*height)}
+
+	if opts.Threshold == 0 {
+		opts.Threshold = defaultThreshold
+	}
+
+	// The background is picked so transparent pixels end up white on the
+	// paper after the image is inverted
+	blackBackground := opts.Invert != opts.TransparentBlack
+
+	gray := grayLevels(img, blackBackground, opts.ChannelWeights)
+
+	// edge reports if the pixel is much lighter or darker than a pixel next
+	// to it
+	edge := func(x, y int) bool {
+		v := gray[y*width+x]
+		for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || ny < 0 || nx >= width || ny >= height {
+				continue
+			}
+			diff := v - gray[ny*width+nx]
+			if diff >= logoEdge || -diff >= logoEdge {
+				return true
+			}
+		}
+		return false
+	}
+
+	// spread adds the error to the pixel if it is in the image
+	spread := func(x, y, e int) {
+		if x < 0 || x >= width || y >= height {
+			return
+		}
+		gray[y*width+x] += e
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := gray[y*width+x]
+
+			threshold := int(opts.Threshold)
+			if opts.Dither == DitherOrdered {
+				threshold += bayer4[y%4][x%4]*16 + 8 - 128
+			}
+			if opts.Dither == DitherLogo && v > threshold-logoBand && v < threshold+logoBand && !edge(x, y) {
+				// Spread the offsets over the band around the threshold
+				threshold += clustered4[y%4][x%4]*logoBand/8 + logoBand/16 - logoBand
+			}
+
+			var level int
+			if v >= threshold {
+				level = 0xFF
+			}
+			out.dots[y*width+x] = (level == 0) != opts.Invert
+
+			if opts.Dither == DitherFloydSteinberg {
+				e := v - level
+				spread(x+1, y, e*7/16)
+				spread(x-1, y+1, e*3/16)
+				spread(x, y+1, e*5/16)
+				spread(x+1, y+1, e*1/16)
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,378 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// genericImage hides the type of the image so it is read with At
+type genericImage struct {
+	image.Image
+}
+
+// discardConn throws away writes and answers reads with zeros
+type discardConn struct{}
+
+func (discardConn) Write(b []byte) (int, error) { return len(b), nil }
+func (discardConn) Close() error                { return nil }
+
+func (discardConn) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
+
+// gradient makes a width x height image that goes from black to white
+func gradient(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 0xFF / (width - 1))})
+		}
+	}
+	return img
+}
+
+// checkerboard makes a width x height image of black and white squares that
+// are size dots wide
+func checkerboard(width, height, size int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/size+y/size)%2 == 1 {
+				img.SetGray(x, y, color.Gray{Y: 0xFF})
+			}
+		}
+	}
+	return img
+}
+
+// colors makes an RGBA image that doesn't start at 0, 0 with every channel
+// changing
+func colors(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(5, 7, 5+width, 7+height))
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 3), uint8(y * 5), uint8(x ^ y), uint8(0xFF - x)})
+		}
+	}
+	return img
+}
+
+func TestImageFastPath(t *testing.T) {
+	gray := gradient(203, 61)
+
+	images := []struct {
+		name string
+		img  image.Image
+	}{
+		{"gray", gray},
+		{"gray part", gray.SubImage(image.Rect(10, 3, 150, 50))},
+		{"rgba", colors(203, 61)},
+	}
+
+	options := []struct {
+		name string
+		opts escpos.ImageOptions
+	}{
+		{"threshold", escpos.ImageOptions{Threshold: 128}},
+		{"floyd steinberg inverted", escpos.ImageOptions{Threshold: 90, Dither: escpos.DitherFloydSteinberg, Invert: true}},
+		{"ordered transparent black", escpos.ImageOptions{Threshold: 128, Dither: escpos.DitherOrdered, TransparentBlack: true}},
+		{"channel weights", escpos.ImageOptions{Threshold: 128, ChannelWeights: escpos.ChannelWeights{R: 1, G: 0, B: 2}}},
+	}
+
+	render := func(t *testing.T, img image.Image, opts escpos.ImageOptions) []byte {
+		sink, printer := escpos.NewCapturePrinter()
+		err := printer.PrintImage24Opts(img, escpos.DoubleDensity, opts)
+		if err != nil {
+			t.Fatalf("could not print image: %v", err)
+		}
+		return sink.Bytes()
+	}
+
+	for _, img := range images {
+		for _, opts := range options {
+			t.Run(img.name+" "+opts.name, func(t *testing.T) {
+				fast := render(t, img.img, opts.opts)
+				generic := render(t, genericImage{img.img}, opts.opts)
+				if !bytes.Equal(fast, generic) {
+					t.Fatalf("printed differently when read with At")
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkPrintImage24 compares the fast path for Gray and RGBA images with
+// reading the same images with At
+func BenchmarkPrintImage24(b *testing.B) {
+	gray, rgba := gradient(576, 240), colors(576, 240)
+
+	images := []struct {
+		name string
+		img  image.Image
+	}{
+		{"gray", gray},
+		{"gray generic", genericImage{gray}},
+		{"rgba", rgba},
+		{"rgba generic", genericImage{rgba}},
+	}
+
+	for _, img := range images {
+		b.Run(img.name, func(b *testing.B) {
+			printer := escpos.NewPrinter(discardConn{})
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := printer.PrintImage24(img.img, escpos.DoubleDensity)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// mixedColumns counts the columns of a 24 dot band that have both black and
+// white dots
+func mixedColumns(t *testing.T, img image.Image, opts escpos.ImageOptions) int {
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.PrintImage24Opts(img, escpos.DoubleDensity, opts)
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+
+	var mixed int
+	for _, cmd := range sink.Decoded() {
+		if !strings.HasPrefix(cmd.Name, "IMAGE mode 33") {
+			continue
+		}
+		for column := cmd.Data[5:]; len(column) >= 3; column = column[3:] {
+			full := column[0]&column[1]&column[2] == 0xFF
+			empty := column[0]|column[1]|column[2] == 0
+			if !full && !empty {
+				mixed++
+			}
+		}
+	}
+	return mixed
+}
+
+func TestImageDither(t *testing.T) {
+	img := gradient(256, 24)
+
+	// Every row of the gradient is the same, so without dithering each
+	// column is all black or all white
+	if n := mixedColumns(t, img, escpos.ImageOptions{Threshold: 128}); n != 0 {
+		t.Fatalf("threshold printed %d mixed columns instead of a hard split", n)
+	}
+
+	for _, dither := range []escpos.DitherMode{escpos.DitherFloydSteinberg, escpos.DitherOrdered} {
+		if n := mixedColumns(t, img, escpos.ImageOptions{Threshold: 128, Dither: dither}); n < 64 {
+			t.Fatalf("dither %d printed only %d mixed columns", dither, n)
+		}
+	}
+}
+
+func TestImageZeroThreshold(t *testing.T) {
+	img := gradient(256, 24)
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.PrintImage24(img, escpos.DoubleDensity)
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+	want := bytes.Clone(sink.Bytes())
+
+	sink.Reset()
+	err = printer.PrintImage24Opts(img, escpos.DoubleDensity, escpos.ImageOptions{})
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("a threshold of 0 printed differently than the default of 128")
+	}
+}
+
+func TestDitherLogo(t *testing.T) {
+	// A solid block, a thin gray line like an anti-aliased stroke, and a
+	// flat gray area
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			c := uint8(0xFF)
+			switch {
+			case x < 6:
+				c = 0
+			case x == 8:
+				c = 96
+			case x >= 11:
+				c = 128
+			}
+			img.SetGray(x, y, color.Gray{Y: c})
+		}
+	}
+
+	opts := escpos.GraphicsOptions{Image: escpos.ImageOptions{Threshold: 128, Dither: escpos.DitherLogo}}
+
+	// The block and the line stay solid and only the gray area is dithered
+	want := []byte{
+		0xFC, 0x86,
+		0xFC, 0x8F,
+		0xFC, 0x86,
+		0xFC, 0x80,
+		0xFC, 0x86,
+		0xFC, 0x8F,
+		0xFC, 0x86,
+		0xFC, 0x80,
+	}
+
+	// The dither has no state left over between images, so printing it
+	// again gives the same dots
+	for i := 0; i < 2; i++ {
+		sink, printer := escpos.NewCapturePrinter()
+		err := printer.PrintGraphics(img, opts)
+		if err != nil {
+			t.Fatalf("could not print logo: %v", err)
+		}
+
+		// GS ( L pL pH m fn a bx by c xL xH yL yH comes before the dots
+		data := sink.Bytes()
+		if len(data) < 15+len(want) || !bytes.Equal(data[15:15+len(want)], want) {
+			t.Fatalf("logo dither run %d made % x instead of % x", i+1, data[15:], want)
+		}
+	}
+}
+
+func TestChannelWeights(t *testing.T) {
+	red := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			red.Set(x, y, color.RGBA{R: 0xFF, A: 0xFF})
+		}
+	}
+
+	cases := []struct {
+		name    string
+		weights escpos.ChannelWeights
+		inked   bool
+	}{
+		// Red is 0.299 which is a dark gray
+		{"default", escpos.ChannelWeights{}, true},
+		{"Rec601", escpos.Rec601, true},
+		{"heavy red", escpos.ChannelWeights{R: 0.9, G: 0.587, B: 0.114}, false},
+		{"only red", escpos.ChannelWeights{R: 1}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+			err := printer.PrintGraphics(red, escpos.GraphicsOptions{Image: escpos.ImageOptions{Threshold: 128, ChannelWeights: c.weights}})
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+
+			// GS ( L pL pH m fn a bx by c xL xH yL yH comes before the dots
+			dots := sink.Bytes()[15 : 15+8]
+			if inked := bytes.Equal(dots, bytes.Repeat([]byte{0xFF}, 8)); inked != c.inked {
+				t.Fatalf("red printed as ink was %t instead of %t", inked, c.inked)
+			}
+		})
+	}
+}
+
+func TestChannelWeightsNegative(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	err := printer.PrintImage24Opts(img, escpos.DoubleDensity, escpos.ImageOptions{Threshold: 128, ChannelWeights: escpos.ChannelWeights{R: -1, G: 1, B: 1}})
+	if err == nil {
+		t.Fatalf("negative channel weights did not fail")
+	}
+}
+
+// halves makes a 128x24 image that is black on the left half and white on
+// the right half
+func halves() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 128, 24))
+	for y := 0; y < 24; y++ {
+		for x := 64; x < 128; x++ {
+			img.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+	return img
+}
+
+func TestImageOverflow(t *testing.T) {
+	profile := escpos.ProfileHoin
+	profile.DotWidth = 64
+
+	// The graphics row for each mode, with a 64 dot wide image being 8 bytes
+	cases := []struct {
+		name     string
+		overflow escpos.ImageOverflow
+		row      []byte
+	}{
+		{"crop left", escpos.ImageOverflowCropLeft, bytes.Repeat([]byte{0xFF}, 8)},
+		{"crop center", escpos.ImageOverflowCropCenter, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}},
+		{"scale", escpos.ImageOverflowScale, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(profile)
+
+			var warnings []string
+			opts := escpos.ImageOptions{Threshold: 128, OnOverflow: c.overflow, WarnFunc: func(s string) { warnings = append(warnings, s) }}
+
+			err := printer.PrintGraphics(halves(), escpos.GraphicsOptions{Image: opts})
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+
+			// GS ( L pL pH m fn a bx by c xL xH yL yH comes before the dots
+			data := sink.Bytes()
+			if width := int(data[11]) | int(data[12])<<8; width != 64 {
+				t.Fatalf("printed %d dots wide instead of 64", width)
+			}
+			if !bytes.Equal(data[15:15+8], c.row) {
+				t.Fatalf("printed % x instead of % x", data[15:15+8], c.row)
+			}
+			if len(warnings) != 1 {
+				t.Fatalf("warned %q instead of once", warnings)
+			}
+		})
+	}
+}
+
+func TestImageOverflowDefault(t *testing.T) {
+	profile := escpos.ProfileHoin
+	profile.DotWidth = 64
+	sink, printer := newPrinter(profile)
+
+	warned := false
+	opts := escpos.ImageOptions{Threshold: 128, WarnFunc: func(string) { warned = true }}
+	err := printer.PrintImage24Opts(halves(), escpos.DoubleDensity, opts)
+	if !errors.Is(err, escpos.ErrImageTooWide) {
+		t.Fatalf("default overflow returned %v instead of ErrImageTooWide", err)
+	}
+	if warned || len(sink.Bytes()) != 0 {
+		t.Fatalf("default overflow should not warn or send anything")
+	}
+
+	// Images that fit are left alone
+	opts.OnOverflow = escpos.ImageOverflowScale
+	err = printer.PrintImage24Opts(halves().SubImage(image.Rect(0, 0, 64, 24)), escpos.DoubleDensity, opts)
+	if err != nil {
+		t.Fatalf("could not print image that fits: %v", err)
+	}
+	if warned {
+		t.Fatalf("image that fits should not warn")
+	}
+}
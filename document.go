@@ -0,0 +1,222 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Document is a receipt described as data.  PrintDocument prints the
+// elements in order, so the same document always prints the same commands.
+//
+// Documents can be stored as JSON, where each element is an object with a
+// type field of "text", "image", "barcode", "qr", "divider", "columns",
+// "feed", or "cut" and the enums are names like "center" instead of numbers.
+//
+//	{"elements": [
+//		{"type": "text", "text": "Receipt", "bold": true, "justify": "center"},
+//		{"type": "barcode", "barcode": "CODE39", "data": "1234"},
+//		{"type": "cut", "mode": "partial", "lines": 3}
+//	]}
+type Document struct {
+	Elements []Element
+}
+
+// Element is a part of a Document.  The elements are TextElement,
+// ImageElement, BarCodeElement, QRElement, DividerElement, ColumnsElement,
+// FeedElement, and CutElement.
+type Element interface {
+	printElement(p Printer) error
+}
+
+// TextElement prints a line of text.  Only the styles that are not the zero
+// value are sent, and they are turned back off after the text so the next
+// element starts with the default style.
+type TextElement struct {
+	Text      string        `json:"text"`
+	Bold      bool          `json:"bold,omitempty"`
+	Underline UnderlineMode `json:"underline,omitempty"`
+	Font      Font          `json:"font,omitempty"`
+	// Width and Height are the character size from 0 to 7, where 0 is normal
+	Width   int           `json:"width,omitempty"`
+	Height  int           `json:"height,omitempty"`
+	Justify Justification `json:"justify,omitempty"`
+	// Wrap wraps the text to the columns of the profile with PrintWrapped,
+	// so the lines fit printers with different paper widths
+	Wrap bool `json:"wrap,omitempty"`
+}
+
+func (e TextElement) printElement(p Printer) error {
+	var resets []func() error
+	defer func() {
+		for i := len(resets) - 1; i >= 0; i-- {
+			resets[i]()
+		}
+	}()
+
+	style := func(set func() error, reset func() error) error {
+		err := set()
+		if err != nil {
+			return err
+		}
+		resets = append(resets, reset)
+		return nil
+	}
+
+	if e.Bold {
+		err := style(func() error { return p.SetBold(true) }, func() error { return p.SetBold(false) })
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.Underline != UnderlineOff {
+		err := style(func() error { return p.SetUnderline(e.Underline) }, func() error { return p.SetUnderline(UnderlineOff) })
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.Font != FontA {
+		err := style(func() error { return p.SetFont(e.Font) }, func() error { return p.SetFont(FontA) })
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.Width != 0 || e.Height != 0 {
+		err := style(func() error { return p.SetCharacterSize(e.Width, e.Height) }, func() error { return p.SetCharacterSize(0, 0) })
+		if err != nil {
+			return err
+		}
+	}
+
+	return justified(p, e.Justify, func() error {
+		if e.Wrap {
+			return p.PrintWrapped(e.Text, 0)
+		}
+		return p.Println(e.Text)
+	})
+}
+
+// ImageElement prints an image with PrintImageRaster
+//
+// In JSON the image is a base64 PNG in the png field.
+type ImageElement struct {
+	Image   image.Image
+	Justify Justification
+}
+
+func (e ImageElement) printElement(p Printer) error {
+	return justified(p, e.Justify, func() error { return p.PrintImageRaster(e.Image, RasterNormal) })
+}
+
+// BarCodeElement prints a bar code with PrintBarCode
+type BarCodeElement struct {
+	Type    BarCode       `json:"barcode"`
+	Data    string        `json:"data"`
+	Justify Justification `json:"justify,omitempty"`
+}
+
+func (e BarCodeElement) printElement(p Printer) error {
+	return justified(p, e.Justify, func() error { return p.PrintBarCode(e.Type, e.Data) })
+}
+
+// QRElement prints a model 2 QR code with QRCode
+type QRElement struct {
+	Data string `json:"data"`
+	// Size is the width of a module in dots from 1 to 16, the default is 6
+	Size            int               `json:"size,omitempty"`
+	ErrorCorrection QRErrorCorrection `json:"errorCorrection,omitempty"`
+	Justify         Justification     `json:"justify,omitempty"`
+}
+
+func (e QRElement) printElement(p Printer) error {
+	size := e.Size
+	if size == 0 {
+		size = 6
+	}
+	return justified(p, e.Justify, func() error { return p.QRCode(e.Data, QRModel2, size, e.ErrorCorrection) })
+}
+
+// DividerElement prints a line of Char across the columns of the profile.
+// The default Char is '-'.
+//
+// In JSON Char is a string with one character.
+type DividerElement struct {
+	Char rune
+}
+
+func (e DividerElement) printElement(p Printer) error {
+	char := e.Char
+	if char == 0 {
+		char = '-'
+	}
+	return p.Println(strings.Repeat(string(char), p.Columns()))
+}
+
+// ColumnsElement prints a line of columns with PrintColumns
+type ColumnsElement struct {
+	// Width is the number of characters in the line, 0 uses the columns of
+	// the profile
+	Width   int      `json:"width,omitempty"`
+	Columns []Column `json:"columns"`
+}
+
+func (e ColumnsElement) printElement(p Printer) error {
+	return p.PrintColumns(e.Width, e.Columns...)
+}
+
+// FeedElement feeds the paper Lines lines
+type FeedElement struct {
+	Lines int `json:"lines"`
+}
+
+func (e FeedElement) printElement(p Printer) error {
+	return p.FeedLines(e.Lines)
+}
+
+// CutElement feeds the paper Lines lines and cuts it with FeedAndCut
+type CutElement struct {
+	Mode  CutMode `json:"mode,omitempty"`
+	Lines int     `json:"lines,omitempty"`
+}
+
+func (e CutElement) printElement(p Printer) error {
+	return p.FeedAndCut(e.Lines, e.Mode)
+}
+
+// justified calls f with the printer justified with j, and then goes back to
+// LeftJustify.  Nothing is sent for LeftJustify.
+func justified(p Printer, j Justification, f func() error) error {
+	if j == LeftJustify {
+		return f()
+	}
+
+	err := p.Justify(j)
+	if err != nil {
+		return err
+	}
+	defer p.Justify(LeftJustify)
+
+	return f()
+}
+
+// PrintDocument prints the elements of the document in order.  It stops at
+// the first element that fails.
+func (p Printer) PrintDocument(doc Document) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	for i, e := range doc.Elements {
+		if e == nil {
+			return fmt.Errorf("could not print document element %d: element is nil", i)
+		}
+
+		err := e.printElement(p)
+		if err != nil {
+			return fmt.Errorf("could not print document element %d: %w", i, err)
+		}
+	}
+	return nil
+}
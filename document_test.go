@@ -0,0 +1,89 @@
+package escpos_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestPrintDocument(t *testing.T) {
+	doc := escpos.Document{Elements: []escpos.Element{
+		escpos.TextElement{Text: "Document", Bold: true, Width: 1, Height: 1, Justify: escpos.CenterJustify},
+		escpos.DividerElement{Char: '='},
+		escpos.ColumnsElement{Width: 20, Columns: []escpos.Column{
+			{Text: "Coffee"},
+			{Text: "3.50", Width: 6, Align: escpos.RightJustify},
+		}},
+		escpos.TextElement{Text: "Thank you", Underline: escpos.UnderlineThin},
+		escpos.BarCodeElement{Type: escpos.BcCODE39, Data: "DOC1", Justify: escpos.CenterJustify},
+		escpos.FeedElement{Lines: 2},
+	}}
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.PrintDocument(doc)
+	if err != nil {
+		t.Fatalf("could not print document: %v", err)
+	}
+
+	want := []string{
+		"BOLD on",
+		"CHARACTER SIZE 1x1",
+		"JUSTIFY center",
+		`PRINT "Document"`,
+		"LF",
+		"JUSTIFY left",
+		"CHARACTER SIZE 0x0",
+		"BOLD off",
+		fmt.Sprintf("PRINT %q", strings.Repeat("=", printer.Columns())),
+		"LF",
+		`PRINT "Coffee          3.50"`,
+		"LF",
+		"UNDERLINE 1",
+		`PRINT "Thank you"`,
+		"LF",
+		"UNDERLINE 0",
+		"JUSTIFY center",
+		`BARCODE 4 "DOC1"`,
+		"JUSTIFY left",
+		"FEED LINES 2",
+	}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("document sent %q instead of %q", got, want)
+	}
+}
+
+func TestPrintDocumentErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		elements []escpos.Element
+		index    string
+		sent     []string
+	}{
+		{"nil element", []escpos.Element{escpos.FeedElement{Lines: 1}, nil}, "element 1", []string{"FEED LINES 1"}},
+		// The feed after the bad bar code isn't sent
+		{
+			"bad bar code",
+			[]escpos.Element{escpos.BarCodeElement{Type: escpos.BcEAN13, Data: "ABC"}, escpos.FeedElement{Lines: 1}},
+			"element 0",
+			nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintDocument(escpos.Document{Elements: c.elements})
+			if err == nil || !strings.Contains(err.Error(), c.index) {
+				t.Fatalf("document returned %v instead of failing at %s", err, c.index)
+			}
+
+			if got := sink.Commands(); !reflect.DeepEqual(got, c.sent) {
+				t.Fatalf("sent %q instead of %q", got, c.sent)
+			}
+		})
+	}
+}
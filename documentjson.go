@@ -0,0 +1,316 @@
+package escpos
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"strings"
+	"unicode/utf8"
+)
+
+// elementDecoders decodes the JSON of each element type
+var elementDecoders = map[string]func([]byte) (Element, error){
+	"text":    decodeElement[TextElement],
+	"image":   decodeElement[ImageElement],
+	"barcode": decodeElement[BarCodeElement],
+	"qr":      decodeElement[QRElement],
+	"divider": decodeElement[DividerElement],
+	"columns": decodeElement[ColumnsElement],
+	"feed":    decodeElement[FeedElement],
+	"cut":     decodeElement[CutElement],
+}
+
+func decodeElement[T Element](data []byte) (Element, error) {
+	var e T
+	err := json.Unmarshal(data, &e)
+	return e, err
+}
+
+// elementType returns the JSON type of the element
+func elementType(e Element) (string, error) {
+	switch e.(type) {
+	case TextElement:
+		return "text", nil
+	case ImageElement:
+		return "image", nil
+	case BarCodeElement:
+		return "barcode", nil
+	case QRElement:
+		return "qr", nil
+	case DividerElement:
+		return "divider", nil
+	case ColumnsElement:
+		return "columns", nil
+	case FeedElement:
+		return "feed", nil
+	case CutElement:
+		return "cut", nil
+	}
+	return "", fmt.Errorf("%T is not a document element", e)
+}
+
+type documentJSON struct {
+	Elements []json.RawMessage `json:"elements"`
+}
+
+// MarshalJSON encodes the document with a type field in each element
+func (d Document) MarshalJSON() ([]byte, error) {
+	errMsg := "could not marshal document element %d: %w"
+
+	doc := documentJSON{Elements: []json.RawMessage{}}
+	for i, e := range d.Elements {
+		name, err := elementType(e)
+		if err != nil {
+			return nil, fmt.Errorf(errMsg, i, err)
+		}
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, fmt.Errorf(errMsg, i, err)
+		}
+
+		element := []byte(fmt.Sprintf(`{"type":%q`, name))
+		if len(data) > 2 {
+			element = append(element, ',')
+		}
+		doc.Elements = append(doc.Elements, append(element, data[1:]...))
+	}
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes a document with a type field in each element.  An
+// unknown type is an error.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	errMsg := "could not unmarshal document element %d: %w"
+
+	var doc documentJSON
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal document: %w", err)
+	}
+
+	elements := make([]Element, 0, len(doc.Elements))
+	for i, raw := range doc.Elements {
+		var header struct {
+			Type string `json:"type"`
+		}
+		err = json.Unmarshal(raw, &header)
+		if err != nil {
+			return fmt.Errorf(errMsg, i, err)
+		}
+
+		decode, ok := elementDecoders[header.Type]
+		if !ok {
+			return fmt.Errorf(errMsg, i, fmt.Errorf("%q is not a valid element type", header.Type))
+		}
+
+		e, err := decode(raw)
+		if err != nil {
+			return fmt.Errorf(errMsg, i, err)
+		}
+		elements = append(elements, e)
+	}
+
+	d.Elements = elements
+	return nil
+}
+
+type imageElementJSON struct {
+	PNG     []byte        `json:"png"`
+	Justify Justification `json:"justify,omitempty"`
+}
+
+func (e ImageElement) MarshalJSON() ([]byte, error) {
+	if e.Image == nil {
+		return nil, fmt.Errorf("could not marshal image: image is nil")
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, e.Image)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal image: %w", err)
+	}
+
+	return json.Marshal(imageElementJSON{PNG: buf.Bytes(), Justify: e.Justify})
+}
+
+func (e *ImageElement) UnmarshalJSON(data []byte) error {
+	var v imageElementJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	img, err := png.Decode(bytes.NewReader(v.PNG))
+	if err != nil {
+		return fmt.Errorf("could not unmarshal image: %w", err)
+	}
+
+	e.Image = img
+	e.Justify = v.Justify
+	return nil
+}
+
+type dividerElementJSON struct {
+	Char string `json:"char,omitempty"`
+}
+
+func (e DividerElement) MarshalJSON() ([]byte, error) {
+	v := dividerElementJSON{}
+	if e.Char != 0 {
+		v.Char = string(e.Char)
+	}
+	return json.Marshal(v)
+}
+
+func (e *DividerElement) UnmarshalJSON(data []byte) error {
+	var v dividerElementJSON
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	e.Char = 0
+	if v.Char != "" {
+		char, size := utf8.DecodeRuneInString(v.Char)
+		if size != len(v.Char) {
+			return fmt.Errorf("divider char %q must be one character", v.Char)
+		}
+		e.Char = char
+	}
+	return nil
+}
+
+// marshalEnum returns the name of v from names
+func marshalEnum[T comparable](v T, names map[T]string) ([]byte, error) {
+	name, ok := names[v]
+	if !ok {
+		return nil, fmt.Errorf("%v is not a valid %T", v, v)
+	}
+	return []byte(name), nil
+}
+
+// unmarshalEnum sets v to the value with the name in text, ignoring case
+func unmarshalEnum[T comparable](text []byte, v *T, names map[T]string) error {
+	for value, name := range names {
+		if strings.EqualFold(name, string(text)) {
+			*v = value
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a valid %T", text, *v)
+}
+
+var justificationNames = map[Justification]string{
+	LeftJustify:   "left",
+	CenterJustify: "center",
+	RightJustify:  "right",
+}
+
+func (j Justification) MarshalText() ([]byte, error) {
+	return marshalEnum(j, justificationNames)
+}
+
+func (j *Justification) UnmarshalText(text []byte) error {
+	return unmarshalEnum(text, j, justificationNames)
+}
+
+var fontNames = map[Font]string{
+	FontA: "A",
+	FontB: "B",
+	FontC: "C",
+}
+
+func (f Font) MarshalText() ([]byte, error) {
+	return marshalEnum(f, fontNames)
+}
+
+func (f *Font) UnmarshalText(text []byte) error {
+	return unmarshalEnum(text, f, fontNames)
+}
+
+var densityNames = map[Density]string{
+	SingleDensity: "single",
+	DoubleDensity: "double",
+}
+
+func (d Density) MarshalText() ([]byte, error) {
+	return marshalEnum(d, densityNames)
+}
+
+func (d *Density) UnmarshalText(text []byte) error {
+	return unmarshalEnum(text, d, densityNames)
+}
+
+var underlineNames = map[UnderlineMode]string{
+	UnderlineOff:   "off",
+	UnderlineThin:  "thin",
+	UnderlineThick: "thick",
+}
+
+func (u UnderlineMode) MarshalText() ([]byte, error) {
+	return marshalEnum(u, underlineNames)
+}
+
+func (u *UnderlineMode) UnmarshalText(text []byte) error {
+	return unmarshalEnum(text, u, underlineNames)
+}
+
+var cutModeNames = map[CutMode]string{
+	CutFull:    "full",
+	CutPartial: "partial",
+}
+
+func (m CutMode) MarshalText() ([]byte, error) {
+	return marshalEnum(m, cutModeNames)
+}
+
+func (m *CutMode) UnmarshalText(text []byte) error {
+	return unmarshalEnum(text, m, cutModeNames)
+}
+
+var qrErrorNames = map[QRErrorCorrection]string{
+	QRErrorL: "L",
+	QRErrorM: "M",
+	QRErrorQ: "Q",
+	QRErrorH: "H",
+}
+
+func (e QRErrorCorrection) MarshalText() ([]byte, error) {
+	return marshalEnum(e, qrErrorNames)
+}
+
+func (e *QRErrorCorrection) UnmarshalText(text []byte) error {
+	return unmarshalEnum(text, e, qrErrorNames)
+}
+
+var barCodeNames = map[BarCode]string{
+	BcUPCA:    "UPCA",
+	BcUPCE:    "UPCE",
+	BcJAN13:   "JAN13",
+	BcJAN8:    "JAN8",
+	BcCODE39:  "CODE39",
+	BcITF:     "ITF",
+	BcCODABAR: "CODABAR",
+	BcCODE93:  "CODE93",
+	BcCODE128: "CODE128",
+}
+
+func (b BarCode) MarshalText() ([]byte, error) {
+	return marshalEnum(b, barCodeNames)
+}
+
+// UnmarshalText also takes EAN13 and EAN8 for JAN13 and JAN8
+func (b *BarCode) UnmarshalText(text []byte) error {
+	switch strings.ToUpper(string(text)) {
+	case "EAN13":
+		*b = BcEAN13
+		return nil
+	case "EAN8":
+		*b = BcEAN8
+		return nil
+	}
+	return unmarshalEnum(text, b, barCodeNames)
+}
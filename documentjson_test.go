@@ -0,0 +1,60 @@
+package escpos_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestDocumentJSON(t *testing.T) {
+	data := []byte(`{"elements": [
+		{"type": "text", "text": "JSON document", "bold": true, "justify": "center"},
+		{"type": "barcode", "barcode": "EAN8", "data": "1234567"},
+		{"type": "cut", "mode": "partial", "lines": 3}
+	]}`)
+
+	want := escpos.Document{Elements: []escpos.Element{
+		escpos.TextElement{Text: "JSON document", Bold: true, Justify: escpos.CenterJustify},
+		escpos.BarCodeElement{Type: escpos.BcEAN8, Data: "1234567"},
+		escpos.CutElement{Mode: escpos.CutPartial, Lines: 3},
+	}}
+
+	var doc escpos.Document
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		t.Fatalf("could not unmarshal document: %v", err)
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("unmarshaled %+v instead of %+v", doc, want)
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("could not marshal document: %v", err)
+	}
+	for _, name := range []string{`"justify":"center"`, `"barcode":"JAN8"`} {
+		if !strings.Contains(string(data), name) {
+			t.Fatalf("enums should marshal as names, %s doesn't have %s", data, name)
+		}
+	}
+
+	var roundTrip escpos.Document
+	err = json.Unmarshal(data, &roundTrip)
+	if err != nil {
+		t.Fatalf("could not unmarshal %s: %v", data, err)
+	}
+	if !reflect.DeepEqual(roundTrip, want) {
+		t.Fatalf("round trip of %s gave %+v instead of %+v", data, roundTrip, want)
+	}
+}
+
+func TestDocumentJSONUnknownElement(t *testing.T) {
+	var doc escpos.Document
+	err := json.Unmarshal([]byte(`{"elements": [{"type": "sticker"}]}`), &doc)
+	if err == nil {
+		t.Fatalf("unknown element type should not unmarshal")
+	}
+}
@@ -0,0 +1,128 @@
+// Package escpostest compares what is sent to a printer against golden files,
+// so tests of receipts don't have to compare raw bytes.
+package escpostest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// Update rewrites the golden files instead of comparing them.  Tests can set
+// it from a flag to accept new output.
+var Update bool
+
+// maxMismatches is the most differing lines shown in a mismatch message
+const maxMismatches = 10
+
+// TB is the part of testing.TB used by RecordTo, so *testing.T and
+// *testing.B can be passed in
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+// RecordTo returns a printer that records the commands sent to it.  When the
+// test finishes the commands are compared with the golden file at goldenPath
+// and the test fails with the differing lines if they don't match.  The
+// golden file is written if it doesn't exist yet or Update is set.
+//
+// Each line of the golden file is the hex of one command and the decoded
+// name of it, like "1b 45 01  # BOLD on".
+func RecordTo(t TB, goldenPath string) escpos.Printer {
+	t.Helper()
+
+	sink, printer := escpos.NewCapturePrinter()
+	t.Cleanup(func() {
+		t.Helper()
+
+		got := Format(sink.Decoded())
+
+		want, err := os.ReadFile(goldenPath)
+		if Update || errors.Is(err, fs.ErrNotExist) {
+			err = writeGolden(goldenPath, got)
+			if err != nil {
+				t.Errorf("could not write golden file: %v", err)
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf("could not read golden file: %v", err)
+			return
+		}
+
+		diff := Diff(string(want), got)
+		if diff != "" {
+			t.Errorf("commands do not match golden file %s:\n%s", goldenPath, diff)
+		}
+	})
+
+	return printer
+}
+
+func writeGolden(path string, data string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(data), 0o644)
+}
+
+// Format writes the commands with one line for each command in the golden
+// file format
+func Format(cmds []escpos.CapturedCommand) string {
+	var sb strings.Builder
+	for _, cmd := range cmds {
+		fmt.Fprintf(&sb, "% x  # %s\n", cmd.Data, cmd.Name)
+	}
+	return sb.String()
+}
+
+// Diff compares the lines of want and got, and returns the lines that are
+// different with their line numbers.  It returns an empty string when they
+// match.
+func Diff(want, got string) string {
+	wantLines := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	gotLines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	count := len(wantLines)
+	if len(gotLines) > count {
+		count = len(gotLines)
+	}
+
+	var sb strings.Builder
+	mismatches := 0
+	for i := 0; i < count; i++ {
+		line := func(lines []string) string {
+			if i < len(lines) {
+				return lines[i]
+			}
+			return "<missing>"
+		}
+
+		w, g := line(wantLines), line(gotLines)
+		if w == g {
+			continue
+		}
+
+		mismatches++
+		if mismatches > maxMismatches {
+			continue
+		}
+		fmt.Fprintf(&sb, "line %d:\n\twant: %s\n\tgot:  %s\n", i+1, w, g)
+	}
+
+	if mismatches > maxMismatches {
+		fmt.Fprintf(&sb, "and %d more differing lines\n", mismatches-maxMismatches)
+	}
+	if len(wantLines) != len(gotLines) {
+		fmt.Fprintf(&sb, "want %d commands, got %d\n", len(wantLines), len(gotLines))
+	}
+	return sb.String()
+}
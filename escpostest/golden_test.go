@@ -0,0 +1,74 @@
+package escpostest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos/escpostest"
+)
+
+// fakeT is a escpostest.TB that keeps the errors and runs the cleanups when
+// finish is called
+type fakeT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestRecordTo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipt.golden")
+
+	receipt := func(total string) []string {
+		t := &fakeT{}
+		golden := escpostest.RecordTo(t, path)
+		golden.SetBold(true)
+		golden.Println("Total")
+		golden.SetBold(false)
+		golden.Println(total)
+		t.finish()
+		return t.errors
+	}
+
+	// The first run writes the golden file
+	if errs := receipt("4.50"); len(errs) != 0 {
+		t.Fatalf("first run failed: %v", errs)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "1b 45 01  # BOLD on\n") {
+		t.Fatalf("golden file starts with %q", strings.SplitAfter(string(data), "\n")[0])
+	}
+
+	if errs := receipt("4.50"); len(errs) != 0 {
+		t.Fatalf("same commands failed: %v", errs)
+	}
+
+	errs := receipt("5.00")
+	if len(errs) != 1 {
+		t.Fatalf("different commands had %d errors instead of 1", len(errs))
+	}
+	for _, line := range []string{`want: 34 2e 35 30  # PRINT "4.50"`, `got:  35 2e 30 30  # PRINT "5.00"`} {
+		if !strings.Contains(errs[0], line) {
+			t.Fatalf("mismatch message did not show %s:\n%s", line, errs[0])
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package escpos
+
+// WatchErrorLimit is watchErrorLimit for the tests of WatchStatus
+const WatchErrorLimit = watchErrorLimit
@@ -0,0 +1,89 @@
+package escpos
+
+import "time"
+
+// WriteFunc writes all of b to the printer connection, or returns an error
+// with the number of bytes of b that were written.  Like io.Writer it must
+// not keep b after it returns, since the buffer is used for the next command.
+type WriteFunc func(b []byte) (int, error)
+
+// WriteFilter wraps the write to the printer connection with more logic,
+// like counting or logging the bytes.  It returns a WriteFunc that calls
+// next to keep writing, and can change the bytes before they are passed on.
+type WriteFilter func(next WriteFunc) WriteFunc
+
+// Use adds a filter to the writes to the printer connection.  The filter
+// added first is the outermost, so it gets the bytes first and the filters
+// added after it get what it passes on.  A nil filter is ignored.
+//
+// Every command goes through the filters and not just printed text, so a
+// filter that changes the bytes has to leave the commands alone.  When the
+// printer is buffering the filters get the whole buffer on Flush.  The
+// debug dump from SetDebugWriter and the pacing from SetWritePacing are
+// filters inside the ones from Use, so the dump shows the bytes that were
+// really sent.
+func (p Printer) Use(filter WriteFilter) {
+	if filter == nil {
+		return
+	}
+
+	if p.config == nil {
+		return
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.filters = append(p.config.filters, filter)
+}
+
+// send writes all of b to the printer connection and stops if the context
+// of the printer is done
+func (p Printer) send(b []byte) (int, error) {
+	if p.config == nil || p.config.ctx == nil {
+		return writeAll(p.dst, b)
+	}
+
+	var setDeadline func(time.Time) error
+	if conn, ok := p.dst.(writeDeadliner); ok {
+		setDeadline = conn.SetWriteDeadline
+	}
+
+	return p.withContext(b, setDeadline, func(b []byte) (int, error) { return writeAll(p.dst, b) })
+}
+
+// writeChain returns the write to the connection wrapped with the filters
+// from Use and the built-in debug and pacing filters
+func (p Printer) writeChain() WriteFunc {
+	write := WriteFunc(p.send)
+	if p.config == nil {
+		return write
+	}
+
+	if p.config.pacing > 0 {
+		write = pacingFilter(p.config.pacing)(write)
+	}
+	if p.config.debug != nil {
+		write = p.debugFilter(write)
+	}
+	for i := len(p.config.filters) - 1; i >= 0; i-- {
+		write = p.config.filters[i](write)
+	}
+	return write
+}
+
+// pacingFilter limits the writes to rate bytes a second like SetWritePacing
+func pacingFilter(rate int) WriteFilter {
+	return func(next WriteFunc) WriteFunc {
+		return pacedWriter{next: next, rate: rate}.Write
+	}
+}
+
+// debugFilter writes the bytes that were sent to the debug writer
+func (p Printer) debugFilter(next WriteFunc) WriteFunc {
+	return func(b []byte) (int, error) {
+		n, err := next(b)
+		p.dumpDebug(b[:n])
+		return n, err
+	}
+}
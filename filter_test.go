@@ -0,0 +1,127 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// filters records the bytes that go through the count and upper filters
+type filters struct {
+	events  []string
+	counted int
+}
+
+func (f *filters) count(next escpos.WriteFunc) escpos.WriteFunc {
+	return func(b []byte) (int, error) {
+		f.events = append(f.events, fmt.Sprintf("count %q", b))
+		n, err := next(b)
+		f.counted += n
+		return n, err
+	}
+}
+
+func (f *filters) upper(next escpos.WriteFunc) escpos.WriteFunc {
+	return func(b []byte) (int, error) {
+		f.events = append(f.events, fmt.Sprintf("upper %q", b))
+		return next(bytes.ToUpper(b))
+	}
+}
+
+func TestWriteFilter(t *testing.T) {
+	f := &filters{}
+	var debug strings.Builder
+
+	sink, printer := escpos.NewCapturePrinter()
+	printer.SetDebugWriter(&debug)
+	printer.Use(f.count)
+	printer.Use(f.upper)
+	printer.Use(nil)
+
+	err := printer.Print("total 3.50")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	err = printer.LF()
+	if err != nil {
+		t.Fatalf("could not feed: %v", err)
+	}
+
+	// count was added first so it sees the bytes before upper changes them
+	want := []string{`count "total 3.50"`, `upper "total 3.50"`, `count "\n"`, `upper "\n"`}
+	if !reflect.DeepEqual(f.events, want) {
+		t.Fatalf("filters ran as %q instead of %q", f.events, want)
+	}
+	if string(sink.Bytes()) != "TOTAL 3.50\n" {
+		t.Fatalf("sent %q", sink.Bytes())
+	}
+	if f.counted != len(sink.Bytes()) {
+		t.Fatalf("counted %d bytes instead of %d", f.counted, len(sink.Bytes()))
+	}
+	if !strings.Contains(debug.String(), "TOTAL 3.50") {
+		t.Fatalf("debug dump didn't have the filtered bytes:\n%s", debug.String())
+	}
+}
+
+func TestWriteFilterBuffered(t *testing.T) {
+	f := &filters{}
+
+	sink, printer := escpos.NewCapturePrinter()
+	printer.Use(f.count)
+	printer.Use(f.upper)
+
+	// Buffered writes go through the filters once on Flush
+	err := printer.SetBuffered(true)
+	if err != nil {
+		t.Fatalf("could not buffer: %v", err)
+	}
+
+	err = printer.Print("a", "b")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	err = printer.Print("c")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+	if len(f.events) != 0 {
+		t.Fatalf("filters ran before Flush: %q", f.events)
+	}
+
+	err = printer.Flush()
+	if err != nil {
+		t.Fatalf("could not flush: %v", err)
+	}
+
+	want := []string{`count "abc"`, `upper "abc"`}
+	if !reflect.DeepEqual(f.events, want) || string(sink.Bytes()) != "ABC" {
+		t.Fatalf("flush ran %q and sent %q", f.events, sink.Bytes())
+	}
+	if f.counted != 3 {
+		t.Fatalf("counted %d bytes instead of 3", f.counted)
+	}
+}
+
+func TestWriteFilterError(t *testing.T) {
+	errFilter := errors.New("filter failed")
+
+	sink, printer := escpos.NewCapturePrinter()
+	printer.Use(func(next escpos.WriteFunc) escpos.WriteFunc {
+		return func(b []byte) (int, error) { return 0, errFilter }
+	})
+
+	err := printer.Print("total 3.50")
+	if !errors.Is(err, errFilter) {
+		t.Fatalf("print returned %v instead of the filter error", err)
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent %q after the filter failed", sink.Bytes())
+	}
+}
@@ -1,7 +1,15 @@
 module github.com/joeyak/go-escpos
 
-go 1.18
+go 1.21
 
-require github.com/alexflint/go-arg v1.5.1
+require (
+	github.com/alexflint/go-arg v1.5.1
+	go.bug.st/serial v1.6.2
+	golang.org/x/text v0.14.0
+)
 
-require github.com/alexflint/go-scalar v1.2.0 // indirect
+require (
+	github.com/alexflint/go-scalar v1.2.0 // indirect
+	github.com/creack/goselect v0.1.2 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+)
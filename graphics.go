@@ -0,0 +1,304 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+)
+
+// graphics sends a GS ( L graphics command.  Commands with more than 65535
+// bytes of parameters are sent with GS 8 L, which has a 4 byte length.
+func (p Printer) graphics(m, fn byte, params ...byte) error {
+	length := len(params) + 2
+
+	buf := getScratch()
+	defer putScratch(buf)
+
+	data := *buf
+	if length > 0xFFFF {
+		data = append(data, GS, '8', 'L', byte(length), byte(length>>8), byte(length>>16), byte(length>>24), m, fn)
+	} else {
+		data = append(data, GS, '(', 'L', byte(length), byte(length>>8), m, fn)
+	}
+	data = append(data, params...)
+	*buf = data
+
+	_, err := p.Write(data)
+	if err != nil {
+		return fmt.Errorf("could not send graphics function %d: %w", fn, err)
+	}
+	return nil
+}
+
+func checkKeyCode(keyCode [2]byte) error {
+	for i, kc := range keyCode {
+		err := checkRange(int(kc), 32, 126, fmt.Sprintf("key code %d", i+1))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefineNVImage stores the image in the NV (non-volatile) memory of the
+// printer under the key code so it can be printed with PrintNVImage without
+// sending the image again.  The image stays stored after the printer is
+// turned off.  Defining an image with a key code that is already used
+// replaces the old image.
+//
+// Each key code byte must be a printable ASCII character between 32 and 126,
+// like [2]byte{'L', '1'}.  The image can be up to 8192 pixels wide and 2400
+// pixels tall.
+//
+// The NV memory is shared between all the stored images and the size depends
+// on the model, it's usually between 64KB and 384KB.  Writing NV memory wears
+// it out, so images should be defined when they change and not for every
+// receipt.  Epson recommends no more than 10 writes a day.
+func (p Printer) DefineNVImage(keyCode [2]byte, img image.Image) error {
+	imgRect := img.Bounds()
+	errMsg := "could not define NV image: %w"
+
+	err := checkKeyCode(keyCode)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(imgRect.Dx(), 1, 8192, "image width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(imgRect.Dy(), 1, 2400, "image height")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	bm := monochrome(img, defaultImageOptions)
+	width, height := bm.width, bm.height
+
+	// a=48 is monochrome, b=1 is the number of colors, c=49 is the first color
+	params := []byte{'0', keyCode[0], keyCode[1], 1, byte(width), byte(width >> 8), byte(height), byte(height >> 8), '1'}
+	for y := 0; y < height; y++ {
+		params = appendRasterRow(params, bm, y)
+	}
+
+	// Function 67: define the NV graphics data in raster format
+	err = p.graphics('0', 'C', params...)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// PrintNVImage prints the image stored under the key code by DefineNVImage.
+// The mode can double the width and/or height of each dot.
+func (p Printer) PrintNVImage(keyCode [2]byte, scale RasterMode) error {
+	errMsg := "could not print NV image: %w"
+
+	err := checkKeyCode(keyCode)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(scale, RasterNormal, RasterDoubleWidth, RasterDoubleHeight, RasterQuadruple)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	x, y := byte(1), byte(1)
+	if scale == RasterDoubleWidth || scale == RasterQuadruple {
+		x = 2
+	}
+	if scale == RasterDoubleHeight || scale == RasterQuadruple {
+		y = 2
+	}
+
+	// Function 69: print the specified NV graphics data
+	err = p.graphics('0', 'E', keyCode[0], keyCode[1], x, y)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// Max bytes of image data in a single GS ( L store command after the 10 bytes
+// used by the header
+const graphicsMaxData = 0xFFFF - 10
+
+// GraphicsOptions are the settings for PrintGraphics
+type GraphicsOptions struct {
+	// Scale can double the width and/or height of each dot
+	Scale RasterMode
+	// Image controls how the image is converted to black and white.  The zero
+	// value gives the same result as PrintImage24.
+	Image ImageOptions
+}
+
+// graphicsBands splits an image with rows of rowBytes bytes into bands of at
+// most maxRows rows that each fit in a single store command
+func graphicsBands(rowBytes, height, maxRows int) []int {
+	if graphicsMaxData/rowBytes < maxRows {
+		maxRows = graphicsMaxData / rowBytes
+	}
+	var bands []int
+	for height > maxRows {
+		bands = append(bands, maxRows)
+		height -= maxRows
+	}
+	if height > 0 {
+		bands = append(bands, height)
+	}
+	return bands
+}
+
+// PrintGraphics prints an image with the GS ( L graphics functions.  The
+// image is stored in the print buffer and then printed at the full 180dpi in
+// both directions without the banding of PrintImage24.
+//
+// The image can be as wide as the profile dot width, or half of it when the
+// width is doubled.
+// Tall images are split into bands that each fit in one store command, which
+// is up to 65525 bytes of image data and 2400 rows, or 1200 rows when the
+// height is doubled.  SetImageChunkBands can make the bands smaller.  An
+// error is returned before anything is sent when the image is too wide,
+// unless the OnOverflow of the image options crops or scales it.
+func (p Printer) PrintGraphics(img image.Image, opts GraphicsOptions) error {
+	errMsg := "could not print graphics: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := checkEnum(opts.Scale, RasterNormal, RasterDoubleWidth, RasterDoubleHeight, RasterQuadruple)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkImageOptions(opts.Image)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	density := DoubleDensity
+	bx, by := byte(1), byte(1)
+	if opts.Scale == RasterDoubleWidth || opts.Scale == RasterQuadruple {
+		density, bx = SingleDensity, 2
+	}
+	if opts.Scale == RasterDoubleHeight || opts.Scale == RasterQuadruple {
+		by = 2
+	}
+
+	if img.Bounds().Empty() {
+		return fmt.Errorf(errMsg, fmt.Errorf("image is empty"))
+	}
+
+	img, err = p.fitOverflow(img, density, opts.Image)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// The whole image is dithered before it is split into bands so the error
+	// carries across the bands without seams
+	bm := monochrome(img, opts.Image)
+	width := bm.width
+	rowBytes := (width + 7) / 8
+
+	buf := getScratch()
+	defer putScratch(buf)
+
+	y := 0
+	for _, height := range graphicsBands(rowBytes, bm.height, p.imageChunkRows(2400/int(by))) {
+		// a=48 is monochrome, c=49 is the first color
+		params := append((*buf)[:0], '0', bx, by, '1', byte(width), byte(width>>8), byte(height), byte(height>>8))
+		for i := 0; i < height; i++ {
+			params = appendRasterRow(params, bm, y+i)
+		}
+		*buf = params
+		y += height
+
+		// Function 112: store the graphics data in the print buffer
+		err = p.graphics('0', 'p', params...)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		// Function 50: print the graphics data in the print buffer
+		err = p.graphics('0', '2')
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		// Wait for the band to finish
+		_, err = p.TransmitErrorStatus()
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// DefineDownloadImage stores the image in the volatile memory of the printer
+// with GS * so it can be printed with PrintDownloadImage without sending the
+// image again.  The image is lost when the printer is turned off or
+// initialized, or when another image is defined.
+//
+// The width and height are sent in 8 dot units so the image is padded with
+// white to a multiple of 8.  The image can be up to 2040 pixels wide and 384
+// pixels tall, and the width times the height in 8 dot units can be at most
+// 1536 for the download buffer.
+func (p Printer) DefineDownloadImage(img image.Image) error {
+	imgRect := img.Bounds()
+	errMsg := "could not define download image: %w"
+
+	x := (imgRect.Dx() + 7) / 8
+	y := (imgRect.Dy() + 7) / 8
+
+	err := checkRange(x, 1, 255, "image width in 8 dot units")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(y, 1, 48, "image height in 8 dot units")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(x*y, 1, 1536, "image width times height in 8 dot units")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	bm := monochrome(img, defaultImageOptions)
+
+	// Each column is y bytes going down, starting from the left
+	data := []byte{GS, '*', byte(x), byte(y)}
+	for col := 0; col < x*8; col++ {
+		for row := 0; row < y; row++ {
+			data = append(data, imageColumn(bm, col, row*8))
+		}
+	}
+
+	_, err = p.Write(data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// PrintDownloadImage prints the image stored by DefineDownloadImage.  The
+// mode can double the width and/or height of each dot.
+func (p Printer) PrintDownloadImage(scale RasterMode) error {
+	errMsg := "could not print download image: %w"
+
+	err := checkEnum(scale, RasterNormal, RasterDoubleWidth, RasterDoubleHeight, RasterQuadruple)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{GS, '/', byte(scale)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package escpos_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestDefineNVImage(t *testing.T) {
+	// Only the first dot is black, and the 10 dot rows are padded to 2 bytes
+	img := image.NewGray(image.Rect(0, 0, 10, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 10; x++ {
+			img.SetGray(x, y, color.Gray{255})
+		}
+	}
+	img.SetGray(0, 0, color.Gray{0})
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.DefineNVImage([2]byte{'L', '1'}, img)
+	if err != nil {
+		t.Fatalf("could not define image: %v", err)
+	}
+
+	want := []byte{
+		escpos.GS, '(', 'L', 15, 0, '0', 'C',
+		'0', 'L', '1', 1, 10, 0, 2, 0, '1',
+		0x80, 0, 0, 0,
+	}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+}
+
+func TestDefineNVImageLarge(t *testing.T) {
+	// 1024 bytes a row for 70 rows doesn't fit the 2 byte length of GS ( L
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.DefineNVImage([2]byte{'L', '2'}, image.NewGray(image.Rect(0, 0, 8192, 70)))
+	if err != nil {
+		t.Fatalf("could not define image: %v", err)
+	}
+
+	length := 2 + 9 + 1024*70
+	want := []byte{
+		escpos.GS, '8', 'L', byte(length), byte(length >> 8), byte(length >> 16), 0, '0', 'C',
+		'0', 'L', '2', 1, 0, 0x20, 70, 0, '1',
+	}
+	data := sink.Bytes()
+	if !bytes.HasPrefix(data, want) {
+		t.Fatalf("header was % x instead of % x", data[:len(want)], want)
+	}
+	if len(data) != 7+length {
+		t.Fatalf("sent %d bytes instead of %d", len(data), 7+length)
+	}
+}
+
+func TestPrintNVImage(t *testing.T) {
+	cases := []struct {
+		name  string
+		scale escpos.RasterMode
+		x, y  byte
+	}{
+		{"normal", escpos.RasterNormal, 1, 1},
+		{"double width", escpos.RasterDoubleWidth, 2, 1},
+		{"double height", escpos.RasterDoubleHeight, 1, 2},
+		{"quadruple", escpos.RasterQuadruple, 2, 2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintNVImage([2]byte{'L', '1'}, c.scale)
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+
+			want := []byte{escpos.GS, '(', 'L', 6, 0, '0', 'E', 'L', '1', c.x, c.y}
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestNVImageErrors(t *testing.T) {
+	small := image.NewGray(image.Rect(0, 0, 8, 8))
+
+	cases := []struct {
+		name string
+		run  func(escpos.Printer) error
+	}{
+		{"define key code", func(p escpos.Printer) error { return p.DefineNVImage([2]byte{'L', 0x1F}, small) }},
+		{"define too wide", func(p escpos.Printer) error {
+			return p.DefineNVImage([2]byte{'L', '1'}, image.NewGray(image.Rect(0, 0, 8193, 8)))
+		}},
+		{"define too tall", func(p escpos.Printer) error {
+			return p.DefineNVImage([2]byte{'L', '1'}, image.NewGray(image.Rect(0, 0, 8, 2401)))
+		}},
+		{"define empty", func(p escpos.Printer) error {
+			return p.DefineNVImage([2]byte{'L', '1'}, image.NewGray(image.Rect(0, 0, 0, 0)))
+		}},
+		{"print key code", func(p escpos.Printer) error { return p.PrintNVImage([2]byte{127, '1'}, escpos.RasterNormal) }},
+		{"print scale", func(p escpos.Printer) error { return p.PrintNVImage([2]byte{'L', '1'}, escpos.RasterMode(4)) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.run(printer)
+			if err == nil {
+				t.Fatalf("NV image did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestDefineDownloadImage(t *testing.T) {
+	// A 10x9 image is padded to 2x2 units of 8 dots, with only the top left
+	// dot black
+	img := image.NewGray(image.Rect(0, 0, 10, 9))
+	for y := 0; y < 9; y++ {
+		for x := 0; x < 10; x++ {
+			img.SetGray(x, y, color.Gray{255})
+		}
+	}
+	img.SetGray(0, 0, color.Gray{0})
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.DefineDownloadImage(img)
+	if err != nil {
+		t.Fatalf("could not define image: %v", err)
+	}
+
+	// Each of the 16 columns is 2 bytes going down
+	want := append([]byte{escpos.GS, '*', 2, 2, 0x80}, make([]byte, 16*2-1)...)
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+}
+
+func TestDefineDownloadImageLimits(t *testing.T) {
+	cases := []struct {
+		name          string
+		width, height int
+		ok            bool
+	}{
+		{"widest", 2040, 8, true},
+		{"tallest", 8, 384, true},
+		{"largest buffer", 256, 384, true},
+		{"too wide", 2041, 8, false},
+		{"too tall", 8, 385, false},
+		{"buffer full", 320, 320, false},
+		{"empty", 0, 0, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.DefineDownloadImage(image.NewGray(image.Rect(0, 0, c.width, c.height)))
+			if c.ok && err != nil {
+				t.Fatalf("could not define image: %v", err)
+			}
+			if !c.ok && (err == nil || len(sink.Bytes()) > 0) {
+				t.Fatalf("image did not fail without sending anything: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrintDownloadImage(t *testing.T) {
+	for _, scale := range []escpos.RasterMode{escpos.RasterNormal, escpos.RasterDoubleWidth, escpos.RasterDoubleHeight, escpos.RasterQuadruple} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.PrintDownloadImage(scale)
+		if err != nil {
+			t.Fatalf("could not print image: %v", err)
+		}
+
+		want := []byte{escpos.GS, '/', byte(scale)}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+		}
+	}
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.PrintDownloadImage(escpos.RasterMode(4))
+	if err == nil || len(sink.Bytes()) > 0 {
+		t.Fatalf("scale 4 did not fail without sending anything: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package escpos
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/bits"
+	"os"
+)
+
+func init() {
+	image.RegisterFormat("bmp", "BM", decodeBMP, decodeBMPConfig)
+}
+
+// DecodeImage decodes a PNG, JPEG, GIF, or BMP image.  The format comes from
+// the data and not the file name, so a file with the wrong extension still
+// decodes.
+func DecodeImage(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if errors.Is(err, image.ErrFormat) {
+		return nil, fmt.Errorf("could not decode image: unknown format, the supported formats are PNG, JPEG, GIF, and BMP")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode image: %w", err)
+	}
+	return img, nil
+}
+
+// PrintImageFile decodes the image file at path with DecodeImage and prints it
+// with PrintImage24
+func (p Printer) PrintImageFile(path string, density Density) error {
+	errMsg := "could not print image file: %w"
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	defer file.Close()
+
+	img, err := DecodeImage(file)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.PrintImage24(img, density)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// bmpHeader is the part of the BMP file and info headers needed to read the
+// pixels
+type bmpHeader struct {
+	offset        int
+	width, height int
+	// topDown is set when the rows are stored from the top instead of the
+	// bottom
+	topDown     bool
+	bpp         int
+	compression uint32
+	palette     color.Palette
+	// masks are the red, green, blue, and alpha masks of 32 bit pixels
+	masks [4]uint32
+}
+
+// readBMPHeader reads the headers of an uncompressed BMP with a
+// BITMAPINFOHEADER or newer
+func readBMPHeader(data []byte) (bmpHeader, error) {
+	if len(data) < 54 || data[0] != 'B' || data[1] != 'M' {
+		return bmpHeader{}, fmt.Errorf("bmp: not a BMP file")
+	}
+
+	u16 := func(i int) int { return int(binary.LittleEndian.Uint16(data[i:])) }
+	u32 := func(i int) uint32 { return binary.LittleEndian.Uint32(data[i:]) }
+
+	h := bmpHeader{
+		offset:      int(u32(10)),
+		width:       int(int32(u32(18))),
+		height:      int(int32(u32(22))),
+		bpp:         u16(28),
+		compression: u32(30),
+	}
+
+	infoSize := int(u32(14))
+	if infoSize < 40 || 14+infoSize > len(data) {
+		return bmpHeader{}, fmt.Errorf("bmp: unsupported info header size %d", infoSize)
+	}
+
+	if h.height < 0 {
+		h.height, h.topDown = -h.height, true
+	}
+	if h.width <= 0 || h.height == 0 {
+		return bmpHeader{}, fmt.Errorf("bmp: invalid size %dx%d", h.width, h.height)
+	}
+
+	switch {
+	case h.compression == 0 && (h.bpp == 1 || h.bpp == 4 || h.bpp == 8):
+		colors := int(u32(46))
+		if colors == 0 || colors > 1<<h.bpp {
+			colors = 1 << h.bpp
+		}
+
+		start := 14 + infoSize
+		if start+colors*4 > len(data) {
+			return bmpHeader{}, fmt.Errorf("bmp: palette is cut off")
+		}
+
+		for i := 0; i < colors; i++ {
+			c := data[start+i*4:]
+			h.palette = append(h.palette, color.RGBA{c[2], c[1], c[0], 0xFF})
+		}
+
+	case h.compression == 0 && (h.bpp == 24 || h.bpp == 32):
+		// The fourth byte of 32 bit pixels isn't used without bit fields
+		h.masks = [4]uint32{0xFF0000, 0xFF00, 0xFF, 0}
+
+	case h.compression == 3 && h.bpp == 32:
+		// The masks are after a BITMAPINFOHEADER or part of a newer header
+		if 14+40+12 > len(data) {
+			return bmpHeader{}, fmt.Errorf("bmp: bit field masks are cut off")
+		}
+		h.masks = [4]uint32{u32(54), u32(58), u32(62), 0}
+		if infoSize >= 56 {
+			h.masks[3] = u32(66)
+		}
+
+	default:
+		return bmpHeader{}, fmt.Errorf("bmp: unsupported %d bit image with compression %d", h.bpp, h.compression)
+	}
+
+	return h, nil
+}
+
+// bmpChannel pulls the masked bits out of a pixel and scales them to 8 bits
+func bmpChannel(pixel, mask uint32) uint8 {
+	if mask == 0 {
+		return 0xFF
+	}
+
+	v := (pixel & mask) >> bits.TrailingZeros32(mask)
+	full := mask >> bits.TrailingZeros32(mask)
+	return uint8(uint64(v) * 0xFF / uint64(full))
+}
+
+func decodeBMPConfig(r io.Reader) (image.Config, error) {
+	// The palette comes right after the info header, so read enough for the
+	// largest header and palette
+	data := make([]byte, 14+124+256*4)
+	n, err := io.ReadFull(r, data)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return image.Config{}, err
+	}
+
+	h, err := readBMPHeader(data[:n])
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	var model color.Model = color.NRGBAModel
+	if h.palette != nil {
+		model = h.palette
+	}
+	return image.Config{ColorModel: model, Width: h.width, Height: h.height}, nil
+}
+
+func decodeBMP(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := readBMPHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows are padded to 4 bytes
+	stride := (h.width*h.bpp + 31) / 32 * 4
+	if h.offset < 0 || h.offset+stride*h.height > len(data) {
+		return nil, fmt.Errorf("bmp: pixel data is cut off")
+	}
+
+	row := func(y int) []byte {
+		if !h.topDown {
+			y = h.height - 1 - y
+		}
+		start := h.offset + y*stride
+		return data[start : start+stride]
+	}
+
+	if h.palette != nil {
+		img := image.NewPaletted(image.Rect(0, 0, h.width, h.height), h.palette)
+		perByte := 8 / h.bpp
+		for y := 0; y < h.height; y++ {
+			pixels := row(y)
+			for x := 0; x < h.width; x++ {
+				b := pixels[x/perByte]
+				shift := 8 - h.bpp*(x%perByte+1)
+				index := b >> shift & (1<<h.bpp - 1)
+				if int(index) >= len(h.palette) {
+					index = 0
+				}
+				img.SetColorIndex(x, y, index)
+			}
+		}
+		return img, nil
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+	bytesPerPixel := h.bpp / 8
+	for y := 0; y < h.height; y++ {
+		pixels := row(y)
+		for x := 0; x < h.width; x++ {
+			p := pixels[x*bytesPerPixel:]
+
+			var pixel uint32
+			if bytesPerPixel == 3 {
+				pixel = uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16
+			} else {
+				pixel = binary.LittleEndian.Uint32(p)
+			}
+
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: bmpChannel(pixel, h.masks[0]),
+				G: bmpChannel(pixel, h.masks[1]),
+				B: bmpChannel(pixel, h.masks[2]),
+				A: bmpChannel(pixel, h.masks[3]),
+			})
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,134 @@
+package escpos_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestDecodeImage(t *testing.T) {
+	img := checkerboard(96, 48, 8)
+
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatalf("could not encode PNG: %v", err)
+	}
+
+	decoded, err := escpos.DecodeImage(buf)
+	if err != nil {
+		t.Fatalf("could not decode PNG: %v", err)
+	}
+
+	if decoded.Bounds() != img.Bounds() {
+		t.Fatalf("decoded image is %v instead of %v", decoded.Bounds(), img.Bounds())
+	}
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 96; x++ {
+			got, want := color.GrayModel.Convert(decoded.At(x, y)), img.At(x, y)
+			if got != want {
+				t.Fatalf("decoded pixel %d,%d is %v instead of %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestDecodeImageUnknown(t *testing.T) {
+	_, err := escpos.DecodeImage(strings.NewReader("not an image"))
+	if err == nil || !strings.Contains(err.Error(), "PNG, JPEG, GIF, and BMP") {
+		t.Fatalf("unknown format should list the supported formats, got %v", err)
+	}
+}
+
+func TestDecodeImageBMP(t *testing.T) {
+	// A 2x2 24 bit BMP stored from the bottom row up, with each 6 byte row
+	// padded to 8 bytes
+	header := []byte{
+		'B', 'M', 70, 0, 0, 0, 0, 0, 0, 0, 54, 0, 0, 0,
+		40, 0, 0, 0, 2, 0, 0, 0, 2, 0, 0, 0, 1, 0, 24, 0,
+		0, 0, 0, 0, 16, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	}
+	pixels := []byte{
+		// Bottom row is blue then white
+		0xFF, 0, 0, 0xFF, 0xFF, 0xFF, 0, 0,
+		// Top row is black then red
+		0, 0, 0, 0, 0, 0xFF, 0, 0,
+	}
+
+	img, err := escpos.DecodeImage(bytes.NewReader(append(header, pixels...)))
+	if err != nil {
+		t.Fatalf("could not decode BMP: %v", err)
+	}
+
+	want := [][]color.NRGBA{
+		{{0, 0, 0, 0xFF}, {0xFF, 0, 0, 0xFF}},
+		{{0, 0, 0xFF, 0xFF}, {0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+	for y, row := range want {
+		for x, c := range row {
+			if got := color.NRGBAModel.Convert(img.At(x, y)); got != c {
+				t.Fatalf("decoded pixel %d,%d is %v instead of %v", x, y, got, c)
+			}
+		}
+	}
+}
+
+func TestPrintImageFile(t *testing.T) {
+	img := checkerboard(96, 48, 8)
+
+	// The extension is wrong so the format has to come from the content
+	path := filepath.Join(t.TempDir(), "logo.dat")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("could not create image file: %v", err)
+	}
+	err = png.Encode(file, img)
+	file.Close()
+	if err != nil {
+		t.Fatalf("could not encode PNG: %v", err)
+	}
+
+	sink, printer := escpos.NewCapturePrinter()
+	err = printer.PrintImageFile(path, escpos.DoubleDensity)
+	if err != nil {
+		t.Fatalf("could not print image file: %v", err)
+	}
+
+	wantSink, wantPrinter := escpos.NewCapturePrinter()
+	err = wantPrinter.PrintImage24(img, escpos.DoubleDensity)
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+	if !bytes.Equal(sink.Bytes(), wantSink.Bytes()) {
+		t.Fatalf("image file sent % x instead of % x", sink.Bytes(), wantSink.Bytes())
+	}
+}
+
+func TestPrintImageFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	text := filepath.Join(dir, "logo.png")
+	err := os.WriteFile(text, []byte("not an image"), 0o644)
+	if err != nil {
+		t.Fatalf("could not write file: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing.png"), text} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.PrintImageFile(path, escpos.DoubleDensity)
+		if err == nil {
+			t.Fatalf("printing %s did not fail", filepath.Base(path))
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package escpos
+
+import (
+	"fmt"
+	"strings"
+)
+
+var markupBarCodes = map[string]BarCode{
+	"UPCA":    BcUPCA,
+	"UPCE":    BcUPCE,
+	"EAN13":   BcEAN13,
+	"JAN13":   BcJAN13,
+	"EAN8":    BcEAN8,
+	"JAN8":    BcJAN8,
+	"CODE39":  BcCODE39,
+	"ITF":     BcITF,
+	"CODABAR": BcCODABAR,
+	"CODE93":  BcCODE93,
+	"CODE128": BcCODE128,
+}
+
+// PrintMarkup prints text with a small markup for formatting.  Each line of
+// the markup is printed as a line on the paper.
+//
+//	**bold**              bold text
+//	_underline_           underlined text
+//	##center##            a centered line, this must be the whole line
+//	---                   a line of dashes across the paper
+//	[qr:DATA]             a QR code of DATA
+//	[barcode:TYPE:DATA]   a bar code like [barcode:CODE128:12345]
+//
+// Bold and underline must be closed on the same line.  Markers that aren't
+// closed and tags that aren't known are printed as they are.  The bar code
+// types are the names of the Bc constants without the Bc, like CODE39 or
+// EAN13.
+func (p Printer) PrintMarkup(markup string) error {
+	errMsg := "could not print markup: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	// A newline at the end doesn't print an extra empty line
+	markup = strings.TrimSuffix(markup, "\n")
+
+	for i, line := range strings.Split(markup, "\n") {
+		err := p.printMarkupLine(strings.TrimRight(line, "\r"))
+		if err != nil {
+			return fmt.Errorf(errMsg, fmt.Errorf("line %d: %w", i+1, err))
+		}
+	}
+
+	return nil
+}
+
+func (p Printer) printMarkupLine(line string) error {
+	if line == "---" {
+		return p.Println(strings.Repeat("-", p.Columns()))
+	}
+
+	if len(line) >= 4 && strings.HasPrefix(line, "##") && strings.HasSuffix(line, "##") {
+		err := p.Justify(CenterJustify)
+		if err != nil {
+			return err
+		}
+
+		err = p.printMarkupText(line[2 : len(line)-2])
+		if err != nil {
+			return err
+		}
+
+		return p.Justify(LeftJustify)
+	}
+
+	return p.printMarkupText(line)
+}
+
+// printMarkupText prints the bold, underline, and tags of a line and ends it
+// with a line feed
+func (p Printer) printMarkupText(line string) error {
+	var text strings.Builder
+	flush := func() error {
+		if text.Len() == 0 {
+			return nil
+		}
+		err := p.Print(text.String())
+		text.Reset()
+		return err
+	}
+
+	bold, underline := false, false
+	for i := 0; i < len(line); {
+		rest := line[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "**") && (bold || strings.Contains(rest[2:], "**")):
+			err := flush()
+			if err != nil {
+				return err
+			}
+
+			bold = !bold
+			err = p.SetBold(bold)
+			if err != nil {
+				return err
+			}
+			i += 2
+			continue
+
+		case rest[0] == '_' && (underline || strings.Contains(rest[1:], "_")):
+			err := flush()
+			if err != nil {
+				return err
+			}
+
+			underline = !underline
+			mode := UnderlineOff
+			if underline {
+				mode = UnderlineThin
+			}
+			err = p.SetUnderline(mode)
+			if err != nil {
+				return err
+			}
+			i++
+			continue
+
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				break
+			}
+
+			printTag, ok := p.markupTag(rest[1:end])
+			if !ok {
+				break
+			}
+
+			err := flush()
+			if err != nil {
+				return err
+			}
+
+			err = printTag()
+			if err != nil {
+				return err
+			}
+			i += end + 1
+			continue
+		}
+
+		text.WriteByte(line[i])
+		i++
+	}
+
+	err := flush()
+	if err != nil {
+		return err
+	}
+	return p.LF()
+}
+
+// markupTag returns a function that prints the tag, or false when the tag is
+// not known
+func (p Printer) markupTag(tag string) (func() error, bool) {
+	name, data, ok := strings.Cut(tag, ":")
+	if !ok {
+		return nil, false
+	}
+
+	switch name {
+	case "qr":
+		return func() error { return p.QRCode(data, QRModel2, 6, QRErrorM) }, true
+
+	case "barcode":
+		typ, data, ok := strings.Cut(data, ":")
+		if !ok {
+			return nil, false
+		}
+
+		barcode, ok := markupBarCodes[strings.ToUpper(typ)]
+		if !ok {
+			return nil, false
+		}
+		return func() error { return p.PrintBarCode(barcode, data) }, true
+	}
+
+	return nil, false
+}
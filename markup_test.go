@@ -0,0 +1,76 @@
+package escpos_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestPrintMarkup(t *testing.T) {
+	qrCode := []string{
+		"SYMBOL 49 function 65",
+		"SYMBOL 49 function 67",
+		"SYMBOL 49 function 69",
+		"SYMBOL 49 function 80",
+		"SYMBOL 49 function 81",
+	}
+
+	cases := []struct {
+		name   string
+		markup string
+		want   []string
+	}{
+		{"plain", "Coffee", []string{`PRINT "Coffee"`, "LF"}},
+		{"lines", "a\r\nb\n", []string{`PRINT "a"`, "LF", `PRINT "b"`, "LF"}},
+		{"empty line", "a\n\nb", []string{`PRINT "a"`, "LF", "LF", `PRINT "b"`, "LF"}},
+		{"bold", "**Total** 3.50", []string{"BOLD on", `PRINT "Total"`, "BOLD off", `PRINT " 3.50"`, "LF"}},
+		{"underline", "_due_", []string{"UNDERLINE 1", `PRINT "due"`, "UNDERLINE 0", "LF"}},
+		{"center", "##Thanks##", []string{"JUSTIFY center", `PRINT "Thanks"`, "LF", "JUSTIFY left"}},
+		{"center bold", "##**Hi**##", []string{"JUSTIFY center", "BOLD on", `PRINT "Hi"`, "BOLD off", "LF", "JUSTIFY left"}},
+		{"divider", "---", []string{`PRINT "` + strings.Repeat("-", 48) + `"`, "LF"}},
+		{"qr", "[qr:hi]", append(qrCode, "LF")},
+		{"barcode", "[barcode:code39:123]", []string{`BARCODE 4 "123"`, "LF"}},
+		{"unknown tag", "[logo:1]", []string{`PRINT "[logo:1]"`, "LF"}},
+		{"unknown bar code", "[barcode:PDF:1]", []string{`PRINT "[barcode:PDF:1]"`, "LF"}},
+		{"unclosed tag", "[qr:hi", []string{`PRINT "[qr:hi"`, "LF"}},
+		{"unclosed bold", "**open", []string{`PRINT "**open"`, "LF"}},
+		{"unclosed underline", "snake_case", []string{`PRINT "snake_case"`, "LF"}},
+		{"short center", "###", []string{`PRINT "###"`, "LF"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintMarkup(c.markup)
+			if err != nil {
+				t.Fatalf("could not print markup: %v", err)
+			}
+
+			if got := sink.Commands(); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("decoded\n%q\ninstead of\n%q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPrintMarkupErrors(t *testing.T) {
+	printer := escpos.NewPrinter(brokenConn{})
+	err := printer.PrintMarkup("**Total**")
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+
+	// EAN13 only takes digits so the error names the second line
+	sink, printer := escpos.NewCapturePrinter()
+	err = printer.PrintMarkup("Total\n[barcode:EAN13:abc]")
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("bad bar code gave %v", err)
+	}
+	if got, want := sink.Commands(), []string{`PRINT "Total"`, "LF"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("decoded %q instead of %q", got, want)
+	}
+}
@@ -104,6 +104,12 @@ func stringToMorse(s string) []int {
 func printMorse(p Printer, message string, f func(t int) error) error {
 	errMsg := "could not send morse code beeps: %w"
 
+	// Check for a buzzer before MorsePrint prints anything
+	err := p.supports("Beep", p.Profile().SupportsBuzzer)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
 	data := stringToMorse(message)
 	for _, t := range data {
 		err := f(t)
@@ -124,6 +130,9 @@ func printMorse(p Printer, message string, f func(t int) error) error {
 
 // Morse beeps out the message in morse code
 func (p Printer) Morse(message string) error {
+	p, unlock := p.lock()
+	defer unlock()
+
 	return printMorse(p, message, func(t int) error { return nil })
 }
 
@@ -131,6 +140,9 @@ func (p Printer) Morse(message string) error {
 func (p Printer) MorsePrint(message string) error {
 	errMsg := "could not print morse: %w"
 
+	p, unlock := p.lock()
+	defer unlock()
+
 	err := printMorse(p, message, func(t int) error {
 		var err error
 		if t == 0 {
@@ -0,0 +1,115 @@
+package escpos
+
+import "fmt"
+
+// PageDirection is the direction and starting corner used to print in page
+// mode
+type PageDirection int
+
+const (
+	// LeftToRight starts at the upper left
+	LeftToRight PageDirection = iota
+	// BottomToTop starts at the lower left
+	BottomToTop
+	// RightToLeft starts at the lower right
+	RightToLeft
+	// TopToBottom starts at the upper right
+	TopToBottom
+)
+
+// EnterPageMode switches from standard mode to page mode.  In page mode
+// everything is drawn into the print area set with SetPageArea and nothing
+// is printed until PrintPage is called.
+//
+// Text is positioned horizontally in the print area with SetAbsolutePosition
+// and SetRelativePosition.
+func (p Printer) EnterPageMode() error {
+	_, err := p.Write([]byte{ESC, 'L'})
+	if err != nil {
+		return fmt.Errorf("could not enter page mode: %w", err)
+	}
+	return nil
+}
+
+// ExitPageMode switches from page mode back to standard mode.  Anything in
+// the page that wasn't printed is thrown away.
+func (p Printer) ExitPageMode() error {
+	_, err := p.Write([]byte{ESC, 'S'})
+	if err != nil {
+		return fmt.Errorf("could not exit page mode: %w", err)
+	}
+	return nil
+}
+
+// SetPageArea sets the position and size of the print area in page mode.  x
+// and y are the position of the upper left corner, and dx and dy are the width
+// and height.  All values are in motion units.
+func (p Printer) SetPageArea(x, y, dx, dy int) error {
+	errMsg := "could not set page area: %w"
+
+	values := []struct {
+		n    int
+		min  int
+		info string
+	}{
+		{x, 0, "x"},
+		{y, 0, "y"},
+		{dx, 1, "dx"},
+		{dy, 1, "dy"},
+	}
+
+	data := []byte{ESC, 'W'}
+	for _, v := range values {
+		err := checkRange(v.n, v.min, 0xFFFF, v.info)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+		data = append(data, byte(v.n), byte(v.n>>8))
+	}
+
+	_, err := p.Write(data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetPrintDirection sets the direction and starting position for printing
+// in page mode
+func (p Printer) SetPrintDirection(dir PageDirection) error {
+	errMsg := "could not set print direction: %w"
+
+	err := checkEnum(dir, LeftToRight, BottomToTop, RightToLeft, TopToBottom)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'T', byte(dir)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// PrintPage prints everything in the page and returns to standard mode
+func (p Printer) PrintPage() error {
+	_, err := p.Write([]byte{FF})
+	if err != nil {
+		return fmt.Errorf("could not print page: %w", err)
+	}
+	return nil
+}
+
+// CancelPrintData throws away the data that hasn't been printed yet with CAN.
+// In standard mode this is the text and commands of the current line, and in
+// page mode it is everything in the current print area set by SetPageArea.
+//
+// Only data already in the printer is thrown away, so call Flush first when
+// buffering is on.
+func (p Printer) CancelPrintData() error {
+	_, err := p.Write([]byte{CAN})
+	if err != nil {
+		return fmt.Errorf("could not cancel print data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestSetPageArea(t *testing.T) {
+	cases := []struct {
+		name         string
+		x, y, dx, dy int
+		want         []byte
+	}{
+		{"origin", 0, 0, 1, 1, []byte{escpos.ESC, 'W', 0, 0, 0, 0, 1, 0, 1, 0}},
+		{"label", 20, 300, 512, 1000, []byte{escpos.ESC, 'W', 20, 0, 0x2C, 0x01, 0x00, 0x02, 0xE8, 0x03}},
+		{"largest", 0xFFFF, 0xFFFF, 0xFFFF, 0xFFFF, []byte{escpos.ESC, 'W', 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetPageArea(c.x, c.y, c.dx, c.dy)
+			if err != nil {
+				t.Fatalf("could not set page area: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestSetPageAreaErrors(t *testing.T) {
+	cases := []struct {
+		name         string
+		x, y, dx, dy int
+	}{
+		{"negative x", -1, 0, 1, 1},
+		{"negative y", 0, -1, 1, 1},
+		{"no width", 0, 0, 0, 1},
+		{"no height", 0, 0, 1, 0},
+		{"x too big", 0x10000, 0, 1, 1},
+		{"height too big", 0, 0, 1, 0x10000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetPageArea(c.x, c.y, c.dx, c.dy)
+			if err == nil {
+				t.Fatalf("page area did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPageMode(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	steps := []func() error{
+		printer.EnterPageMode,
+		func() error { return printer.SetPrintDirection(escpos.TopToBottom) },
+		printer.PrintPage,
+		printer.ExitPageMode,
+	}
+	for _, step := range steps {
+		err := step()
+		if err != nil {
+			t.Fatalf("could not use page mode: %v", err)
+		}
+	}
+
+	want := []byte{escpos.ESC, 'L', escpos.ESC, 'T', 3, escpos.FF, escpos.ESC, 'S'}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+
+	sink.Reset()
+	err := printer.SetPrintDirection(escpos.PageDirection(4))
+	if err == nil {
+		t.Fatalf("print direction 4 did not fail")
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x after failing", sink.Bytes())
+	}
+}
+
+func TestCancelPrintData(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.CancelPrintData()
+	if err != nil {
+		t.Fatalf("could not cancel print data: %v", err)
+	}
+	if !bytes.Equal(sink.Bytes(), []byte{0x18}) {
+		t.Fatalf("sent % x instead of 18", sink.Bytes())
+	}
+
+	// Buffered data hasn't gotten to the printer yet so CAN goes after it
+	sink.Reset()
+	printer.SetBuffered(true)
+	printer.Print("void")
+	printer.CancelPrintData()
+	printer.Flush()
+
+	want := []byte{'v', 'o', 'i', 'd', 0x18}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+
+	err = escpos.NewPrinter(brokenConn{}).CancelPrintData()
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
@@ -0,0 +1,213 @@
+package escpos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// readPNMHeader reads the header of a binary netpbm image with the magic
+// number, like "P4".  PBM images don't have a max value, so it is 1 for them.
+func readPNMHeader(r *bufio.Reader, magic string) (width, height, maxValue int, err error) {
+	// token reads the next number or magic number, skipping the white space
+	// and comments before it
+	token := func() (string, error) {
+		var text []byte
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				if err == io.EOF && len(text) > 0 {
+					return string(text), nil
+				}
+				return "", fmt.Errorf("header is cut off: %w", err)
+			}
+
+			switch {
+			case b == '#' && len(text) == 0:
+				_, err = r.ReadString('\n')
+				if err != nil {
+					return "", fmt.Errorf("header is cut off: %w", err)
+				}
+			case b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f':
+				// The single white space after the last number is the start
+				// of the pixels, so it is read but nothing after it is
+				if len(text) > 0 {
+					return string(text), nil
+				}
+			default:
+				text = append(text, b)
+			}
+		}
+	}
+
+	number := func(info string) (int, error) {
+		text, err := token()
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := strconv.Atoi(text)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%s %q is not a positive number", info, text)
+		}
+		return n, nil
+	}
+
+	got, err := token()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if got != magic {
+		return 0, 0, 0, fmt.Errorf("magic number %q is not %s", got, magic)
+	}
+
+	width, err = number("width")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	height, err = number("height")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if magic == "P4" {
+		return width, height, 1, nil
+	}
+
+	maxValue, err = number("max value")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if maxValue > 0xFFFF {
+		return 0, 0, 0, fmt.Errorf("max value %d is more than 65535", maxValue)
+	}
+	return width, height, maxValue, nil
+}
+
+// printRasterRows prints an image that is width dots wide and height rows
+// tall with GS v 0, where readRow fills each row with the packed dots.  Rows
+// are read as they are sent, so the whole image is never in memory.
+func (p Printer) printRasterRows(width, height int, readRow func(row []byte) error) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := p.checkImageWidth(width, DoubleDensity)
+	if err != nil {
+		return err
+	}
+
+	buf := getScratch()
+	defer putScratch(buf)
+
+	stride := (width + 7) / 8
+	maxRows := p.imageChunkRows(rasterMaxHeight)
+	for y := 0; y < height; y += maxRows {
+		rows := height - y
+		if rows > maxRows {
+			rows = maxRows
+		}
+
+		data := append((*buf)[:0], GS, 'v', '0', byte(RasterNormal), byte(stride), byte(stride>>8), byte(rows), byte(rows>>8))
+		data = append(data, make([]byte, rows*stride)...)
+		*buf = data
+		for i := 0; i < rows; i++ {
+			err = readRow(data[8+i*stride : 8+(i+1)*stride])
+			if err != nil {
+				return fmt.Errorf("could not read row %d: %w", y+i, err)
+			}
+		}
+
+		_, err = p.Write(data)
+		if err != nil {
+			return err
+		}
+
+		// Wait for the block to finish
+		_, err = p.TransmitErrorStatus()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintPBM prints a binary PBM (P4) image with the GS v 0 raster command.
+// The bits of a PBM row are already packed the way the printer wants them, so
+// they are sent as they are read without thresholding or dithering, and a
+// bitmap made for the paper prints exactly.  The image can't be wider than
+// MaxWidthDots.
+func (p Printer) PrintPBM(r io.Reader) error {
+	errMsg := "could not print PBM: %w"
+
+	br := bufio.NewReader(r)
+	width, height, _, err := readPNMHeader(br, "P4")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// The bits after the width in the last byte of a row can be anything, so
+	// they are cleared to not print
+	mask := byte(0xFF)
+	if width%8 != 0 {
+		mask = 0xFF << (8 - width%8)
+	}
+
+	err = p.printRasterRows(width, height, func(row []byte) error {
+		_, err := io.ReadFull(br, row)
+		if err != nil {
+			return err
+		}
+		row[len(row)-1] &= mask
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// PrintPGM prints a binary PGM (P5) grayscale image with the GS v 0 raster
+// command.  Gray levels darker than half of the max value of the image are
+// printed as black and the rest are left white, without dithering.  Like
+// PrintPBM the rows are read as they are sent, and the image can't be wider
+// than MaxWidthDots.
+func (p Printer) PrintPGM(r io.Reader) error {
+	errMsg := "could not print PGM: %w"
+
+	br := bufio.NewReader(r)
+	width, height, maxValue, err := readPNMHeader(br, "P5")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Samples are 2 bytes when the max value doesn't fit in one
+	sampleSize := 1
+	if maxValue > 0xFF {
+		sampleSize = 2
+	}
+	samples := make([]byte, width*sampleSize)
+
+	err = p.printRasterRows(width, height, func(row []byte) error {
+		_, err := io.ReadFull(br, samples)
+		if err != nil {
+			return err
+		}
+
+		for x := 0; x < width; x++ {
+			v := int(samples[x])
+			if sampleSize == 2 {
+				v = int(samples[2*x])<<8 | int(samples[2*x+1])
+			}
+			if 2*v < maxValue {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// raster returns the data of the raster images that were sent
+func raster(sink *escpos.CaptureSink) [][]byte {
+	var data [][]byte
+	for _, cmd := range sink.Decoded() {
+		if strings.HasPrefix(cmd.Name, "RASTER IMAGE") {
+			data = append(data, cmd.Data)
+		}
+	}
+	return data
+}
+
+func TestPrintPNM(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(escpos.Printer) error
+		want []byte
+	}{
+		{
+			// The padding bits after the 10 dots of each row are set, so they
+			// have to be cleared before printing
+			"PBM",
+			func(p escpos.Printer) error {
+				pbm := append([]byte("P4\n# pre-rendered\n10 3\n"), 0xB3, 0xFF, 0x00, 0x40, 0x81, 0x3F)
+				return p.PrintPBM(bytes.NewReader(pbm))
+			},
+			[]byte{escpos.GS, 'v', '0', 0, 2, 0, 3, 0, 0xB3, 0xC0, 0x00, 0x40, 0x81, 0x00},
+		},
+		{
+			// Gray levels below half of the max value are black
+			"PGM",
+			func(p escpos.Printer) error {
+				pgm := append([]byte("P5 4 2 255\n"), 0, 127, 128, 255, 255, 10, 200, 0)
+				return p.PrintPGM(bytes.NewReader(pgm))
+			},
+			[]byte{escpos.GS, 'v', '0', 0, 1, 0, 2, 0, 0xC0, 0x50},
+		},
+		{
+			// 16 bit samples are big endian
+			"16 bit PGM",
+			func(p escpos.Printer) error {
+				pgm := append([]byte("P5 2 1 1000\n"), 0x01, 0xF3, 0x01, 0xF4)
+				return p.PrintPGM(bytes.NewReader(pgm))
+			},
+			[]byte{escpos.GS, 'v', '0', 0, 1, 0, 1, 0, 0x80},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.run(printer)
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+			if got := raster(sink); len(got) != 1 || !bytes.Equal(got[0], c.want) {
+				t.Fatalf("sent % x instead of % x", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPrintPBMChunks(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	// Tall images are sent in chunks like PrintImageRaster
+	err := printer.SetImageChunkBands(1)
+	if err != nil {
+		t.Fatalf("could not set chunk bands: %v", err)
+	}
+
+	err = printer.PrintPBM(bytes.NewReader(append([]byte("P4 8 30\n"), bytes.Repeat([]byte{0xAA}, 30)...)))
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+
+	want := []string{"RASTER IMAGE mode 0 8x24", "STATUS 3", "RASTER IMAGE mode 0 8x6", "STATUS 3"}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("chunked PBM sent %q instead of %q", got, want)
+	}
+}
+
+func TestPrintPBMErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		pbm  string
+	}{
+		{"PGM magic", "P5 8 1 255\n\x00"},
+		{"missing height", "P4 8\n"},
+		{"short data", "P4 8 2\n\x00"},
+		{"negative width", "P4 -8 1\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintPBM(strings.NewReader(c.pbm))
+			if err == nil {
+				t.Fatalf("PBM %q didn't fail", c.pbm)
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPrintPBMTooWide(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	wide := append([]byte("P4 640 1\n"), make([]byte, 80)...)
+	err := printer.PrintPBM(bytes.NewReader(wide))
+	if !errors.Is(err, escpos.ErrImageTooWide) {
+		t.Fatalf("wide PBM returned %v instead of ErrImageTooWide", err)
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("wide PBM sent % x", sink.Bytes())
+	}
+}
@@ -0,0 +1,38 @@
+package escpos
+
+import "sync"
+
+// maxScratch is the largest buffer that is put back in scratchPool, so one
+// big image doesn't keep its memory around
+const maxScratch = 1 << 18
+
+// scratchPool holds the byte buffers commands are put together in, so
+// printing images doesn't allocate new buffers for every band.  A buffer is
+// only used until the write of the command returns, and the pool is safe to
+// use from printers on different goroutines.
+var scratchPool = sync.Pool{New: func() any { return new([]byte) }}
+
+// getScratch returns an empty buffer from the pool
+func getScratch() *[]byte {
+	buf := scratchPool.Get().(*[]byte)
+	*buf = (*buf)[:0]
+	return buf
+}
+
+// putScratch gives the buffer back to the pool
+func putScratch(buf *[]byte) {
+	if cap(*buf) > maxScratch {
+		return
+	}
+	scratchPool.Put(buf)
+}
+
+// writeCommand writes the command from a buffer in the pool, for the small
+// commands that are sent for every band of an image
+func (p Printer) writeCommand(cmd ...byte) (int, error) {
+	buf := getScratch()
+	defer putScratch(buf)
+
+	*buf = append(*buf, cmd...)
+	return p.Write(*buf)
+}
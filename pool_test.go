@@ -0,0 +1,106 @@
+package escpos_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestImageBufferPool(t *testing.T) {
+	// Every other column is black, so each raster row is 0xAA and each 24
+	// dot band is 0xFF 0xFF 0xFF then 0 0 0 for every pair of columns
+	stripes := image.NewGray(image.Rect(0, 0, 64, 30))
+	for y := 0; y < 30; y++ {
+		for x := 1; x < 64; x += 2 {
+			stripes.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+
+	raster := append([]byte{escpos.GS, 'v', '0', 0, 8, 0, 30, 0}, bytes.Repeat([]byte{0xAA}, 8*30)...)
+	band := append([]byte{escpos.ESC, '*', 33, 64, 0}, bytes.Repeat([]byte{0xFF, 0xFF, 0xFF, 0, 0, 0}, 32)...)
+	lastBand := append([]byte{escpos.ESC, '*', 33, 64, 0}, bytes.Repeat([]byte{0xFC, 0, 0, 0, 0, 0}, 32)...)
+	want := [][]byte{raster, band, lastBand}
+
+	// prints sends the images and returns the raster and band commands
+	prints := func() ([][]byte, error) {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.PrintImageRaster(stripes, escpos.RasterNormal)
+		if err != nil {
+			return nil, err
+		}
+
+		err = printer.PrintImage24(stripes, escpos.DoubleDensity)
+		if err != nil {
+			return nil, err
+		}
+
+		var data [][]byte
+		for _, cmd := range sink.Decoded() {
+			if bytes.HasPrefix(cmd.Data, []byte{escpos.GS, 'v'}) || bytes.HasPrefix(cmd.Data, []byte{escpos.ESC, '*'}) {
+				data = append(data, cmd.Data)
+			}
+		}
+		return data, nil
+	}
+
+	// The pooled buffers are shared between printers, so printing from a lot
+	// of goroutines at once must still send the same bytes
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				got, err := prints()
+				if err != nil {
+					t.Errorf("could not print images: %v", err)
+					return
+				}
+				if len(got) != len(want) {
+					t.Errorf("sent %d image commands instead of %d", len(got), len(want))
+					return
+				}
+				for k := range want {
+					if !bytes.Equal(got[k], want[k]) {
+						t.Errorf("image command %d was % x instead of % x", k, got[k], want[k])
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkPrintImageRaster prints a tall raster image, which used to
+// allocate every row
+func BenchmarkPrintImageRaster(b *testing.B) {
+	img := image.NewGray(image.Rect(0, 0, 512, 480))
+	printer := escpos.NewPrinter(discardConn{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := printer.PrintImageRaster(img, escpos.RasterNormal)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPrintGraphics(b *testing.B) {
+	img := image.NewGray(image.Rect(0, 0, 512, 480))
+	printer := escpos.NewPrinter(discardConn{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := printer.PrintGraphics(img, escpos.GraphicsOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,23 +1,35 @@
 package escpos
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"io"
+	"math"
 	"net"
+	"os"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
 )
 
 const (
 	// Default ip and port for hoin printers
 	DefaultHoinIP = "192.168.1.23:9100"
+	// Number of dots in a line at 180dpi for 80mm hoin printers
+	DefaultDotWidth = 576
 
 	HT  = 0x09
 	LF  = 0x0A
+	FF  = 0x0C
 	CR  = 0x0D
+	CAN = 0x18
 	GS  = 0x1D
 	ESC = 0x1B
+	FS  = 0x1C
 	DLE = 0x10
 )
 
@@ -26,6 +38,9 @@ type Font int
 const (
 	FontA Font = iota
 	FontB
+	// FontC is only on some printers, so SetFont checks that the profile
+	// has columns for it
+	FontC
 )
 
 type Justification int
@@ -55,6 +70,46 @@ const (
 	DoubleDensity
 )
 
+// UnderlineMode selects the thickness of the underline
+type UnderlineMode int
+
+const (
+	UnderlineOff UnderlineMode = iota
+	UnderlineThin
+	UnderlineThick
+)
+
+// CutMode selects how much of the paper is cut
+type CutMode int
+
+const (
+	// CutFull cuts all the way through the paper
+	CutFull CutMode = iota
+	// CutPartial leaves a small tab of paper uncut
+	CutPartial
+)
+
+// DrawerPin selects the connector pin used to kick out the cash drawer
+type DrawerPin int
+
+const (
+	DrawerPin2 DrawerPin = iota
+	DrawerPin5
+)
+
+// RasterMode scales the dots of a raster image
+type RasterMode int
+
+const (
+	RasterNormal RasterMode = iota
+	RasterDoubleWidth
+	RasterDoubleHeight
+	RasterQuadruple
+)
+
+// Max rows of a raster image sent in a single GS v 0 command
+const rasterMaxHeight = 2303
+
 type BarCode int
 
 const (
@@ -66,7 +121,31 @@ const (
 	BcITF
 	BcCODABAR
 	BcCODE93  BarCode = 72
-	BcCODE123 BarCode = 73
+	BcCODE128 BarCode = 73
+
+	// BcEAN13 is the international name for BcJAN13
+	BcEAN13 = BcJAN13
+	// BcEAN8 is the international name for BcJAN8
+	BcEAN8 = BcJAN8
+
+	// Deprecated: BcCODE123 was a typo, use BcCODE128
+	BcCODE123 = BcCODE128
+)
+
+// Symbology is the kind of bar code printed by Barcode.  It is the same type
+// as BarCode, so the Bc constants can be used too.
+type Symbology = BarCode
+
+const (
+	UPCA    Symbology = BcUPCA
+	UPCE    Symbology = BcUPCE
+	EAN13   Symbology = BcEAN13
+	EAN8    Symbology = BcEAN8
+	CODE39  Symbology = BcCODE39
+	ITF     Symbology = BcITF
+	CODABAR Symbology = BcCODABAR
+	CODE93  Symbology = BcCODE93
+	CODE128 Symbology = BcCODE128
 )
 
 type PrintModeMask int
@@ -86,12 +165,19 @@ const (
 	Underline PrintModeMask = 1 << 7
 )
 
+// PrintMode is a whole ESC ! print mode byte for SetPrintMode, made of the
+// PrintModeMask flags combined with |
+type PrintMode = PrintModeMask
+
+// Emphasized is the ESC/POS name of the Bold print mode flag
+const Emphasized = Bold
+
 var (
-	lengthBarcodes = []BarCode{BcCODE93, BcCODE123}
+	lengthBarcodes = []BarCode{BcCODE93, BcCODE128}
 	allBarcodes    = append(lengthBarcodes, BcUPCA, BcUPCE, BcJAN13, BcJAN8, BcCODE39, BcITF, BcCODABAR)
 )
 
-func inSlice[T ~int](v T, s ...T) bool {
+func inSlice[T comparable](v T, s ...T) bool {
 	for _, a := range s {
 		if v == a {
 			return true
@@ -121,279 +207,1810 @@ func boolToByte(b bool) byte {
 	return 0
 }
 
+// Printer can be used anywhere an io.ReadWriteCloser is needed
+var _ io.ReadWriteCloser = Printer{}
+
+// Printer is safe for concurrent use.  Each method is sent to the printer as
+// a whole, so the commands of one goroutine are never mixed into the middle
+// of another goroutine's commands.  Copies of a printer share the same lock.
+//
+// Methods that send several commands, like PrintImage24 or QRCode, hold the
+// lock until they are done so other goroutines wait for them to finish.
+// Status methods hold the lock between sending the request and reading the
+// response so they always get their own response.
+//
+// The zero Printer, like the one returned with an error by NewIpPrinter, has
+// nowhere to keep settings, so the setters on it do nothing.
 type Printer struct {
 	dst io.ReadWriteCloser
+	// config is shared between copies of the printer
+	config *config
+	// locked is set on the copy returned by lock so methods called while
+	// holding the lock don't lock again
+	locked bool
+}
+
+// config holds the settings of a printer that aren't sent to it
+type config struct {
+	encoder      *charmap.Charmap
+	substitute   byte
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	// ctx stops reads and writes when it is done, from NewPrinterWithContext
+	ctx context.Context
+	// filters wrap the writes to the connection, from Use
+	filters []WriteFilter
+	// pacing is the bytes per second from SetWritePacing
+	pacing int
+
+	profile Profile
+	// font is the last font that was selected
+	font Font
+	// charWidth and charHeight are the last character size multipliers
+	// minus 1
+	charWidth, charHeight int
+	// reverse is if reverse printing was last turned on
+	reverse bool
+	// bold and underline are the last bold and underline modes
+	bold      bool
+	underline UnderlineMode
+	// codePage is the last code page that was selected
+	codePage CodePage
+	// rotate90 and upsideDown are if 90 degree rotation and upside-down
+	// printing were last turned on
+	rotate90, upsideDown bool
+	// justify is the last justification that was set
+	justify Justification
+	// motionX and motionY are the motion units from SetMotionUnits
+	motionX, motionY int
+	// overflow is what Printfw does with long lines
+	overflow Overflow
+	// imageChunk is the number of 24 dot bands from SetImageChunkBands
+	imageChunk int
+	// closePolicy is sent by Close
+	closePolicy ClosePolicy
+	// lineSpacing is the spacing from SetLineSpacing, which is only used
+	// when lineSpacingSet is true
+	lineSpacing    int
+	lineSpacingSet bool
+
+	buffered  bool
+	lineFlush bool
+	buf       []byte
+	// confirmJobs is if Flush waits for the printer to confirm the buffer
+	confirmJobs bool
+	// sanitize is if control bytes are dropped from printed text
+	sanitize bool
+	// text is kept between prints to hold the encoded bytes of the text
+	text []byte
+	// newline is the line ending from SetNewline
+	newline NewlineMode
+	// tabWidth is the columns between tab stops from SetExpandTabs, where 0
+	// sends tabs as they are, and column is where Print is on the line
+	tabWidth int
+	column   int
+
+	// pendingRead is a read that timed out and is still waiting on the
+	// printer
+	pendingRead chan readResult
+
+	// autoRecover is the number of retries from SetAutoRecover, and
+	// recovering is set while the error status is being checked
+	autoRecover int
+	recovering  bool
+
+	// debug gets a hex dump of everything written from SetDebugWriter, and
+	// debugOffset is the number of bytes written so far
+	debug       io.Writer
+	debugOffset int
+
+	// mu is held while a method is sending commands, and unlock is
+	// mu.Unlock kept so lock doesn't allocate it every time
+	mu     sync.Mutex
+	unlock func()
+}
+
+// lock locks the printer and returns a copy that can call other methods
+// without locking again.  Calling lock on a copy that is already locked does
+// nothing.
+func (p Printer) lock() (Printer, func()) {
+	if p.locked || p.config == nil {
+		return p, func() {}
+	}
+
+	p.config.mu.Lock()
+	p.locked = true
+	if p.config.unlock == nil {
+		p.config.unlock = p.config.mu.Unlock
+	}
+	return p, p.config.unlock
 }
 
 func NewPrinter(dst io.ReadWriteCloser) Printer {
 	return Printer{
-		dst: dst,
+		dst:    dst,
+		config: &config{profile: ProfileHoin},
 	}
 }
 
 func NewIpPrinter(addr string) (Printer, error) {
-	conn, err := net.Dial("tcp", addr)
+	return NewIpPrinterContext(context.Background(), addr)
+}
+
+// NewIpPrinterContext connects to the printer at addr over tcp.  The context
+// can be used to timeout or cancel the dial when the printer is not on.
+func NewIpPrinterContext(ctx context.Context, addr string) (Printer, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
-		return Printer{}, fmt.Errorf("unable to dial: %w", err)
+		return Printer{}, fmt.Errorf("unable to dial %s: %w", addr, err)
 	}
 	return NewPrinter(conn), nil
 }
 
+// ClosePolicy is what Close sends to finish the receipt before the
+// connection is closed.  The zero value sends nothing.
+type ClosePolicy struct {
+	// FeedLines is the number of lines to feed
+	FeedLines int
+	// Cut cuts the paper with CutMode after feeding, like FeedAndCut
+	Cut     bool
+	CutMode CutMode
+}
+
+// NewPrinterWithClosePolicy returns a printer that feeds and cuts the paper
+// with the policy when it is closed, so every receipt ends the same way.
+func NewPrinterWithClosePolicy(dst io.ReadWriteCloser, policy ClosePolicy) Printer {
+	printer := NewPrinter(dst)
+	printer.config.closePolicy = policy
+	return printer
+}
+
+// finish sends the feed and cut of the close policy
+func (p Printer) finish() error {
+	if p.config == nil {
+		return nil
+	}
+
+	policy := p.config.closePolicy
+	if policy.Cut {
+		return p.FeedAndCut(policy.FeedLines, policy.CutMode)
+	}
+	if policy.FeedLines > 0 {
+		return p.FeedLines(policy.FeedLines)
+	}
+	return nil
+}
+
+// Close flushes any buffered data and closes the printer.  The printer might
+// not have received everything yet, so call Sync first to wait for it.
+//
+// The feed and cut of the ClosePolicy from NewPrinterWithClosePolicy are sent
+// before the buffer is flushed, so they come after everything else.
 func (p Printer) Close() error {
 	closer, ok := p.dst.(io.Closer)
 	if p.dst == nil || !ok {
 		return nil
 	}
 
+	p, unlock := p.lock()
+	defer unlock()
+
+	finishErr := p.finish()
+	flushErr := p.Flush()
+
 	err := closer.Close()
 	if err != nil {
 		return fmt.Errorf("could not close printer: %w", err)
 	}
+
+	for _, err := range []error{finishErr, flushErr} {
+		if err != nil {
+			return fmt.Errorf("could not close printer: %w", err)
+		}
+	}
 	return nil
 }
 
-func (p Printer) Write(b []byte) (int, error) {
-	n, err := p.dst.Write(b)
-	if err != nil {
-		return n, fmt.Errorf("could not write to printer: %w", err)
+// SetBuffered turns buffering on or off.  While buffering is on, writes are
+// kept until Flush is called so a whole receipt can be sent at once.  Reading
+// from the printer also flushes so the commands are sent before waiting on a
+// response.
+//
+// Turning buffering off flushes any buffered data.  See SetLineFlush to send
+// each finished line while buffering.
+func (p Printer) SetBuffered(b bool) error {
+	if p.config == nil {
+		return nil
 	}
-	return n, nil
-}
 
-func (p Printer) Read(b []byte) (int, error) {
-	n, err := p.dst.Read(b)
-	if err != nil {
-		return n, fmt.Errorf("could not read from printer: %w", err)
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.buffered = b
+	if !b {
+		return p.Flush()
 	}
-	return n, nil
+	return nil
 }
 
-func (p Printer) Initialize() error {
-	_, err := p.Write([]byte{ESC, '@'})
-	if err != nil {
-		return fmt.Errorf("could not initialize printer: %w", err)
+// SetLineFlush turns line flushing on or off.  While it is on and buffering
+// is on from SetBuffered, Print, Println, and Printf flush the buffer when the
+// text has a line feed, so each finished line is sent right away while
+// partial lines stay buffered.  This is useful for printing logs as they
+// come in.
+//
+// Line flushing does nothing while buffering is off since every write is
+// already sent.  Commands other than the Print methods are still buffered
+// until the next line or Flush.
+func (p Printer) SetLineFlush(b bool) {
+	if p.config == nil {
+		return
 	}
-	return nil
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.lineFlush = b
 }
 
-// Beep makes a beep sound n times for t duration
+// Batch runs f with buffering on and sends everything f wrote in one write at
+// the end, which is faster when printing a lot of small labels.  Buffering
+// and line flushing are put back to how they were afterwards, and when
+// buffering was already on the commands are left in the buffer for the next
+// Flush.  Other goroutines wait until the batch is done.
 //
-// Duration is dependent on the model. For the HOP-E802
-// each duration is around 100ms
-func (p Printer) Beep(n, t int) error {
-	errMsg := "could not beep the printer: %w"
+// When f returns an error the commands it wrote are thrown away instead of
+// sent, so a label that failed halfway isn't printed.  Reading a status in f
+// flushes the buffer like it does while buffering, and what was sent then
+// can't be thrown away.
+//
+// f is passed a copy of the printer that already holds the lock, and it must
+// only use that copy.  Calling the printer Batch was called on from f waits
+// for the batch to finish, which never happens, so it deadlocks.  Batch can
+// be called again on the copy to nest batches.
+func (p Printer) Batch(f func(Printer) error) error {
+	errMsg := "could not print batch: %w"
+
+	// Without a config there is no buffer, so f writes straight through
+	if p.config == nil {
+		err := f(p)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+		return nil
+	}
 
-	err := checkRange(n, 1, 9, "n")
+	p, unlock := p.lock()
+	defer unlock()
+
+	buffered, lineFlush := p.config.buffered, p.config.lineFlush
+	start := len(p.config.buf)
+	defer func() {
+		p.config.buffered, p.config.lineFlush = buffered, lineFlush
+	}()
+
+	p.config.buffered, p.config.lineFlush = true, false
+
+	err := f(p)
 	if err != nil {
+		if start <= len(p.config.buf) {
+			p.config.buf = p.config.buf[:start]
+		} else {
+			p.config.buf = nil
+		}
 		return fmt.Errorf(errMsg, err)
 	}
 
-	err = checkRange(t, 1, 9, "t")
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+	if buffered {
+		return nil
 	}
 
-	_, err = p.Write([]byte{ESC, 'B', byte(n), byte(t)})
+	err = p.Flush()
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
-
 	return nil
 }
 
-func (p Printer) Print(a ...any) error {
-	_, err := p.Write([]byte(fmt.Sprint(a...)))
-	if err != nil {
-		return fmt.Errorf("could not print %q: %w", a, err)
+// SetSanitizeText turns text sanitizing on or off.  While it is on, Print,
+// Println, and Printf drop control characters from 0x00 to 0x1F, other than
+// LF, CR, and HT, before the text is sent.
+//
+// Text that comes from users, like names or notes on an order, can have
+// control characters in it.  Since ESC, GS, and the others start printer
+// commands, that text could change the printer settings, open the cash
+// drawer, or cut the paper in the middle of a receipt.  Sanitizing makes sure
+// printed text can only ever be printed.  Commands can still be sent with
+// Write and the other methods.
+func (p Printer) SetSanitizeText(b bool) {
+	if p.config == nil {
+		return
 	}
-	return nil
-}
 
-func (p Printer) Println(a ...any) error {
-	return p.Print(fmt.Sprint(a...) + string(rune(LF)))
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.sanitize = b
 }
 
-func (p Printer) Printf(format string, a ...any) error {
-	return p.Print(fmt.Sprintf(format, a...))
+// sanitizeText drops the control characters other than LF, CR, and HT
+func sanitizeText(text string) string {
+	// Go over the bytes so text that isn't valid UTF-8 is kept as it is
+	clean := make([]byte, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if b < 0x20 && b != LF && b != CR && b != HT {
+			continue
+		}
+		clean = append(clean, b)
+	}
+	return string(clean)
 }
 
-// HT moves the print position to the next horizontal tab position
+// SetExpandTabs makes Print, Println, and Printf change tabs in the text to
+// spaces up to the next tab stop, with a stop every width columns.  The
+// output then doesn't depend on the tab positions set on the printer.  A
+// width of 0 sends tabs to the printer as they are, which is the default.
 //
-// By default HT will do nothing if SetHT is not called with tab positions
-func (p Printer) HT() error {
-	_, err := p.Write([]byte{HT})
+// The column is kept across Print calls, so a line printed in pieces still
+// lines up.  It goes back to 0 after a line ending in the text and after LF,
+// CR, and Initialize, but commands that feed the paper and Write don't move
+// it.
+func (p Printer) SetExpandTabs(width int) error {
+	err := checkRange(width, 0, 255, "tab width")
 	if err != nil {
-		return fmt.Errorf("could not send HT: %w", err)
+		return fmt.Errorf("could not set expand tabs: %w", err)
 	}
-	return nil
-}
 
-// LF prints the data in the print buffer and feeds one line
-func (p Printer) LF() error {
-	_, err := p.Write([]byte{LF})
-	if err != nil {
-		return fmt.Errorf("could not send LF: %w", err)
+	if p.config == nil {
+		return nil
 	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.tabWidth = width
+	p.config.column = 0
 	return nil
 }
 
-// CR prints and does a carriage return
-func (p Printer) CR() error {
-	_, err := p.Write([]byte{CR})
-	if err != nil {
-		return fmt.Errorf("could not send CR: %w", err)
+// expandTabs changes the tabs in text to spaces up to the next stop every
+// width columns, starting at column.  It returns the column at the end of the
+// text.
+func expandTabs(text string, column, width int) (string, int) {
+	var b strings.Builder
+	for _, r := range text {
+		switch r {
+		case '\t':
+			spaces := width - column%width
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		case '\n', '\r':
+			column = 0
+		default:
+			column++
+		}
+		b.WriteRune(r)
 	}
-	return nil
+	return b.String(), column
 }
 
-// Cut cuts the paper
-func (p Printer) Cut() error {
-	_, err := p.Write([]byte{GS, 'V', 0})
-	if err != nil {
-		return fmt.Errorf("could not cut paper: %w", err)
+// Flush sends any buffered data to the printer
+func (p Printer) Flush() error {
+	if p.config == nil {
+		return nil
 	}
-	return nil
-}
 
-// CutFeed feeds the paper n units and then cuts it
-func (p Printer) CutFeed(n int) error {
-	errMsg := "could not feed and cut the paper: %w"
+	p, unlock := p.lock()
+	defer unlock()
 
-	err := checkRange(n, 0, 255, "n")
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+	if len(p.config.buf) == 0 {
+		return nil
 	}
 
-	_, err = p.Write([]byte{GS, 'V', 66, byte(n)})
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+	buf := p.config.buf
+	p.config.buf = nil
+
+	if p.config.confirmJobs {
+		err := p.flushConfirmed(buf)
+		if err != nil {
+			return fmt.Errorf("could not flush printer: %w", err)
+		}
+		return nil
 	}
-	return nil
-}
 
-// ResetLineSpacing sets the spacing to the default which
-// is 1/6-inch lines (approx. 4.23mm)
-func (p Printer) ResetLineSpacing() error {
-	_, err := p.Write([]byte{ESC, '2'})
+	_, err := p.writeRecover(buf)
 	if err != nil {
-		return fmt.Errorf("could not reset line spacing: %w", err)
+		return fmt.Errorf("could not flush printer: %w", err)
 	}
 	return nil
 }
 
-// SetLineSpacing sets the line spacing to n * v/h motion units in inches
-func (p Printer) SetLineSpacing(n int) error {
-	errMsg := "could not set line spacing: %w"
-
-	err := checkRange(n, 0, 255, "n")
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+// ErrNotConfirmed is returned by Flush when SetConfirmJobs is on and the
+// printer didn't answer the status requests around the buffer
+var ErrNotConfirmed = errors.New("printer did not confirm the job")
+
+// SetConfirmJobs turns job confirmation on or off.  While it is on, Flush
+// sends a real-time status request (DLE EOT 1) before the buffer and a
+// transmit status (GS r 1) after it, and waits for both answers.  The
+// real-time request is answered right away and checks that the printer is
+// there before the job is sent.  GS r is only answered once the printer has
+// processed everything before it, so its answer confirms the whole buffer
+// made it to the printer.  Batch and the other methods that flush get the
+// same confirmation.
+//
+// Flush returns an error wrapping ErrNotConfirmed when an answer doesn't come
+// or isn't a status.  Set a read timeout with SetReadTimeout, otherwise Flush
+// waits forever on a printer that dropped the job.  This only does something
+// while buffering is on, and the printer must be able to send data back.
+func (p Printer) SetConfirmJobs(b bool) {
+	if p.config == nil {
+		return
 	}
 
-	_, err = p.Write([]byte{ESC, '3', byte(n)})
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
-	}
+	p, unlock := p.lock()
+	defer unlock()
 
-	return err
+	p.config.confirmJobs = b
 }
 
-// Feed feeds the paper n units
-func (p Printer) Feed(n int) error {
-	errMsg := "could not feed paper: %w"
+// flushConfirmed writes buf between the status requests of SetConfirmJobs and
+// checks the answers
+func (p Printer) flushConfirmed(buf []byte) error {
+	// status sends the request and reads the answer, which must have the
+	// mask bits set to want
+	status := func(data []byte, mask, want byte, when string) error {
+		_, err := p.writeRecover(data)
+		if err != nil {
+			return err
+		}
 
-	err := checkRange(n, 0, 255, "n")
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+		b := make([]byte, 1)
+		n, err := p.readBefore(b, p.readDeadline())
+		if err == nil && n == 0 {
+			err = io.ErrNoProgress
+		}
+		if err != nil {
+			return fmt.Errorf("%w: no status %s: %v", ErrNotConfirmed, when, err)
+		}
+		if b[0]&mask != want {
+			return fmt.Errorf("%w: %#x is not a status %s", ErrNotConfirmed, b[0], when)
+		}
+		return nil
 	}
 
-	_, err = p.Write([]byte{ESC, 'J', byte(n)})
+	// Real-time statuses always have bits 1 and 4 set and bits 0 and 7
+	// cleared
+	err := status([]byte{DLE, 0x04, 1}, 0x93, 0x12, "before the job")
 	if err != nil {
-		return fmt.Errorf(errMsg, err)
+		return err
 	}
 
-	return err
+	// GS r statuses always have bits 4 and 7 cleared
+	return status(append(buf, GS, 'r', 1), 0x90, 0x00, "after the job")
 }
 
-// FeedLines feeds the paper n lines
-func (p Printer) FeedLines(n int) error {
-	errMsg := "could not feed lines: %w"
+// ErrTimeout is returned when the printer does not respond within the time
+// set by SetReadTimeout or SetWriteTimeout
+var ErrTimeout = errors.New("printer timed out")
 
-	err := checkRange(n, 0, 255, "n")
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+// timeoutError wraps deadline errors with ErrTimeout
+func timeoutError(err error) error {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
 	}
+	return err
+}
 
-	_, err = p.Write([]byte{ESC, 'd', byte(n)})
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+// writeDeadliner is implemented by connections like net.Conn and os.File
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// SetWriteTimeout sets how long a write can block before it fails with
+// ErrTimeout, like when the printer buffer is full from a paper jam.  This
+// only works when the printer connection has a SetWriteDeadline method like
+// net.Conn, otherwise the timeout does nothing.  A timeout of 0 waits
+// forever, which is the default.
+func (p Printer) SetWriteTimeout(d time.Duration) {
+	if p.config == nil {
+		return
 	}
 
-	return err
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.writeTimeout = d
 }
 
-// SetHT sets the horizontal tab positions
+// SetWritePacing limits writes to the printer to bytesPerSecond bytes a
+// second by sending the data in small pieces with a sleep between them.  A
+// pacing of 0 or less sends everything as fast as the connection takes it,
+// which is the default.
 //
-// This command cancels previous SetHT commands
-// Multiple positions can be set for tabbing
-// A max of 32 positions can be set
-// Calling SetHT with no argments resets the tab positions
-func (p Printer) SetHT(positions ...int) error {
-	errMsg := "could not set horizontal tab positions: %w"
+// This is a workaround for cheap printers on connections without flow
+// control, where the printer drops data that comes in faster than it can
+// print.  It usually shows up as the first few lines printing fine and then
+// garbage.  The timeout from SetWriteTimeout covers the whole paced write, so
+// it has to be long enough for the biggest write, like an image.
+func (p Printer) SetWritePacing(bytesPerSecond int) {
+	if p.config == nil {
+		return
+	}
 
-	if len(positions) > 32 {
-		return fmt.Errorf("more than 32 positions was set")
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.pacing = bytesPerSecond
+}
+
+// pacedWriter writes to next at no more than rate bytes a second
+type pacedWriter struct {
+	next WriteFunc
+	rate int
+}
+
+func (w pacedWriter) Write(b []byte) (int, error) {
+	// Send about 20 pieces a second
+	size := w.rate / 20
+	if size < 1 {
+		size = 1
 	}
 
-	var data []byte
-	for i, pos := range positions {
-		err := checkRange(pos, 1, 255, fmt.Sprintf("position %d", i))
+	start := time.Now()
+	written := 0
+	for written < len(b) {
+		end := written + size
+		if end > len(b) {
+			end = len(b)
+		}
+
+		n, err := w.next(b[written:end])
+		written += n
 		if err != nil {
-			return fmt.Errorf(errMsg, err)
+			return written, err
 		}
 
-		data = append(data, byte(pos))
+		// Sleep until the time the bytes so far should have taken
+		time.Sleep(time.Until(start.Add(time.Duration(written) * time.Second / time.Duration(w.rate))))
 	}
+	return written, nil
+}
 
-	data = append([]byte{ESC, 'D'}, data...)
-	data = append(data, 0)
-
-	_, err := p.Write(data)
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
+// writeAll writes all of b to w.  io.Writer allows returning fewer bytes
+// without an error, which network connections do when they are busy, so the
+// rest is written again until it is all written or there is an error.  A
+// write that makes no progress returns io.ErrShortWrite instead of looping
+// forever.
+func writeAll(w io.Writer, b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		n, err := w.Write(b[written:])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if n == 0 {
+			return written, io.ErrShortWrite
+		}
 	}
-
-	return nil
+	return written, nil
 }
 
-// SetTabs will set up to 32 tab positions at the given width intervals.  If
-// the tab value exceeds 256, fewer than 32 positions will be set.
-func (p Printer) SetTabs(width int) error {
-	tabs := []int{}
-	for i := width; i < 256 && len(tabs) < 32; i += width {
-		tabs = append(tabs, i)
+// write sends all the bytes to the printer connection through the write
+// filters with the write timeout
+func (p Printer) write(b []byte) (int, error) {
+	if p.config != nil && p.config.writeTimeout > 0 {
+		if conn, ok := p.dst.(writeDeadliner); ok {
+			err := conn.SetWriteDeadline(time.Now().Add(p.config.writeTimeout))
+			if err != nil && !errors.Is(err, os.ErrNoDeadline) {
+				return 0, err
+			}
+			defer conn.SetWriteDeadline(time.Time{})
+		}
 	}
-	return p.SetHT(tabs...)
-}
 
-// SetBold turns emphasized mode on or off
-func (p Printer) SetBold(b bool) error {
-	_, err := p.Write([]byte{ESC, 'E', boolToByte(b)})
-	if err != nil {
-		return fmt.Errorf("could not set bold to %t: %w", b, err)
+	var n int
+	var err error
+	if p.config == nil || (len(p.config.filters) == 0 && p.config.debug == nil && p.config.pacing <= 0) {
+		n, err = p.send(b)
+	} else {
+		n, err = p.writeChain()(b)
 	}
-	return nil
+	return n, timeoutError(err)
 }
 
-// SetRotate90 turns on 90 clockwise rotation mode for the text
+// Write sends the raw bytes to the printer.  This lets the printer be used as
+// an io.Writer with things like fmt.Fprintf and io.Copy.
 //
-// When text is double-width or double-height the text will be mirrored
-func (p Printer) SetRotate90(b bool) error {
-	_, err := p.Write([]byte{ESC, 'V', boolToByte(b)})
+// Like io.Writer, an error is returned when fewer than len(b) bytes were
+// written.  While buffering is on the bytes are kept in the buffer as they
+// are, so n is always len(b) and write errors come from Flush instead.
+func (p Printer) Write(b []byte) (int, error) {
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := p.contextErr()
 	if err != nil {
-		return fmt.Errorf("could not set bold to %t: %w", b, err)
+		return 0, fmt.Errorf("could not write to printer: %w", err)
 	}
-	return nil
+
+	if p.config != nil && p.config.buffered {
+		p.config.buf = append(p.config.buf, b...)
+		return len(b), nil
+	}
+
+	n, err := p.writeRecover(b)
+	if err != nil {
+		return n, fmt.Errorf("could not write to printer: %w", err)
+	}
+	return n, nil
+}
+
+// WriteRawN sends b to the printer exactly as it is and returns the number of
+// bytes written like io.Writer.  Nothing is encoded or sanitized like the
+// Print methods do.  While buffering is on, b is added to the buffer as it is
+// and sent with the next Flush, so n is len(b) and write errors come from
+// Flush.  It is the same as Write.
+func (p Printer) WriteRawN(b []byte) (int, error) {
+	return p.Write(b)
+}
+
+// WriteRaw sends b to the printer exactly as it is like WriteRawN, for when
+// the count isn't needed.  An error is returned when fewer than len(b) bytes
+// were written.
+func (p Printer) WriteRaw(b []byte) error {
+	_, err := p.WriteRawN(b)
+	return err
+}
+
+func (p Printer) Read(b []byte) (int, error) {
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := p.Flush()
+	if err != nil {
+		return 0, fmt.Errorf("could not read from printer: %w", err)
+	}
+
+	n, err := p.receive(b)
+	if err != nil {
+		err = timeoutError(err)
+		return n, fmt.Errorf("could not read from printer: %w", err)
+	}
+	return n, nil
+}
+
+// receive reads from the printer connection and stops if the context of the
+// printer is done
+func (p Printer) receive(b []byte) (int, error) {
+	if p.config == nil || p.config.ctx == nil {
+		return p.dst.Read(b)
+	}
+
+	var setDeadline func(time.Time) error
+	if conn, ok := p.dst.(readDeadliner); ok {
+		setDeadline = conn.SetReadDeadline
+	}
+
+	return p.withContext(b, setDeadline, p.dst.Read)
+}
+
+// BuildCommand puts together the bytes of a command that starts with the
+// prefix and cmd bytes, like BuildCommand(ESC, '!', mode) for ESC ! n.
+func BuildCommand(prefix, cmd byte, args ...byte) []byte {
+	return append([]byte{prefix, cmd}, args...)
+}
+
+// SendCommand writes a command that the library doesn't have a method for.
+// The prefix must be ESC, GS, FS, or DLE, and the arguments are sent as they
+// are so they must match what the command expects.  Fonts and sizes set this
+// way aren't tracked, so Columns won't know about them.
+func (p Printer) SendCommand(prefix, cmd byte, args ...byte) error {
+	errMsg := "could not send command: %w"
+
+	if !inSlice(prefix, ESC, GS, FS, DLE) {
+		return fmt.Errorf(errMsg, fmt.Errorf("%#02x is not ESC, GS, FS, or DLE", prefix))
+	}
+
+	_, err := p.Write(BuildCommand(prefix, cmd, args...))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+func (p Printer) Initialize() error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err := p.Write([]byte{ESC, '@'})
+	if err != nil {
+		return fmt.Errorf("could not initialize printer: %w", err)
+	}
+
+	if p.config != nil {
+		p.config.font = FontA
+		p.config.charWidth, p.config.charHeight = 0, 0
+		p.config.reverse = false
+		p.config.bold, p.config.underline = false, UnderlineOff
+		p.config.codePage = CP437
+		p.config.rotate90, p.config.upsideDown = false, false
+		p.config.justify = LeftJustify
+		p.config.motionX, p.config.motionY = 0, 0
+		p.config.column = 0
+		p.config.lineSpacingSet = false
+	}
+	return nil
+}
+
+// ResetFormatting sets the text formatting back to the defaults without
+// initializing the printer like Initialize does.  Tab positions, code pages,
+// and bar code settings are left alone.
+//
+// The following are reset:
+//   - print mode (ESC !) is cleared
+//   - bold and double-strike are off
+//   - underline is off
+//   - 90 degree rotation is off
+//   - reverse printing is off
+//   - upside-down printing is off
+//   - justification is left
+//   - font is font A
+//   - character size is 1x1
+//   - line spacing is the default
+func (p Printer) ResetFormatting() error {
+	errMsg := "could not reset formatting: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	resets := []func() error{
+		func() error { return p.SelectPrintMode() },
+		func() error { return p.SetBold(false) },
+		func() error { return p.SetDoubleStrike(false) },
+		func() error { return p.SetUnderline(UnderlineOff) },
+		func() error { return p.SetRotate90(false) },
+		func() error { return p.SetReversePrinting(false) },
+		func() error { return p.SetUpsideDown(false) },
+		func() error { return p.Justify(LeftJustify) },
+		func() error { return p.SetFont(FontA) },
+		func() error { return p.SetCharacterSize(0, 0) },
+		p.ResetLineSpacing,
+	}
+
+	for _, reset := range resets {
+		err := reset()
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// SelfTestMode selects what is printed by SelfTest
+type SelfTestMode int
+
+const (
+	// SelfTestHexDump puts the printer in hexadecimal dump mode where the
+	// data sent to the printer is printed as hex instead of being run
+	SelfTestHexDump SelfTestMode = iota + 1
+	// SelfTestStatus prints the printer settings and hardware info
+	SelfTestStatus
+	// SelfTestRollingPattern prints the rolling pattern to check the print
+	// head
+	SelfTestRollingPattern
+)
+
+// SelfTest runs the built-in test print on the roll paper with GS ( A, which
+// is the same as holding the feed button while turning the printer on.
+// Printers that don't have the command ignore it.
+//
+// Hexadecimal dump mode stays on until the printer is turned off or the feed
+// button is pressed 3 times.
+func (p Printer) SelfTest(mode SelfTestMode) error {
+	errMsg := "could not run self test: %w"
+
+	err := checkEnum(mode, SelfTestHexDump, SelfTestStatus, SelfTestRollingPattern)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// n=50 is roll paper
+	_, err = p.Write([]byte{GS, '(', 'A', 2, 0, '2', '0' + byte(mode)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// Beep makes a beep sound n times for t duration.  Printers without
+// SupportsBuzzer in their profile return ErrUnsupported.
+//
+// Duration is dependent on the model. For the HOP-E802
+// each duration is around 100ms
+func (p Printer) Beep(n, t int) error {
+	errMsg := "could not beep the printer: %w"
+
+	err := p.supports("Beep", p.Profile().SupportsBuzzer)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(n, 1, 9, "n")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(t, 1, 9, "t")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'B', byte(n), byte(t)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// OpenCashDrawer sends a pulse to the drawer pin to open the cash drawer.
+// The pulse is on for onTime and then off for offTime.
+//
+// The times are sent in 2ms units so each time must be at most 510ms.
+func (p Printer) OpenCashDrawer(pin DrawerPin, onTime, offTime time.Duration) error {
+	errMsg := "could not open cash drawer: %w"
+
+	err := checkEnum(pin, DrawerPin2, DrawerPin5)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	t1 := int(onTime / (2 * time.Millisecond))
+	err = checkRange(t1, 0, 255, "on time in 2ms units")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	t2 := int(offTime / (2 * time.Millisecond))
+	err = checkRange(t2, 0, 255, "off time in 2ms units")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'p', byte(pin), byte(t1), byte(t2)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// Peripheral selects the device that gets the data sent after SelectPeripheral
+type Peripheral int
+
+const (
+	// PeripheralPrinter sets bit 0 so the printer gets the data
+	PeripheralPrinter Peripheral = 1
+	// PeripheralDisplay sets bit 1 so the customer display gets the data
+	PeripheralDisplay Peripheral = 2
+)
+
+// SelectPeripheral selects the device for the data that follows with ESC = n
+// when a customer display is connected through the printer.  While the
+// display is selected the printer ignores everything except ESC = and
+// real-time commands, so select PeripheralPrinter again before printing.
+func (p Printer) SelectPeripheral(device Peripheral) error {
+	errMsg := "could not select peripheral: %w"
+
+	err := checkEnum(device, PeripheralPrinter, PeripheralDisplay)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, '=', byte(device)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetPanelButtons enables or disables the buttons on the printer panel, like
+// the feed button.  Disabling them stops someone feeding paper in the middle
+// of a job and messing up the alignment.  Initialize turns them back on.
+//
+// Some models have the buttons disabled while the cover is open no matter what
+// this is set to.
+func (p Printer) SetPanelButtons(enabled bool) error {
+	_, err := p.Write([]byte{ESC, 'c', '5', boolToByte(!enabled)})
+	if err != nil {
+		return fmt.Errorf("could not set panel buttons to %t: %w", enabled, err)
+	}
+	return nil
+}
+
+// NewlineMode is the line ending added by Println and the wrapping functions
+type NewlineMode int
+
+const (
+	// NewlineLF ends lines with LF, which is the default
+	NewlineLF NewlineMode = iota
+	// NewlineCR ends lines with CR
+	NewlineCR
+	// NewlineCRLF ends lines with CR and then LF
+	NewlineCRLF
+)
+
+// SetNewline sets the line ending used by Println, PrintWrapped, PrintReader,
+// Printfw, and the other functions that print more than one line.  Some
+// printers and customer displays need CR before LF to go back to the start
+// of the line.  Text given to Print is sent as it is.
+func (p Printer) SetNewline(mode NewlineMode) error {
+	err := checkEnum(mode, NewlineLF, NewlineCR, NewlineCRLF)
+	if err != nil {
+		return fmt.Errorf("could not set newline: %w", err)
+	}
+
+	if p.config == nil {
+		return nil
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.newline = mode
+	return nil
+}
+
+// newline returns the line ending from SetNewline
+func (p Printer) newline() string {
+	if p.config == nil {
+		return "\n"
+	}
+
+	switch p.config.newline {
+	case NewlineCR:
+		return "\r"
+	case NewlineCRLF:
+		return "\r\n"
+	}
+	return "\n"
+}
+
+func (p Printer) Print(a ...any) error {
+	err := p.printText(fmt.Sprint(a...))
+	if err != nil {
+		return fmt.Errorf("could not print %q: %w", a, err)
+	}
+	return nil
+}
+
+// WriteString prints the string exactly like Print, but without the
+// allocations of formatting the arguments and converting the string to
+// bytes, for printing a lot of short strings.  Unlike io.StringWriter the
+// text is sanitized, encoded, and has its tabs expanded like Print does.
+func (p Printer) WriteString(s string) error {
+	err := p.printText(s)
+	if err != nil {
+		return fmt.Errorf("could not print %q: %w", s, err)
+	}
+	return nil
+}
+
+// Largest text buffer that is kept between prints, so printing one big text
+// doesn't hold on to the memory
+const maxTextBuffer = 4096
+
+// printText sanitizes, expands the tabs of, and encodes the text and writes
+// it.  The bytes are put in a buffer that is used again for the next print.
+func (p Printer) printText(text string) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	if p.config == nil {
+		_, err := p.Write([]byte(text))
+		return err
+	}
+
+	if p.config.sanitize {
+		text = sanitizeText(text)
+	}
+	if p.config.tabWidth > 0 {
+		text, p.config.column = expandTabs(text, p.config.column, p.config.tabWidth)
+	}
+
+	data := p.appendEncoded(p.config.text[:0], text)
+	if cap(data) <= maxTextBuffer {
+		p.config.text = data[:0]
+	}
+
+	_, err := p.Write(data)
+	if err != nil {
+		return err
+	}
+
+	if p.config.lineFlush && strings.Contains(text, p.newline()) {
+		return p.Flush()
+	}
+	return nil
+}
+
+func (p Printer) Println(a ...any) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	return p.Print(fmt.Sprint(a...) + p.newline())
+}
+
+func (p Printer) Printf(format string, a ...any) error {
+	return p.Print(fmt.Sprintf(format, a...))
+}
+
+// HT moves the print position to the next horizontal tab position
+//
+// By default HT will do nothing if SetHT is not called with tab positions
+func (p Printer) HT() error {
+	_, err := p.Write([]byte{HT})
+	if err != nil {
+		return fmt.Errorf("could not send HT: %w", err)
+	}
+	return nil
+}
+
+// LF prints the data in the print buffer and feeds one line
+func (p Printer) LF() error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err := p.writeCommand(LF)
+	if err != nil {
+		return fmt.Errorf("could not send LF: %w", err)
+	}
+
+	if p.config != nil {
+		p.config.column = 0
+	}
+	return nil
+}
+
+// CR prints and does a carriage return
+func (p Printer) CR() error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err := p.Write([]byte{CR})
+	if err != nil {
+		return fmt.Errorf("could not send CR: %w", err)
+	}
+
+	if p.config != nil {
+		p.config.column = 0
+	}
+	return nil
+}
+
+// Cut cuts the paper
+func (p Printer) Cut() error {
+	err := p.supports("Cut", p.Profile().SupportsCut)
+	if err != nil {
+		return fmt.Errorf("could not cut paper: %w", err)
+	}
+
+	_, err = p.Write([]byte{GS, 'V', 0})
+	if err != nil {
+		return fmt.Errorf("could not cut paper: %w", err)
+	}
+	return nil
+}
+
+// presenter returns the presenter of the profile, or an *UnsupportedError
+// when the profile doesn't have one.  This fails even when the profile isn't
+// strict, since the commands come from the presenter and there is nothing to
+// send without it.
+func (p Printer) presenter(command string) (*Presenter, error) {
+	presenter := p.Profile().Presenter
+	if presenter == nil {
+		return nil, &UnsupportedError{Command: command}
+	}
+	return presenter, nil
+}
+
+// PresentTicket pushes the ticket ejectDots out of the slot of a kiosk
+// printer, so it can be taken after Cut.  The command comes from the
+// Presenter of the profile since it is different for every model, and
+// printers without one return ErrUnsupported.
+func (p Printer) PresentTicket(ejectDots int) error {
+	errMsg := "could not present ticket: %w"
+
+	presenter, err := p.presenter("PresentTicket")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(ejectDots, 0, 255, "eject dots")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	cmd := append(append([]byte{}, presenter.Present...), byte(ejectDots))
+	_, err = p.Write(cmd)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// RetractTicket pulls a presented ticket that wasn't taken back into a kiosk
+// printer.  Like PresentTicket the command comes from the Presenter of the
+// profile, and printers without one return ErrUnsupported.
+func (p Printer) RetractTicket() error {
+	errMsg := "could not retract ticket: %w"
+
+	presenter, err := p.presenter("RetractTicket")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write(presenter.Retract)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// PartialCut cuts the paper leaving a small tab uncut
+func (p Printer) PartialCut() error {
+	err := p.supports("PartialCut", p.Profile().SupportsCut)
+	if err != nil {
+		return fmt.Errorf("could not partial cut paper: %w", err)
+	}
+
+	_, err = p.Write([]byte{GS, 'V', 1})
+	if err != nil {
+		return fmt.Errorf("could not partial cut paper: %w", err)
+	}
+	return nil
+}
+
+// CutWith feeds the paper to the cutting position plus feed units and then
+// cuts it with the cut mode
+func (p Printer) CutWith(mode CutMode, feed int) error {
+	errMsg := "could not feed and cut the paper: %w"
+
+	err := p.supports("CutWith", p.Profile().SupportsCut)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(mode, CutFull, CutPartial)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(feed, 0, 255, "feed")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{GS, 'V', 65 + byte(mode), byte(feed)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// FeedAndCut feeds the paper lines lines and then cuts it with the cut mode,
+// which is how most receipts end.  When the profile has SupportsFeedCut the
+// paper is also fed up to the cutter with GS V 65 or 66 before cutting so the
+// last lines aren't cut through.  Otherwise the paper is cut where it is after
+// feeding like FeedLines and Cut.
+func (p Printer) FeedAndCut(lines int, mode CutMode) error {
+	errMsg := "could not feed and cut the paper: %w"
+
+	profile := p.Profile()
+	err := p.supports("FeedAndCut", profile.SupportsCut)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(mode, CutFull, CutPartial)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(lines, 0, 255, "lines")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	cmd := []byte{ESC, 'd', byte(lines), GS, 'V', byte(mode)}
+	if profile.SupportsFeedCut {
+		cmd = []byte{ESC, 'd', byte(lines), GS, 'V', 65 + byte(mode), 0}
+	}
+
+	_, err = p.Write(cmd)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// CutFeed feeds the paper n units and then partial cuts it
+//
+// This is the same as CutWith(CutPartial, n)
+func (p Printer) CutFeed(n int) error {
+	errMsg := "could not feed and cut the paper: %w"
+
+	err := p.supports("CutFeed", p.Profile().SupportsCut)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(n, 0, 255, "n")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{GS, 'V', 66, byte(n)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetCharacterSpacing sets the space on the right side of each character to
+// dots horizontal motion units, which is 1/180 inch on most printers.  The
+// space is doubled along with the characters in double width mode.
+func (p Printer) SetCharacterSpacing(dots int) error {
+	errMsg := "could not set character spacing: %w"
+
+	err := checkRange(dots, 0, 255, "dots")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, ' ', byte(dots)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// ResetCharacterSpacing sets the character spacing back to the default of 0
+func (p Printer) ResetCharacterSpacing() error {
+	return p.SetCharacterSpacing(0)
+}
+
+// ResetLineSpacing sets the spacing to the default which
+// is 1/6-inch lines (approx. 4.23mm)
+func (p Printer) ResetLineSpacing() error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err := p.Write([]byte{ESC, '2'})
+	if err != nil {
+		return fmt.Errorf("could not reset line spacing: %w", err)
+	}
+
+	if p.config != nil {
+		p.config.lineSpacingSet = false
+	}
+	return nil
+}
+
+// LineSpacing returns the line spacing in motion units that was last set with
+// SetLineSpacing.  After ResetLineSpacing or Initialize it returns the default
+// 1/6 inch in motion units, which comes from the dots per mm or the vertical
+// unit of SetMotionUnits.  The image methods set the spacing to 0 while
+// printing and leave it there.
+func (p Printer) LineSpacing() int {
+	p, unlock := p.lock()
+	defer unlock()
+
+	if p.config != nil && p.config.lineSpacingSet {
+		return p.config.lineSpacing
+	}
+
+	if p.config != nil && p.config.motionY > 0 {
+		return int(math.Round(float64(p.config.motionY) / 6))
+	}
+
+	dotsPerMM := p.Profile().DotsPerMM
+	if dotsPerMM <= 0 {
+		dotsPerMM = 8
+	}
+	return int(math.Round(25.4 / 6 * dotsPerMM))
+}
+
+// SetLineSpacing sets the line spacing to n * v/h motion units in inches.
+// The spacing must be between 0 and 255, use SetLineSpacingMM to set it in
+// millimeters.
+func (p Printer) SetLineSpacing(n int) error {
+	errMsg := "could not set line spacing: %w"
+
+	err := checkRange(n, 0, 255, "n")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.writeCommand(ESC, '3', byte(n))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if p.config != nil {
+		p.config.lineSpacing, p.config.lineSpacingSet = n, true
+	}
+	return nil
+}
+
+// SetDotsPerMM sets how many motion units are in a millimeter for
+// SetLineSpacingMM.  The default comes from the profile, which is 8 for the
+// vertical motion unit of most 203dpi printers.  SetMotionUnits sets this to
+// match the vertical motion unit it sends.
+func (p Printer) SetDotsPerMM(dots float64) error {
+	if dots <= 0 {
+		return fmt.Errorf("could not set dots per mm: %v must be more than 0", dots)
+	}
+
+	if p.config == nil {
+		return nil
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.profile.DotsPerMM = dots
+	return nil
+}
+
+// SetMotionUnits sets the horizontal motion unit to 1/x inch and the vertical
+// motion unit to 1/y inch with GS P.  The units are used by commands like
+// Feed, SetLineSpacing, and the position commands, so setting them makes those
+// the same across printer models.  A value of 0 puts that unit back to the
+// printer default.
+//
+// The dots per mm used by SetLineSpacingMM is set from y, while a y of 0
+// leaves it alone since the default unit isn't known.
+func (p Printer) SetMotionUnits(x, y int) error {
+	errMsg := "could not set motion units: %w"
+
+	err := checkRange(x, 0, 255, "x")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(y, 0, 255, "y")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.Write([]byte{GS, 'P', byte(x), byte(y)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if p.config != nil {
+		p.config.motionX, p.config.motionY = x, y
+		if y > 0 {
+			p.config.profile.DotsPerMM = float64(y) / 25.4
+		}
+	}
+	return nil
+}
+
+// MotionUnits returns the units last set with SetMotionUnits, with 0 meaning
+// the printer default
+func (p Printer) MotionUnits() (x, y int) {
+	if p.config == nil {
+		return 0, 0
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	return p.config.motionX, p.config.motionY
+}
+
+// SetLineSpacingMM sets the line spacing to mm millimeters.  The millimeters
+// are converted to motion units with the dots per mm set by SetDotsPerMM and
+// rounded to the nearest unit, so at 8 dots per mm the spacing can be up to
+// about 31.9mm.
+func (p Printer) SetLineSpacingMM(mm float64) error {
+	errMsg := "could not set line spacing: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	dotsPerMM := p.Profile().DotsPerMM
+	if dotsPerMM <= 0 {
+		dotsPerMM = 8
+	}
+
+	dots := math.Round(mm * dotsPerMM)
+	if !(dots >= 0 && dots <= 255) {
+		return fmt.Errorf(errMsg, fmt.Errorf("%vmm is %v dots which is not between 0 and 255", mm, dots))
+	}
+
+	return p.SetLineSpacing(int(dots))
+}
+
+// feedCommands returns the ESC cmd commands that feed n, with each command
+// feeding up to 255.  At least one command is returned so a feed of 0 still
+// prints the data in the print buffer.
+func feedCommands(cmd byte, n int) []byte {
+	var data []byte
+	for ; n > 255; n -= 255 {
+		data = append(data, ESC, cmd, 255)
+	}
+	return append(data, ESC, cmd, byte(n))
+}
+
+// Feed feeds the paper n units.  A single ESC J can only feed 255 units, so
+// longer feeds are sent as more than one.
+func (p Printer) Feed(n int) error {
+	errMsg := "could not feed paper: %w"
+
+	if n < 0 {
+		return fmt.Errorf(errMsg, fmt.Errorf("n must not be negative"))
+	}
+
+	_, err := p.Write(feedCommands('J', n))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// FeedMM feeds the paper mm millimeters.  Like SetLineSpacingMM the
+// millimeters are converted to motion units with the dots per mm set by
+// SetDotsPerMM or SetMotionUnits and rounded to the nearest unit.  A single
+// feed can only be 255 units, about 31.9mm at 8 dots per mm, so longer feeds
+// are sent as more than one ESC J.
+func (p Printer) FeedMM(mm float64) error {
+	errMsg := "could not feed paper: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	dotsPerMM := p.Profile().DotsPerMM
+	if dotsPerMM <= 0 {
+		dotsPerMM = 8
+	}
+
+	dots := math.Round(mm * dotsPerMM)
+	if !(dots >= 0 && dots <= math.MaxInt32) {
+		return fmt.Errorf(errMsg, fmt.Errorf("%vmm is %v dots which is not a valid feed", mm, dots))
+	}
+
+	if dots == 0 {
+		return nil
+	}
+
+	_, err := p.Write(feedCommands('J', int(dots)))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// FeedLines feeds the paper n lines.  Like Feed, more than 255 lines are sent
+// as more than one ESC d.
+func (p Printer) FeedLines(n int) error {
+	errMsg := "could not feed lines: %w"
+
+	if n < 0 {
+		return fmt.Errorf(errMsg, fmt.Errorf("n must not be negative"))
+	}
+
+	_, err := p.Write(feedCommands('d', n))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// ReverseFeedUnits prints the data in the print buffer and feeds the paper
+// backwards n units with ESC K.  Not all printers can feed backwards and most
+// that can have a smaller limit than 255, like 48 units, and ignore the rest.
+func (p Printer) ReverseFeedUnits(n int) error {
+	errMsg := "could not reverse feed paper: %w"
+
+	err := checkRange(n, 0, 255, "n")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'K', byte(n)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// ReverseFeed prints the data in the print buffer and feeds the paper
+// backwards n lines with ESC e.  Not all printers can feed backwards and most
+// that can have a smaller limit than 255, like 2 lines, and ignore the rest.
+func (p Printer) ReverseFeed(n int) error {
+	errMsg := "could not reverse feed lines: %w"
+
+	err := checkRange(n, 0, 255, "n")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'e', byte(n)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// SetHT sets the horizontal tab positions
+//
+// This command cancels previous SetHT commands
+// Multiple positions can be set for tabbing
+// A max of 32 positions can be set
+// Calling SetHT with no argments resets the tab positions
+func (p Printer) SetHT(positions ...int) error {
+	errMsg := "could not set horizontal tab positions: %w"
+
+	if len(positions) > 32 {
+		return fmt.Errorf("more than 32 positions was set")
+	}
+
+	var data []byte
+	for i, pos := range positions {
+		err := checkRange(pos, 1, 255, fmt.Sprintf("position %d", i))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		data = append(data, byte(pos))
+	}
+
+	data = append([]byte{ESC, 'D'}, data...)
+	data = append(data, 0)
+
+	_, err := p.Write(data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// SetTabStops sets the horizontal tab positions to the columns, like
+// SetTabStops(8, 20, 34) for columns that aren't evenly spaced.  The
+// positions must be in ascending order and at most 32 can be set.  Calling
+// SetTabStops with no positions clears the tab positions.
+//
+// Use SetTabs for evenly spaced tab positions.
+func (p Printer) SetTabStops(positions ...int) error {
+	errMsg := "could not set tab stops: %w"
+
+	for i := 1; i < len(positions); i++ {
+		if positions[i] <= positions[i-1] {
+			return fmt.Errorf(errMsg, fmt.Errorf("position %d of %d is not after %d", i, positions[i], positions[i-1]))
+		}
+	}
+
+	err := p.SetHT(positions...)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetAbsolutePosition moves the print position to dots from the start of the
+// line
+func (p Printer) SetAbsolutePosition(dots int) error {
+	errMsg := "could not set absolute position: %w"
+
+	err := checkRange(dots, 0, p.MaxWidthDots(), "position")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{ESC, '$', byte(dots), byte(dots >> 8)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetRelativePosition moves the print position by dots from the current
+// position.  Negative dots move to the left.
+func (p Printer) SetRelativePosition(dots int) error {
+	errMsg := "could not set relative position: %w"
+
+	err := checkRange(dots, -32768, 32767, "position")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	n := uint16(int16(dots))
+	_, err = p.Write([]byte{ESC, '\\', byte(n), byte(n >> 8)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetLeftMargin sets the left margin in dots from the left edge of the
+// printable area.  The margin must be less than the profile dot width.  It
+// only takes effect at the start of a line and moves text, images, and bar
+// codes printed after it.
+func (p Printer) SetLeftMargin(dots int) error {
+	errMsg := "could not set left margin: %w"
+
+	err := checkRange(dots, 0, p.MaxWidthDots()-1, "left margin")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{GS, 'L', byte(dots), byte(dots >> 8)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetLeftMarginChars sets the left margin to cols characters of the font and
+// character width that were last set, to indent a block of text.  A
+// character is the profile dot width divided by the columns of the font and
+// multiplied by the width from SetCharacterSize, so 4 columns of font A on an
+// 80mm printer is 48 dots, or 96 dots at double width.  Spacing from
+// SetCharacterSpacing isn't counted.  The margin must be less than Columns.
+func (p Printer) SetLeftMarginChars(cols int) error {
+	errMsg := "could not set left margin: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	font, width := FontA, 1
+	if p.config != nil {
+		font, width = p.config.font, p.config.charWidth+1
+	}
+
+	columns := p.Profile().FontColumns(font)
+	if columns == 0 {
+		return fmt.Errorf(errMsg, fmt.Errorf("the profile has no columns for font %v", font))
+	}
+
+	err := checkRange(cols, 0, p.Columns()-1, "left margin columns")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// SetLeftMargin already says it couldn't set the left margin
+	return p.SetLeftMargin(cols * (p.MaxWidthDots() / columns) * width)
+}
+
+// SetPrintAreaWidth sets the width in dots of the area that is printed on,
+// starting from the left margin.  The width must be between 1 and the profile
+// dot width.  When the left margin plus the width is more than the paper the
+// printer uses the rest of the paper instead.
+func (p Printer) SetPrintAreaWidth(dots int) error {
+	errMsg := "could not set print area width: %w"
+
+	err := checkRange(dots, 1, p.MaxWidthDots(), "print area width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{GS, 'W', byte(dots), byte(dots >> 8)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetTabs will set up to 32 tab positions at the given width intervals.  If
+// the tab value exceeds 256, fewer than 32 positions will be set.
+func (p Printer) SetTabs(width int) error {
+	tabs := []int{}
+	for i := width; i < 256 && len(tabs) < 32; i += width {
+		tabs = append(tabs, i)
+	}
+	return p.SetHT(tabs...)
+}
+
+// SetBold turns emphasized mode on or off
+func (p Printer) SetBold(b bool) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err := p.Write([]byte{ESC, 'E', boolToByte(b)})
+	if err != nil {
+		return fmt.Errorf("could not set bold to %t: %w", b, err)
+	}
+
+	if p.config != nil {
+		p.config.bold = b
+	}
+	return nil
+}
+
+// SetDoubleStrike turns double-strike mode on or off
+//
+// This can be combined with SetBold
+func (p Printer) SetDoubleStrike(b bool) error {
+	_, err := p.Write([]byte{ESC, 'G', boolToByte(b)})
+	if err != nil {
+		return fmt.Errorf("could not set double-strike to %t: %w", b, err)
+	}
+	return nil
+}
+
+// SetUnderline sets the underline mode for text
+//
+// UnderlineThin is 1 dot thick and UnderlineThick is 2 dots thick
+func (p Printer) SetUnderline(mode UnderlineMode) error {
+	errMsg := "could not set underline mode: %w"
+
+	err := checkEnum(mode, UnderlineOff, UnderlineThin, UnderlineThick)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.Write([]byte{ESC, '-', byte(mode)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if p.config != nil {
+		p.config.underline = mode
+	}
+	return nil
+}
+
+// SetRotate90 turns on 90 clockwise rotation mode for the text
+//
+// When text is double-width or double-height the text will be mirrored
+func (p Printer) SetRotate90(b bool) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err := p.Write([]byte{ESC, 'V', boolToByte(b)})
+	if err != nil {
+		return fmt.Errorf("could not set 90 degree rotation to %t: %w", b, err)
+	}
+
+	if p.config != nil {
+		p.config.rotate90 = b
+	}
+	return nil
+}
+
+// Rotate90Enabled returns if 90 degree rotation was last turned on with
+// SetRotate90 or SetOrientation.  Initialize turns it off.
+func (p Printer) Rotate90Enabled() bool {
+	if p.config == nil {
+		return false
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	return p.config.rotate90
 }
 
 // SetReversePrinting sets the white/black printing mode
@@ -401,47 +2018,194 @@ func (p Printer) SetRotate90(b bool) error {
 // If b is true then it will print black text on white background
 // If b is false then it will print white text on black background
 func (p Printer) SetReversePrinting(b bool) error {
+	p, unlock := p.lock()
+	defer unlock()
+
 	_, err := p.Write([]byte{GS, 'B', boolToByte(b)})
 	if err != nil {
 		return fmt.Errorf("could not set reverse printing mode: %w", err)
 	}
+
+	if p.config != nil {
+		p.config.reverse = b
+	}
+	return nil
+}
+
+// PrintColor selects the color for two-color printers
+type PrintColor int
+
+const (
+	// Color1 is the first color, which is black on most two-color paper
+	Color1 PrintColor = iota + 1
+	// Color2 is the second color, which is red on most two-color paper
+	Color2
+)
+
+// SetColor selects the color of the characters printed after it with GS ( N.
+// The actual colors come from the paper, and this only works on printers and
+// paper that can print in two colors.  Other printers ignore it or print
+// everything in the first color.
+func (p Printer) SetColor(c PrintColor) error {
+	errMsg := "could not set color: %w"
+
+	err := checkEnum(c, Color1, Color2)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 48: select the character color
+	_, err = p.Write([]byte{GS, '(', 'N', 2, 0, '0', byte(c) + '0'})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// SetFont changes the font
+//
+// n=0 selects font A
+// n=1 selects font B
+// n=2 selects font C
+//
+// Every printer has font A, but ErrUnsupported is returned for font B and C
+// when the profile doesn't have any columns for them.
+func (p Printer) SetFont(f Font) error {
+	errMsg := "could not set font to %v: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := checkEnum(f, FontA, FontB, FontC)
+	if err != nil {
+		return fmt.Errorf(errMsg, f, err)
+	}
+
+	err = p.supports("SetFont", f == FontA || p.Profile().FontColumns(f) > 0)
+	if err != nil {
+		return fmt.Errorf(errMsg, f, err)
+	}
+
+	_, err = p.Write([]byte{ESC, 'M', byte(f)})
+	if err != nil {
+		return fmt.Errorf(errMsg, f, err)
+	}
+
+	if p.config != nil {
+		p.config.font = f
+	}
+	return nil
+}
+
+// Justify sets the alignment to n
+func (p Printer) Justify(j Justification) error {
+	errMsg := "could not set justify to %v: %w"
+
+	err := checkEnum(j, CenterJustify, LeftJustify, RightJustify)
+	if err != nil {
+		return fmt.Errorf(errMsg, j, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.Write([]byte{ESC, 'a', byte(j)})
+	if err != nil {
+		return fmt.Errorf(errMsg, j, err)
+	}
+
+	if p.config != nil {
+		p.config.justify = j
+	}
+	return nil
+}
+
+// imageColumn packs the 8 dots going down from x, y into a byte with the top
+// dot as the most significant bit
+func imageColumn(bm *bitmap, x, y int) byte {
+	col := byte(0)
+	for i := 0; i < 8; i++ {
+		col <<= 1
+		if bm.dot(x, y+i) {
+			col |= 1
+		}
+	}
+	return col
+}
+
+// imageBand packs the 24 rows of dots going down from y into row, 3 bytes
+// for each column like imageColumn does for 8.  It goes over the dots a row
+// at a time, which is faster than going down each column.
+func imageBand(bm *bitmap, y int, row []byte) {
+	for i := range row {
+		row[i] = 0
+	}
+
+	for dy := 0; dy < 24 && y+dy < bm.height; dy++ {
+		dots := bm.dots[(y+dy)*bm.width : (y+dy+1)*bm.width]
+		z, bit := dy/8, byte(0x80)>>(dy%8)
+		for x, dot := range dots {
+			if dot {
+				row[x*3+z] |= bit
+			}
+		}
+	}
+}
+
+// ErrImageTooWide is returned when an image is wider than MaxWidthDots
+var ErrImageTooWide = errors.New("image is too wide for the paper")
+
+// checkImageWidth checks that an image with the given width in pixels fits on
+// the paper.  At SingleDensity each pixel is two dots wide.
+func (p Printer) checkImageWidth(width int, density Density) error {
+	maxWidth := p.MaxWidthDots()
+	if density == SingleDensity {
+		maxWidth /= 2
+	}
+
+	if width > maxWidth {
+		return fmt.Errorf("%w: width of %d is more than the max width of %d", ErrImageTooWide, width, maxWidth)
+	}
 	return nil
 }
 
-// SetFont changes the font
+// SetImageChunkBands limits PrintImageRaster and PrintGraphics to sending n
+// bands of 24 dots rows at a time.  The status is read after each chunk, which
+// the printer only sends back once it has the chunk, so slow printers with a
+// small input buffer don't drop rows of a tall image.  PrintImage8 and
+// PrintImage24 already wait after every row so they aren't changed.
 //
-// n=0 selects font A
-// n=1 selects font B
-func (p Printer) SetFont(f Font) error {
-	errMsg := "could not set font to %v: %w"
-
-	err := checkEnum(f, FontA, FontB)
+// A smaller chunk is more reliable but slower.  A chunk of 0 sends the image
+// in as few commands as possible, which is the default.
+func (p Printer) SetImageChunkBands(n int) error {
+	err := checkRange(n, 0, rasterMaxHeight/24, "bands")
 	if err != nil {
-		return fmt.Errorf(errMsg, f, err)
+		return fmt.Errorf("could not set image chunk: %w", err)
 	}
 
-	_, err = p.Write([]byte{ESC, 'M', byte(f)})
-	if err != nil {
-		return fmt.Errorf(errMsg, f, err)
+	if p.config == nil {
+		return nil
 	}
 
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.imageChunk = n
 	return nil
 }
 
-// Justify sets the alignment to n
-func (p Printer) Justify(j Justification) error {
-	errMsg := "could not set justify to %v: %w"
-
-	err := checkEnum(j, CenterJustify, LeftJustify, RightJustify)
-	if err != nil {
-		return fmt.Errorf(errMsg, j, err)
+// imageChunkRows returns the most rows to send in one image command, which is
+// maxRows unless SetImageChunkBands set a smaller chunk
+func (p Printer) imageChunkRows(maxRows int) int {
+	if p.config == nil || p.config.imageChunk == 0 {
+		return maxRows
 	}
 
-	_, err = p.Write([]byte{ESC, 'a', byte(j)})
-	if err != nil {
-		return fmt.Errorf(errMsg, j, err)
+	rows := p.config.imageChunk * 24
+	if rows > maxRows {
+		return maxRows
 	}
-	return nil
+	return rows
 }
 
 // PrintImage8 prints an image in the 8-bit row format.  In this format each
@@ -451,45 +2215,44 @@ func (p Printer) Justify(j Justification) error {
 // 90dpi while DoubleDensity is 180dpi.  Vertical DPI is always 60dpi for
 // 8-bit image data.
 //
-// No black and white conversion is performed on the provided image.  The
-// image should be converted before calling this function.
+// Pixels darker than 50% gray are printed as black and everything else is
+// left white.  The last row is padded with white if the image height is not a
+// multiple of 8.
 func (p Printer) PrintImage8(img image.Image, density Density) error {
 	imgRect := img.Bounds()
 	var err error
 	errMsg := "could not print 8 dot image: %w"
 
+	p, unlock := p.lock()
+	defer unlock()
+
 	err = checkEnum(density, SingleDensity, DoubleDensity)
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
 
-	// 8 dot density (meta row is 8 dots tall)
-	for y := 0; y < imgRect.Max.Y; y += 8 {
-		row := []byte{}
-		for x := 0; x < imgRect.Max.X; x++ {
-			col := byte(0)
-
-			for i := 0; i < 8; i++ {
-				col <<= 1
-				// Pad the bottom row to be white
-				if y+i >= imgRect.Max.Y {
-					continue
-				}
-				c := color.GrayModel.Convert(img.At(x, y+i)).(color.Gray)
-				if c.Y < 0x80 {
-					col |= 1
-				}
-			}
+	err = p.checkImageWidth(imgRect.Dx(), density)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
 
-			row = append(row, col)
-		}
+	bm := monochrome(img, defaultImageOptions)
 
-		data := []byte{ESC, '*', byte(density), byte(len(row)), byte(len(row) >> 8)}
+	buf := getScratch()
+	defer putScratch(buf)
+
+	// 8 dot density (meta row is 8 dots tall)
+	for y := 0; y < bm.height; y += 8 {
+		data := append((*buf)[:0], ESC, '*', byte(density), byte(bm.width), byte(bm.width>>8))
+		for x := 0; x < bm.width; x++ {
+			data = append(data, imageColumn(bm, x, y))
+		}
+		*buf = data
 
 		if err = p.SetLineSpacing(0); err != nil {
 			return fmt.Errorf(errMsg, err)
 		}
-		if _, err = p.Write(append(data, row...)); err != nil {
+		if _, err = p.Write(data); err != nil {
 			return fmt.Errorf(errMsg, err)
 		}
 		if err = p.LF(); err != nil {
@@ -513,46 +2276,85 @@ func (p Printer) PrintImage8(img image.Image, density Density) error {
 // of the printed image.  SingleDensity is 90dpi while DoubleDensity is
 // 180dpi.  Vertical DPI is always 180dpi for 24-bit image data.
 func (p Printer) PrintImage24(img image.Image, density Density) error {
-	imgRect := img.Bounds()
+	return p.PrintImage24Ctx(context.Background(), img, density)
+}
+
+// PrintImage24Ctx works the same as PrintImage24() but stops sending rows
+// when the context is done.  The context is checked before each 24 dot row
+// and when it is done an LF is sent to finish the current line, so the
+// printer is left ready for the next command with part of the image printed.
+func (p Printer) PrintImage24Ctx(ctx context.Context, img image.Image, density Density) error {
+	return p.printImage24(ctx, img, density, defaultImageOptions)
+}
+
+// PrintImage24Opts works the same as PrintImage24() but opts controls how the
+// image is converted to black and white before printing.
+func (p Printer) PrintImage24Opts(img image.Image, density Density, opts ImageOptions) error {
+	return p.printImage24(context.Background(), img, density, opts)
+}
+
+// PrintImage24Inverted works the same as PrintImage24() but prints the white
+// parts of the image as black.  Transparent pixels are still left white, so a
+// white logo on a clear background prints as a black logo.
+func (p Printer) PrintImage24Inverted(img image.Image, density Density) error {
+	opts := defaultImageOptions
+	opts.Invert = true
+	return p.printImage24(context.Background(), img, density, opts)
+}
+
+func (p Printer) printImage24(ctx context.Context, img image.Image, density Density, opts ImageOptions) error {
 	var err error
 	errMsg := "could not print 24 dot image: %w"
 
+	p, unlock := p.lock()
+	defer unlock()
+
 	err = checkEnum(density, SingleDensity, DoubleDensity)
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
 
-	command := []byte{ESC, 0x2A, byte(density + 32), byte(imgRect.Max.X), byte(imgRect.Max.X >> 8)}
+	err = checkImageOptions(opts)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	img, err = p.fitOverflow(img, density, opts)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// The whole image is dithered before it is split into rows so the error
+	// carries across the rows without seams
+	bm := monochrome(img, opts)
+
+	// Each band is the command followed by 3 bytes for every column, and
+	// the buffer from the pool is used again for every band
+	buf := getScratch()
+	defer putScratch(buf)
+
+	command := []byte{ESC, 0x2A, byte(density + 32), byte(bm.width), byte(bm.width >> 8)}
+	band := append(append(*buf, command...), make([]byte, bm.width*3)...)
+	*buf = band
 
 	// 24 dot density (meta row is 24 dots tall (3 bytes))
-	for y := 0; y < imgRect.Max.Y; y += 24 {
-		row := []byte{}
-
-		for x := 0; x < imgRect.Max.X; x++ {
-			for z := 0; z < 3; z++ {
-				col := byte(0)
-				for i := 0; i < 8; i++ {
-					col <<= 1
-					// Pad the bottom row to be white
-					if (y+z*8)+i >= imgRect.Max.Y {
-						continue
-					}
-
-					c := color.GrayModel.Convert(img.At(x, (y+z*8)+i)).(color.Gray)
-					if c.Y < 0x80 {
-						col |= 1
-					}
-				}
-				row = append(row, col)
+	for y := 0; y < bm.height; y += 24 {
+		if ctx.Err() != nil {
+			err = p.LF()
+			if err != nil {
+				return fmt.Errorf(errMsg, err)
 			}
+			return fmt.Errorf(errMsg, ctx.Err())
 		}
 
+		imageBand(bm, y, band[len(command):])
+
 		err = p.SetLineSpacing(0)
 		if err != nil {
 			return fmt.Errorf(errMsg, err)
 		}
 
-		_, err = p.Write(append(command, row...))
+		_, err = p.Write(band)
 		if err != nil {
 			return fmt.Errorf(errMsg, err)
 		}
@@ -579,6 +2381,110 @@ func (p Printer) PrintImage24(img image.Image, density Density) error {
 	return nil
 }
 
+// rasterRow packs a row of the image into bytes with the left most dot as the
+// most significant bit.  The row is padded with white to a whole byte.
+func appendRasterRow(data []byte, bm *bitmap, y int) []byte {
+	start := len(data)
+	data = append(data, make([]byte, (bm.width+7)/8)...)
+
+	row := data[start:]
+	for i := range row {
+		for b := 0; b < 8; b++ {
+			if bm.dot(i*8+b, y) {
+				row[i] |= 0x80 >> b
+			}
+		}
+	}
+	return data
+}
+
+// PrintImageRaster prints an image with the GS v 0 raster bit image command.
+// The whole image is sent as a block instead of being split into rows like
+// PrintImage24(), which is faster and does not band.
+//
+// The image is printed at 180dpi and the mode can double the width and/or
+// height of each dot.  Images taller than 2303 pixels, or the chunk set by
+// SetImageChunkBands, are sent as multiple blocks.
+func (p Printer) PrintImageRaster(img image.Image, mode RasterMode) error {
+	imgRect := img.Bounds()
+	errMsg := "could not print raster image: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := checkEnum(mode, RasterNormal, RasterDoubleWidth, RasterDoubleHeight, RasterQuadruple)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	density := DoubleDensity
+	if mode == RasterDoubleWidth || mode == RasterQuadruple {
+		density = SingleDensity
+	}
+
+	err = p.checkImageWidth(imgRect.Dx(), density)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	bm := monochrome(img, defaultImageOptions)
+	width := (bm.width + 7) / 8
+
+	buf := getScratch()
+	defer putScratch(buf)
+
+	maxRows := p.imageChunkRows(rasterMaxHeight)
+	for y := 0; y < bm.height; y += maxRows {
+		height := bm.height - y
+		if height > maxRows {
+			height = maxRows
+		}
+
+		data := append((*buf)[:0], GS, 'v', '0', byte(mode), byte(width), byte(width>>8), byte(height), byte(height>>8))
+		for i := 0; i < height; i++ {
+			data = appendRasterRow(data, bm, y+i)
+		}
+		*buf = data
+
+		_, err = p.Write(data)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		// Wait for the block to finish
+		_, err = p.TransmitErrorStatus()
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// PrintBlackBar prints a solid black bar across the whole width of the paper
+// from the profile that is height dots tall, up to 2303 dots.
+//
+// Text can't be printed on top of the bar, but the bar lines up with
+// reverse printing.  For a white on black strip, print a bar, then the text
+// with PrintBox and a width of 0 so the reversed line goes across the paper,
+// and then another bar.
+func (p Printer) PrintBlackBar(height int) error {
+	errMsg := "could not print black bar: %w"
+
+	err := checkRange(height, 1, rasterMaxHeight, "height")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, p.MaxWidthDots(), height))
+
+	err = p.PrintImageRaster(img, RasterNormal)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
 // SetHRIPosition sets the printing position of the HRI characters
 // in relation to the barcode
 func (p Printer) SetHRIPosition(hp HRIPosition) error {
@@ -596,6 +2502,22 @@ func (p Printer) SetHRIPosition(hp HRIPosition) error {
 	return nil
 }
 
+// SetHRIFont sets the font of the HRI characters printed with bar codes
+func (p Printer) SetHRIFont(f Font) error {
+	errMsg := "could not set HRI font to %v: %w"
+
+	err := checkEnum(f, FontA, FontB)
+	if err != nil {
+		return fmt.Errorf(errMsg, f, err)
+	}
+
+	_, err = p.Write([]byte{GS, 'f', byte(f)})
+	if err != nil {
+		return fmt.Errorf(errMsg, f, err)
+	}
+	return nil
+}
+
 // ResetBarCodeHeight sets the bar code height to 162
 func (p Printer) ResetBarCodeHeight() error {
 	err := p.SetBarCodeHeight(162)
@@ -622,6 +2544,36 @@ func (p Printer) SetBarCodeHeight(n int) error {
 	return nil
 }
 
+// SetBarcodeHeight sets the bar code height in dots like SetBarCodeHeight
+func (p Printer) SetBarcodeHeight(dots int) error {
+	return p.SetBarCodeHeight(dots)
+}
+
+// SetBarcodeWidth sets the horizontal size of the bar code modules like
+// SetBarCodeWidth
+func (p Printer) SetBarcodeWidth(n int) error {
+	return p.SetBarCodeWidth(n)
+}
+
+// SetBarCodeWidth sets the horizontal size of the bar code modules
+//
+// n must be between 2 and 6
+func (p Printer) SetBarCodeWidth(n int) error {
+	errMsg := "could not set bar code width: %w"
+
+	err := checkRange(n, 2, 6, "width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	_, err = p.Write([]byte{GS, 'w', byte(n)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
 // SetCharacterSize sets both the width and height of text characters.  The
 // values for height and width must be between 0 and 7, inclusively.
 //
@@ -640,11 +2592,54 @@ func (p Printer) SetCharacterSize(width, height int) error {
 		return fmt.Errorf(errMsg, err)
 	}
 
+	p, unlock := p.lock()
+	defer unlock()
+
 	_, err = p.Write([]byte{GS, '!', uint8(width)<<4 | uint8(height)})
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
 
+	if p.config != nil {
+		p.config.charWidth = width
+		p.config.charHeight = height
+	}
+	return nil
+}
+
+// characterSize returns the last character size that was set
+func (p Printer) characterSize() (width, height int) {
+	if p.config == nil {
+		return 0, 0
+	}
+	return p.config.charWidth, p.config.charHeight
+}
+
+// SetDoubleHeight turns double height text on or off with SetCharacterSize
+// and keeps the width that was last set
+func (p Printer) SetDoubleHeight(b bool) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	width, _ := p.characterSize()
+	err := p.SetCharacterSize(width, int(boolToByte(b)))
+	if err != nil {
+		return fmt.Errorf("could not set double height to %t: %w", b, err)
+	}
+	return nil
+}
+
+// SetDoubleWidth turns double width text on or off with SetCharacterSize and
+// keeps the height that was last set
+func (p Printer) SetDoubleWidth(b bool) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, height := p.characterSize()
+	err := p.SetCharacterSize(int(boolToByte(b)), height)
+	if err != nil {
+		return fmt.Errorf("could not set double width to %t: %w", b, err)
+	}
 	return nil
 }
 
@@ -656,15 +2651,98 @@ func (p Printer) SetUpsideDown(upsidedown bool) error {
 		val = 1
 	}
 
-	fmt.Printf("upside-down %X\n", val)
+	p, unlock := p.lock()
+	defer unlock()
 
 	_, err := p.Write([]byte{ESC, '{', val})
 	if err != nil {
 		return fmt.Errorf("could not set upside-down mode: %w", err)
 	}
+
+	if p.config != nil {
+		p.config.upsideDown = upsidedown
+	}
 	return nil
 }
 
+// UpsideDownEnabled returns if upside-down printing was last turned on with
+// SetUpsideDown or SetOrientation.  Initialize turns it off.
+func (p Printer) UpsideDownEnabled() bool {
+	if p.config == nil {
+		return false
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	return p.config.upsideDown
+}
+
+// Orientation is the direction text is printed in
+type Orientation int
+
+const (
+	OrientationNormal Orientation = iota
+	// OrientationUpsideDown rotates the text 180 degrees
+	OrientationUpsideDown
+	// OrientationRotate90 rotates the text 90 degrees clockwise
+	OrientationRotate90
+	// OrientationRotate270 rotates the text 270 degrees clockwise.  This uses
+	// upside-down and 90 degree rotation together, which not all printers
+	// support.
+	OrientationRotate270
+)
+
+// SetOrientation sets the direction text is printed in.  Both upside-down
+// (ESC {) and 90 degree rotation (ESC V) are always sent so the mode from a
+// previous orientation is cleared.
+//
+// The base command set only has upside-down and 90 degree clockwise rotation,
+// so OrientationRotate270 depends on the printer turning on both at once.
+// Upside-down only takes effect at the start of a line.
+func (p Printer) SetOrientation(o Orientation) error {
+	errMsg := "could not set orientation: %w"
+
+	err := checkEnum(o, OrientationNormal, OrientationUpsideDown, OrientationRotate90, OrientationRotate270)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	upsideDown := o == OrientationUpsideDown || o == OrientationRotate270
+	rotate := o == OrientationRotate90 || o == OrientationRotate270
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.Write([]byte{ESC, '{', boolToByte(upsideDown), ESC, 'V', boolToByte(rotate)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if p.config != nil {
+		p.config.upsideDown, p.config.rotate90 = upsideDown, rotate
+	}
+	return nil
+}
+
+// Orientation returns the direction text is printed in from the upside-down
+// and 90 degree rotation modes that were last set, whether they were set with
+// SetOrientation, SetUpsideDown, or SetRotate90
+func (p Printer) Orientation() Orientation {
+	p, unlock := p.lock()
+	defer unlock()
+
+	switch up, rotate := p.UpsideDownEnabled(), p.Rotate90Enabled(); {
+	case up && rotate:
+		return OrientationRotate270
+	case rotate:
+		return OrientationRotate90
+	case up:
+		return OrientationUpsideDown
+	}
+	return OrientationNormal
+}
+
 func checkBarcodeCodabarData(data string) error {
 	body := "0123456789-$:/.+"
 	wrappers := "ABCD"
@@ -673,7 +2751,7 @@ func checkBarcodeCodabarData(data string) error {
 		return fmt.Errorf("the first and last byte of CODABAR must be one of %s", wrappers)
 	}
 
-	for _, d := range data {
+	for _, d := range data[1 : len(data)-1] {
 		if !strings.ContainsRune(body, d) {
 			return fmt.Errorf("%s was in the bar code data and only %q is accepted", string(d), body)
 		}
@@ -682,6 +2760,56 @@ func checkBarcodeCodabarData(data string) error {
 	return nil
 }
 
+// encodeCode128Data converts CODE128 data with code set prefixes into the
+// bytes sent to the printer. Code set C digits are packed in pairs.
+func encodeCode128Data(data string) ([]byte, error) {
+	if len(data) < 2 || data[0] != '{' || !strings.ContainsRune("ABC", rune(data[1])) {
+		return nil, fmt.Errorf("CODE128 data must start with a code set of {A, {B, or {C")
+	}
+
+	var out []byte
+	var set byte
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if c == '{' {
+			if i+1 >= len(data) {
+				return nil, fmt.Errorf("CODE128 data cannot end with {")
+			}
+			i++
+			switch data[i] {
+			case 'A', 'B', 'C':
+				set = data[i]
+			case '1', '2', '3', '4', 'S', '{':
+			default:
+				return nil, fmt.Errorf("{%s is not a valid CODE128 special character", string(data[i]))
+			}
+			out = append(out, '{', data[i])
+			continue
+		}
+
+		switch set {
+		case 'A':
+			if c > 0x5F {
+				return nil, fmt.Errorf("%q is not in CODE128 code set A", c)
+			}
+		case 'B':
+			if c < 0x20 || 0x7F < c {
+				return nil, fmt.Errorf("%q is not in CODE128 code set B", c)
+			}
+		case 'C':
+			if i+1 >= len(data) || !strings.ContainsRune("0123456789", rune(c)) || !strings.ContainsRune("0123456789", rune(data[i+1])) {
+				return nil, fmt.Errorf("CODE128 code set C only accepts pairs of digits")
+			}
+			c = (c-'0')*10 + data[i+1] - '0'
+			i++
+		}
+		out = append(out, c)
+	}
+
+	return out, nil
+}
+
 func checkBarcodeData(data, accepted string) error {
 	for _, d := range data {
 		if !strings.ContainsRune(accepted, d) {
@@ -691,6 +2819,48 @@ func checkBarcodeData(data, accepted string) error {
 	return nil
 }
 
+// AppendCheckDigit returns the data with the modulo 10 check digit added to
+// the end.  It works for BcUPCA with 11 digits, BcJAN13 with 12 digits, and
+// BcJAN8 with 7 digits.  PrintBarCode already lets the printer add the check
+// digit, so this is for when the full code is needed for something else like
+// a receipt line or a database.
+func AppendCheckDigit(data string, barcodeType BarCode) (string, error) {
+	errMsg := "could not append check digit: %w"
+
+	var length int
+	switch barcodeType {
+	case BcUPCA:
+		length = 11
+	case BcJAN13:
+		length = 12
+	case BcJAN8:
+		length = 7
+	default:
+		return "", fmt.Errorf(errMsg, fmt.Errorf("bar code type %d does not use a modulo 10 check digit", barcodeType))
+	}
+
+	if len(data) != length {
+		return "", fmt.Errorf(errMsg, fmt.Errorf("data must be %d digits but was %d", length, len(data)))
+	}
+
+	err := checkBarcodeData(data, "0123456789")
+	if err != nil {
+		return "", fmt.Errorf(errMsg, err)
+	}
+
+	// Digits are weighted 3 and 1 starting with 3 on the right
+	sum := 0
+	for i := range data {
+		digit := int(data[len(data)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return data + string(rune('0'+(10-sum%10)%10)), nil
+}
+
 // PrintBarCode prints the bar code passed in with data.
 //
 // The size ranges are as follows in (Type: min, max):
@@ -703,17 +2873,28 @@ func checkBarcodeData(data, accepted string) error {
 //	BcITF: 0, 22
 //	BcCODABAR: 2, 19
 //	BcCODE93: 1, 17
-//	BcCODE123: 0, 65
+//	BcCODE128: 0, 62
 //
 // For the accepted data values:
 //
 //	BcUPCA, BcUPCE, BcJAN13, BcJAN8, BcITF all only accept [0123456789]
-//	BcCODE39, BcCODE93, BcCODE123 can accept [ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-.*$/+% ]
+//	BcCODE39, BcCODE93 can accept [ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-.*$/+% ]
 //	BcCODABAR:
 //	  The first and last character of the CODABAR code bar has to be one of [ABCD]
 //	  and the rest of the characters in between can be one of [0123456789-$:/.+]
+//	BcCODE128:
+//	  When the data starts with {A, {B, or {C the code set is selected and can be
+//	  switched again later in the data. Code set A accepts 0x00-0x5F, code set B
+//	  accepts 0x20-0x7F, and code set C accepts pairs of digits like "{C123456".
+//	  {1, {2, {3, {4 send FNC1-4, {S shifts the next character, and {{ sends {
+//	  The printer can't read data without a code set prefix, so "{B" is
+//	  added to the front of it, the same as PrintBarCodeImage draws it.
 //
-// Note on the CODE123 length:
+// The CODE128 length is counted after the code set characters are encoded,
+// so "{C123456" is 5 bytes.  The limit is 62 so 60 characters without a
+// prefix still fit once {B is added.
+//
+// Note on the CODE128 length:
 //
 //	...the docs say it's between 2 and 255 but the printer
 //	does not have that limit. On one hand it can go down to 0 character, but also I could
@@ -724,12 +2905,30 @@ func checkBarcodeData(data, accepted string) error {
 func (p Printer) PrintBarCode(barcodeType BarCode, data string) error {
 	errMsg := "could not print bar code: %w"
 
-	err := checkEnum(barcodeType, allBarcodes...)
+	err := p.supports("PrintBarCode", p.Profile().SupportsBarCode)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(barcodeType, allBarcodes...)
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
 
-	dataLength := len(data)
+	msgData := []byte(data)
+	if barcodeType == BcCODE128 {
+		// The printer needs a code set at the start to read the data, and
+		// code set B has all the printable ASCII characters
+		if !strings.HasPrefix(data, "{") {
+			data = "{B" + data
+		}
+		msgData, err = encodeCode128Data(data)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	dataLength := len(msgData)
 
 	// Check length
 	var min, max int
@@ -750,11 +2949,11 @@ func (p Printer) PrintBarCode(barcodeType BarCode, data string) error {
 		min, max = 2, 19
 	case BcCODE93:
 		min, max = 1, 17
-	case BcCODE123:
+	case BcCODE128:
 		// At 66 characters for 'A...' the printer seems to cry
 		// for printing all 0s it cried at 65
 		// maybe it needs some friends
-		min, max = 0, 60
+		min, max = 0, 62
 	}
 
 	err = checkRange(dataLength, min, max, "data length")
@@ -766,7 +2965,7 @@ func (p Printer) PrintBarCode(barcodeType BarCode, data string) error {
 	switch barcodeType {
 	case BcUPCA, BcUPCE, BcJAN13, BcJAN8, BcITF:
 		err = checkBarcodeData(data, "0123456789")
-	case BcCODE39, BcCODE93, BcCODE123:
+	case BcCODE39, BcCODE93:
 		err = checkBarcodeData(data, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-.*$/+% ")
 	case BcCODABAR:
 		err = checkBarcodeCodabarData(data)
@@ -782,7 +2981,7 @@ func (p Printer) PrintBarCode(barcodeType BarCode, data string) error {
 	if inSlice(barcodeType, lengthBarcodes...) {
 		// length defined barcode
 		msg = append(msg, byte(dataLength))
-		msg = append(msg, data...)
+		msg = append(msg, msgData...)
 	} else {
 		// Null ending barcode
 		msg = append(msg, data...)
@@ -797,6 +2996,26 @@ func (p Printer) PrintBarCode(barcodeType BarCode, data string) error {
 	return nil
 }
 
+// Barcode prints data as a bar code of the symbology with PrintBarCode, which
+// has the lengths and characters each symbology accepts
+func (p Printer) Barcode(data string, symbology Symbology) error {
+	return p.PrintBarCode(symbology, data)
+}
+
+// SetPrintMode sends the print mode with ESC ! n, where n is the flags of the
+// mode combined into one byte.  ThinFont selects font B, and a mode of 0 turns
+// everything off.  For example Emphasized|DoubleHeight|Underline is 0x98.
+//
+// Like SelectPrintMode, this overlaps with SetFont, SetBold, SetUnderline,
+// and SetCharacterSize, so pick either the print mode or the individual
+// setters.
+func (p Printer) SetPrintMode(mode PrintMode) error {
+	if mode == 0 {
+		return p.SelectPrintMode()
+	}
+	return p.SelectPrintMode(mode)
+}
+
 // SelectPrintMode sets the print mode for the printer.
 // The modes are as follows and are provided as variadic arguments:
 //   - ThinFont: Font selection, 0: Font A (normal), 1: Font B (thin)
@@ -805,23 +3024,55 @@ func (p Printer) PrintBarCode(barcodeType BarCode, data string) error {
 //   - DoubleHeight: Double height, 0: Off (normal), 1: On (double height font)
 //   - DoubleWidth: Double width, 0: Off (normal), 1: On (double width font)
 //   - Underline: Underline, 0: Off (normal), 1: On (underlined font)
+//
+// Modes can also be combined before being passed in, like Bold|Underline.
+// Any mode that is not passed in is turned off.
+//
+// The print mode overlaps with SetFont, SetBold, SetUnderline, and
+// SetCharacterSize, whichever command is sent last wins.  It's easiest to
+// stick to either SelectPrintMode or the individual setters.
 func (p Printer) SelectPrintMode(modes ...PrintModeMask) error {
 	errMsg := "could not select print mode: %w"
 
-	for _, mode := range modes {
-		err := checkEnum(mode, ThinFont, Bold, DoubleHeight, DoubleWidth, Underline)
-		if err != nil {
-			return fmt.Errorf(errMsg, err)
-		}
-	}
+	allModes := ThinFont | Bold | DoubleHeight | DoubleWidth | Underline
 
 	var mask byte
 	for _, mode := range modes {
+		if mode&^allModes != 0 || mode == 0 {
+			return fmt.Errorf(errMsg, fmt.Errorf("%08b is not a valid print mode", mode))
+		}
 		mask |= byte(mode)
 	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
 	_, err := p.Write([]byte{ESC, '!', mask})
 	if err != nil {
 		return fmt.Errorf(errMsg, err)
 	}
+
+	if p.config != nil {
+		p.config.font = FontA
+		if mask&byte(ThinFont) != 0 {
+			p.config.font = FontB
+		}
+
+		p.config.charWidth = 0
+		if mask&byte(DoubleWidth) != 0 {
+			p.config.charWidth = 1
+		}
+
+		p.config.charHeight = 0
+		if mask&byte(DoubleHeight) != 0 {
+			p.config.charHeight = 1
+		}
+
+		p.config.bold = mask&byte(Bold) != 0
+		p.config.underline = UnderlineOff
+		if mask&byte(Underline) != 0 {
+			p.config.underline = UnderlineThin
+		}
+	}
 	return nil
 }
@@ -0,0 +1,117 @@
+package escpos
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestZeroConfig checks that methods which put the state back don't panic on
+// a printer without a config
+func TestZeroConfig(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(Printer) error
+	}{
+		{"QRCodeCentered", func(p Printer) error { return p.QRCodeCentered("data", QROptions{}) }},
+		{"PrintLarge", func(p Printer) error { return p.PrintLarge("12", 2, 2, CenterJustify) }},
+		{"PrintReversed", func(p Printer) error { return p.PrintReversed("label") }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink := &CaptureSink{}
+			err := c.run(Printer{dst: sink})
+			if err != nil {
+				t.Fatalf("failed on a printer without a config: %v", err)
+			}
+			if len(sink.Bytes()) == 0 {
+				t.Fatalf("nothing was sent")
+			}
+		})
+	}
+}
+
+// TestZeroPrinterSetters checks that setters do nothing instead of panicking on
+// the zero printer
+func TestZeroPrinterSetters(t *testing.T) {
+	var p Printer
+
+	p.SetLineFlush(true)
+	p.SetReadTimeout(time.Second)
+	p.SetWriteTimeout(time.Second)
+	p.SetWritePacing(100)
+	p.SetSanitizeText(true)
+	p.SetConfirmJobs(true)
+	p.SetAutoRecover(3)
+	p.SetDebugWriter(io.Discard)
+	p.SetEncoding(nil)
+	p.SetEncodingSubstitute('*')
+	p.Use(func(next WriteFunc) WriteFunc { return next })
+
+	setters := []struct {
+		name string
+		run  func() error
+	}{
+		{"SetBuffered", func() error { return p.SetBuffered(true) }},
+		{"SetDotsPerMM", func() error { return p.SetDotsPerMM(8) }},
+		{"SetExpandTabs", func() error { return p.SetExpandTabs(8) }},
+		{"SetNewline", func() error { return p.SetNewline(NewlineCRLF) }},
+		{"SetImageChunkBands", func() error { return p.SetImageChunkBands(4) }},
+		{"SetOverflow", func() error { return p.SetOverflow(OverflowWrap) }},
+		{"Batch", func() error { return p.Batch(func(Printer) error { return nil }) }},
+	}
+
+	for _, c := range setters {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.run()
+			if err != nil {
+				t.Fatalf("failed on the zero printer: %v", err)
+			}
+		})
+	}
+
+	// The arguments are still checked
+	if p.SetDotsPerMM(0) == nil {
+		t.Fatalf("0 dots per mm did not fail on the zero printer")
+	}
+	if p.SetExpandTabs(-1) == nil {
+		t.Fatalf("a tab width of -1 did not fail on the zero printer")
+	}
+}
+
+func TestQRSplit(t *testing.T) {
+	parts, headers := qrSplit("ABCDEFGH", 3)
+
+	if !reflect.DeepEqual(parts, []string{"ABC", "DEF", "GH"}) {
+		t.Fatalf("split into %q", parts)
+	}
+
+	// The parity is 41 ^ 42 ^ ... ^ 48 for the whole data
+	want := []qrStructuredAppend{
+		{position: 0, total: 3, parity: 0x08},
+		{position: 1, total: 3, parity: 0x08},
+		{position: 2, total: 3, parity: 0x08},
+	}
+	if !reflect.DeepEqual(headers, want) {
+		t.Fatalf("headers were %+v instead of %+v", headers, want)
+	}
+
+	// Each symbol starts with 0011, the position, the total - 1, and the
+	// parity, then the byte mode 0100 and the length
+	for i, part := range parts {
+		data := qrData(part, headers[i], 1, QRErrorL)
+		want := append([]byte{0x30 | byte(i), 0x20, 0x84, byte(len(part))}, part...)
+		if !bytes.Equal(data[:len(want)], want) {
+			t.Fatalf("symbol %d started with % x instead of % x", i+1, data[:len(want)], want)
+		}
+	}
+
+	// Data that fits is one symbol that is still a structured append of 1
+	parts, headers = qrSplit("ABC", 3)
+	if len(parts) != 1 || headers[0] != (qrStructuredAppend{position: 0, total: 1, parity: 0x40}) {
+		t.Fatalf("split into %q with %+v", parts, headers)
+	}
+}
@@ -0,0 +1,2913 @@
+package escpos_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// newPrinter returns a printer with the profile that writes to a CaptureSink
+func newPrinter(profile escpos.Profile) (*escpos.CaptureSink, escpos.Printer) {
+	sink := &escpos.CaptureSink{}
+	return sink, escpos.NewPrinterWithProfile(sink, profile)
+}
+
+func TestSetFont(t *testing.T) {
+	withFontC := escpos.ProfileHoin
+	withFontC.FontCColumns = 72
+	strict := escpos.ProfileHoin
+	strict.Strict = true
+
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		font    escpos.Font
+		want    []byte
+		columns int
+		wantErr error
+	}{
+		{"font A", escpos.ProfileHoin, escpos.FontA, []byte{escpos.ESC, 'M', 0}, 48, nil},
+		{"font B", escpos.ProfileHoin, escpos.FontB, []byte{escpos.ESC, 'M', 1}, 64, nil},
+		{"font C", withFontC, escpos.FontC, []byte{escpos.ESC, 'M', 2}, 72, nil},
+		{"font C without columns", strict, escpos.FontC, nil, 48, escpos.ErrUnsupported},
+		{"font C without columns on Hoin", escpos.ProfileHoin, escpos.FontC, []byte{escpos.ESC, 'M', 2}, 48, nil},
+		{"font C on an unknown profile", escpos.ProfileUnknown, escpos.FontC, []byte{escpos.ESC, 'M', 2}, 48, nil},
+		{"font B on an unknown profile", escpos.ProfileUnknown, escpos.FontB, []byte{escpos.ESC, 'M', 1}, 64, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.SetFont(c.font)
+			if !errors.Is(err, c.wantErr) {
+				t.Fatalf("error was %v instead of %v", err, c.wantErr)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+			if printer.Columns() != c.columns {
+				t.Fatalf("font had %d columns instead of %d", printer.Columns(), c.columns)
+			}
+		})
+	}
+
+	buf, printer := escpos.NewBufferPrinter()
+	if printer.SetFont(escpos.Font(3)) == nil {
+		t.Fatalf("font 3 should not be sent")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("font 3 sent % x", buf.Bytes())
+	}
+}
+
+func TestBatchNested(t *testing.T) {
+	sink, printer := newPrinter(escpos.ProfileHoin)
+
+	err := printer.Batch(func(p escpos.Printer) error {
+		err := p.Println("outer")
+		if err != nil {
+			return err
+		}
+
+		// The copy passed to f already holds the lock, so batching on it
+		// again doesn't wait, and only the inner batch is thrown away
+		err = p.Batch(func(p escpos.Printer) error {
+			p.Println("inner")
+			return errors.New("label failed")
+		})
+		if err == nil {
+			t.Errorf("failed inner batch did not return the error")
+		}
+
+		return p.Println("end")
+	})
+	if err != nil {
+		t.Fatalf("could not print batch: %v", err)
+	}
+	if string(sink.Bytes()) != "outer\nend\n" {
+		t.Fatalf("nested batch sent %q instead of %q", sink.Bytes(), "outer\nend\n")
+	}
+}
+
+func TestBatchOtherGoroutine(t *testing.T) {
+	sink, printer := newPrinter(escpos.ProfileHoin)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- printer.Batch(func(p escpos.Printer) error {
+			close(started)
+			<-release
+			return p.Println("batch")
+		})
+	}()
+
+	<-started
+	printed := make(chan error, 1)
+	go func() { printed <- printer.Println("after") }()
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if err := <-printed; err != nil {
+		t.Fatalf("print from another goroutine failed: %v", err)
+	}
+	if string(sink.Bytes()) != "batch\nafter\n" {
+		t.Fatalf("sent %q, the other goroutine should wait for the batch", sink.Bytes())
+	}
+}
+
+func TestPrintBarCode(t *testing.T) {
+	cases := []struct {
+		name        string
+		barcodeType escpos.BarCode
+		data        string
+		want        []byte
+	}{
+		{"EAN13", escpos.BcEAN13, "400638133393", append([]byte{escpos.GS, 'k', 2}, "400638133393\x00"...)},
+		{"CODE39", escpos.BcCODE39, "SKU-42", append([]byte{escpos.GS, 'k', 4}, "SKU-42\x00"...)},
+		{"CODABAR", escpos.BcCODABAR, "A123B", append([]byte{escpos.GS, 'k', 6}, "A123B\x00"...)},
+		{"CODE93", escpos.BcCODE93, "AB12", append([]byte{escpos.GS, 'k', 72, 4}, "AB12"...)},
+		{"CODE128 code set B", escpos.BcCODE128, "{BGo-escpos", append([]byte{escpos.GS, 'k', 73, 11}, "{BGo-escpos"...)},
+		{"CODE128 code set C", escpos.BcCODE128, "{C123456", []byte{escpos.GS, 'k', 73, 5, '{', 'C', 12, 34, 56}},
+		{"CODE128 switching code sets", escpos.BcCODE128, "{BSKU{C1234", []byte{escpos.GS, 'k', 73, 9, '{', 'B', 'S', 'K', 'U', '{', 'C', 12, 34}},
+		{"CODE128 without a code set", escpos.BcCODE128, "Order 7", append([]byte{escpos.GS, 'k', 73, 9}, "{BOrder 7"...)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintBarCode(c.barcodeType, c.data)
+			if err != nil {
+				t.Fatalf("could not print bar code: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestBarcode(t *testing.T) {
+	cases := []struct {
+		name      string
+		symbology escpos.Symbology
+		data      string
+		want      []byte
+	}{
+		// Symbologies 0 to 6 end in NUL
+		{"UPC-A", escpos.UPCA, "03600029145", append([]byte{escpos.GS, 'k', 0}, "03600029145\x00"...)},
+		{"UPC-E", escpos.UPCE, "0123456", append([]byte{escpos.GS, 'k', 1}, "0123456\x00"...)},
+		{"EAN13", escpos.EAN13, "4006381333931", append([]byte{escpos.GS, 'k', 2}, "4006381333931\x00"...)},
+		{"EAN8", escpos.EAN8, "9638507", append([]byte{escpos.GS, 'k', 3}, "9638507\x00"...)},
+		{"CODE39", escpos.CODE39, "SKU 42", append([]byte{escpos.GS, 'k', 4}, "SKU 42\x00"...)},
+		{"ITF", escpos.ITF, "1234567890", append([]byte{escpos.GS, 'k', 5}, "1234567890\x00"...)},
+		{"CODABAR", escpos.CODABAR, "A40156B", append([]byte{escpos.GS, 'k', 6}, "A40156B\x00"...)},
+		// Symbologies 72 and 73 start with the length
+		{"CODE93", escpos.CODE93, "TEST93", append([]byte{escpos.GS, 'k', 72, 6}, "TEST93"...)},
+		{"CODE128", escpos.CODE128, "{AHELLO", append([]byte{escpos.GS, 'k', 73, 7}, "{AHELLO"...)},
+		{"CODE128 60 characters", escpos.CODE128, strings.Repeat("x", 60), append([]byte{escpos.GS, 'k', 73, 62, '{', 'B'}, strings.Repeat("x", 60)...)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.Barcode(c.data, c.symbology)
+			if err != nil {
+				t.Fatalf("could not print bar code: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestBarcodeErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		symbology escpos.Symbology
+		data      string
+	}{
+		{"UPC-A too long", escpos.UPCA, "0360002914521"},
+		{"UPC-E too short", escpos.UPCE, "01234"},
+		{"EAN8 with letters", escpos.EAN8, "963850A"},
+		{"ITF too long", escpos.ITF, strings.Repeat("1", 23)},
+		{"CODE93 empty", escpos.CODE93, ""},
+		{"CODE128 61 characters", escpos.CODE128, strings.Repeat("x", 61)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.Barcode(c.data, c.symbology)
+			if err == nil {
+				t.Fatalf("bar code %q should not be sent", c.data)
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("bad bar code sent % x", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPrintBarCodeErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		barcodeType escpos.BarCode
+		data        string
+	}{
+		{"EAN13 too short", escpos.BcEAN13, "40063813339"},
+		{"EAN13 with letters", escpos.BcEAN13, "40063813339A"},
+		{"CODE39 lowercase", escpos.BcCODE39, "sku"},
+		{"CODABAR without start", escpos.BcCODABAR, "123B"},
+		{"CODE128 code set C odd digits", escpos.BcCODE128, "{C12345"},
+		{"CODE128 bad special character", escpos.BcCODE128, "{BAB{X"},
+		{"CODE128 outside code set B", escpos.BcCODE128, "tab\there"},
+		{"unknown type", escpos.BarCode(99), "123"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintBarCode(c.barcodeType, c.data)
+			if err == nil {
+				t.Fatalf("bar code %q should not be sent", c.data)
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("bad bar code sent % x", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestBarCodeSetup(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	for _, err := range []error{
+		printer.SetBarCodeHeight(80),
+		printer.SetBarCodeWidth(2),
+		printer.SetHRIPosition(escpos.HRIBelow),
+		printer.SetBarcodeHeight(120),
+		printer.SetBarcodeWidth(3),
+	} {
+		if err != nil {
+			t.Fatalf("could not set up bar code: %v", err)
+		}
+	}
+
+	want := []byte{escpos.GS, 'h', 80, escpos.GS, 'w', 2, escpos.GS, 'H', 2, escpos.GS, 'h', 120, escpos.GS, 'w', 3}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+
+	for _, err := range []error{
+		printer.SetBarCodeHeight(0),
+		printer.SetBarCodeWidth(7),
+		printer.SetBarcodeHeight(256),
+		printer.SetBarcodeWidth(1),
+		printer.SetHRIPosition(escpos.HRIPosition(4)),
+	} {
+		if err == nil {
+			t.Fatalf("out of range bar code setting should fail")
+		}
+	}
+}
+
+var errBroken = errors.New("broken connection")
+
+// brokenConn fails every read and write with errBroken
+type brokenConn struct{}
+
+func (brokenConn) Read([]byte) (int, error)  { return 0, errBroken }
+func (brokenConn) Write([]byte) (int, error) { return 0, errBroken }
+func (brokenConn) Close() error              { return nil }
+
+func TestWriteErrorNames(t *testing.T) {
+	printer := escpos.NewPrinter(brokenConn{})
+	img := image.NewGray(image.Rect(0, 0, 8, 24))
+
+	cases := []struct {
+		name string
+		run  func() error
+	}{
+		{"set bold", func() error { return printer.SetBold(true) }},
+		{"set font", func() error { return printer.SetFont(escpos.FontB) }},
+		{"set justify", func() error { return printer.Justify(escpos.CenterJustify) }},
+		{"feed", func() error { return printer.Feed(1) }},
+		{"cut", func() error { return printer.Cut() }},
+		{"print bar code", func() error { return printer.PrintBarCode(escpos.BcEAN13, "400638133393") }},
+		{"print 24 dot image", func() error { return printer.PrintImage24(img, escpos.DoubleDensity) }},
+		{"print raster image", func() error { return printer.PrintImageRaster(img, escpos.RasterNormal) }},
+		{"print QR code", func() error { return printer.QRCode("test", escpos.QRModel2, 3, escpos.QRErrorM) }},
+		{"transmit error status", func() error { _, err := printer.TransmitErrorStatus(); return err }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.run()
+			if !errors.Is(err, errBroken) {
+				t.Fatalf("error %q is not the connection error", err)
+			}
+			if !strings.Contains(err.Error(), c.name) {
+				t.Fatalf("error %q does not have the command name", err)
+			}
+		})
+	}
+}
+
+// partialConn takes at most size bytes in each write and counts the writes
+type partialConn struct {
+	bytes.Buffer
+	size   int
+	writes int
+}
+
+func (c *partialConn) Write(b []byte) (int, error) {
+	c.writes++
+	if len(b) > c.size {
+		b = b[:c.size]
+	}
+	return c.Buffer.Write(b)
+}
+
+func (c *partialConn) Close() error { return nil }
+
+func FuzzWriteRaw(f *testing.F) {
+	f.Add([]byte(nil), false)
+	f.Add([]byte("hello"), false)
+	f.Add([]byte{escpos.ESC, '@', 0, 0xFF, escpos.LF}, true)
+	f.Add(bytes.Repeat([]byte{0x1D, 'v'}, 150), true)
+
+	f.Fuzz(func(t *testing.T, data []byte, buffered bool) {
+		conn := &partialConn{size: 7}
+		printer := escpos.NewPrinter(conn)
+
+		err := printer.SetBuffered(buffered)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := printer.WriteRawN(data)
+		if err != nil {
+			t.Fatalf("could not write raw bytes: %v", err)
+		}
+		if n != len(data) {
+			t.Fatalf("wrote %d bytes instead of %d", n, len(data))
+		}
+
+		if buffered && conn.Len() != 0 {
+			t.Fatalf("buffered write sent %d bytes before the flush", conn.Len())
+		}
+
+		err = printer.WriteRaw(data)
+		if err != nil {
+			t.Fatalf("could not write raw bytes: %v", err)
+		}
+
+		err = printer.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := append(append([]byte(nil), data...), data...)
+		if !bytes.Equal(conn.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", conn.Bytes(), want)
+		}
+	})
+}
+
+func TestWriteString(t *testing.T) {
+	texts := []string{"Coffee\t3.50\n", "Crème brûlée\n", "bell \x07 and esc \x1b@\n", ""}
+
+	cases := []struct {
+		name  string
+		setup func(escpos.Printer) error
+	}{
+		{"default", func(escpos.Printer) error { return nil }},
+		{"encoding", func(p escpos.Printer) error { p.SetEncoding(charmap.CodePage850); return nil }},
+		{"sanitize", func(p escpos.Printer) error { p.SetSanitizeText(true); return nil }},
+		{"tabs", func(p escpos.Printer) error { return p.SetExpandTabs(8) }},
+		{"buffered", func(p escpos.Printer) error { return p.SetBuffered(true) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			printed, printPrinter := escpos.NewCapturePrinter()
+			written, writePrinter := escpos.NewCapturePrinter()
+
+			for _, printer := range []escpos.Printer{printPrinter, writePrinter} {
+				err := c.setup(printer)
+				if err != nil {
+					t.Fatalf("could not set up printer: %v", err)
+				}
+			}
+
+			for _, text := range texts {
+				err := printPrinter.Print(text)
+				if err != nil {
+					t.Fatalf("could not print %q: %v", text, err)
+				}
+
+				err = writePrinter.WriteString(text)
+				if err != nil {
+					t.Fatalf("could not write %q: %v", text, err)
+				}
+			}
+
+			for _, printer := range []escpos.Printer{printPrinter, writePrinter} {
+				err := printer.Flush()
+				if err != nil {
+					t.Fatalf("could not flush printer: %v", err)
+				}
+			}
+
+			if !bytes.Equal(printed.Bytes(), written.Bytes()) {
+				t.Fatalf("WriteString sent % x instead of % x like Print", written.Bytes(), printed.Bytes())
+			}
+		})
+	}
+}
+
+func TestWriteStringAllocs(t *testing.T) {
+	printer := escpos.NewPrinter(discardConn{})
+
+	printAllocs := testing.AllocsPerRun(1000, func() { printer.Print("hello\n") })
+	writeAllocs := testing.AllocsPerRun(1000, func() { printer.WriteString("hello\n") })
+	if writeAllocs >= printAllocs || writeAllocs > 0.1 {
+		t.Fatalf("WriteString made %v allocations per call and Print made %v", writeAllocs, printAllocs)
+	}
+}
+
+func BenchmarkPrint(b *testing.B) {
+	printer := escpos.NewPrinter(discardConn{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := printer.Print("hello\n")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteString(b *testing.B) {
+	printer := escpos.NewPrinter(discardConn{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := printer.WriteString("hello\n")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// imageBand is an ESC * band with the line spacing, LF and status request
+// that are sent around it
+func imageBand(mode byte, columns []byte, width int) []byte {
+	data := []byte{escpos.ESC, '3', 0, escpos.ESC, '*', mode, byte(width), byte(width >> 8)}
+	data = append(data, columns...)
+	return append(data, '\n', escpos.DLE, 0x04, 3)
+}
+
+func TestPrintImage8(t *testing.T) {
+	// Each column of a band is a byte with the top dot in the high bit, and
+	// the squares are 4 dots so a column is half black
+	full := bytes.Repeat([]byte{0xF0, 0xF0, 0xF0, 0xF0, 0x0F, 0x0F, 0x0F, 0x0F}, 2)
+	// The last band only has 4 rows so the bottom of it is padded with white
+	padded := bytes.Repeat([]byte{0xF0, 0xF0, 0xF0, 0xF0, 0, 0, 0, 0}, 2)
+
+	cases := []struct {
+		name    string
+		density escpos.Density
+		mode    byte
+	}{
+		{"single density", escpos.SingleDensity, 0},
+		{"double density", escpos.DoubleDensity, 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImage8(checkerboard(16, 12, 4), c.density)
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+
+			want := append(imageBand(c.mode, full, 16), imageBand(c.mode, padded, 16)...)
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestPrintImage8Errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		img     image.Image
+		density escpos.Density
+	}{
+		{"24 dot density", checkerboard(16, 8, 4), escpos.Density(32)},
+		// Single density dots are twice as wide, so half the dots fit
+		{"too wide", checkerboard(escpos.DefaultDotWidth/2+8, 8, 4), escpos.SingleDensity},
+		{"too wide double density", checkerboard(escpos.DefaultDotWidth+8, 8, 4), escpos.DoubleDensity},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImage8(c.img, c.density)
+			if err == nil {
+				t.Fatalf("image did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPrintImageRaster(t *testing.T) {
+	cases := []struct {
+		name string
+		mode escpos.RasterMode
+	}{
+		{"normal", escpos.RasterNormal},
+		{"double width", escpos.RasterDoubleWidth},
+		{"double height", escpos.RasterDoubleHeight},
+		{"quadruple", escpos.RasterQuadruple},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImageRaster(image.NewGray(image.Rect(0, 0, 200, 100)), c.mode)
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+
+			// 200 dots is 25 bytes across for 100 rows
+			data := sink.Bytes()
+			want := []byte{escpos.GS, 'v', '0', byte(c.mode), 25, 0, 100, 0}
+			if !bytes.HasPrefix(data, want) {
+				t.Fatalf("header was % x instead of % x", data[:len(want)], want)
+			}
+			if len(data) != len(want)+25*100+3 {
+				t.Fatalf("sent %d bytes instead of %d", len(data), len(want)+25*100+3)
+			}
+		})
+	}
+}
+
+func TestPrintImageRasterPacking(t *testing.T) {
+	// The first dot is black and the rest of the 10 dot row is white, so the
+	// row is the high bit followed by a byte of padding
+	img := image.NewGray(image.Rect(0, 0, 10, 1))
+	for x := 1; x < 10; x++ {
+		img.SetGray(x, 0, color.Gray{255})
+	}
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.PrintImageRaster(img, escpos.RasterNormal)
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+
+	want := []byte{escpos.GS, 'v', '0', 0, 2, 0, 1, 0, 0x80, 0, escpos.DLE, 0x04, 3}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+}
+
+func TestPrintImageRasterTall(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.PrintImageRaster(image.NewGray(image.Rect(0, 0, 8, 2303+10)), escpos.RasterNormal)
+	if err != nil {
+		t.Fatalf("could not print image: %v", err)
+	}
+
+	want := []string{"RASTER IMAGE mode 0 8x2303", "STATUS 3", "RASTER IMAGE mode 0 8x10", "STATUS 3"}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sent %q instead of %q", got, want)
+	}
+}
+
+func TestPrintImageRasterErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		img  image.Image
+		mode escpos.RasterMode
+	}{
+		{"unknown mode", image.NewGray(image.Rect(0, 0, 8, 8)), escpos.RasterMode(4)},
+		{"too wide", image.NewGray(image.Rect(0, 0, escpos.DefaultDotWidth+8, 8)), escpos.RasterNormal},
+		// Double width dots take twice the paper
+		{"too wide double width", image.NewGray(image.Rect(0, 0, escpos.DefaultDotWidth/2+8, 8)), escpos.RasterDoubleWidth},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImageRaster(c.img, c.mode)
+			if err == nil {
+				t.Fatalf("image did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+// cancelConn cancels a context after it has answered a number of status
+// requests, which PrintImage24 sends after each band
+type cancelConn struct {
+	escpos.CaptureSink
+	cancel context.CancelFunc
+	after  int
+}
+
+func (c *cancelConn) Read(b []byte) (int, error) {
+	c.after--
+	if c.after == 0 {
+		c.cancel()
+	}
+	return c.CaptureSink.Read(b)
+}
+
+func TestPrintImage24Ctx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn := &cancelConn{cancel: cancel, after: 2}
+	printer := escpos.NewPrinter(conn)
+
+	// 5 bands of 24 rows
+	err := printer.PrintImage24Ctx(ctx, image.NewGray(image.Rect(0, 0, 8, 120)), escpos.DoubleDensity)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("print returned %v instead of %v", err, context.Canceled)
+	}
+
+	data := conn.Bytes()
+	if bands := bytes.Count(data, []byte{escpos.ESC, '*'}); bands != 2 {
+		t.Fatalf("sent %d bands instead of 2", bands)
+	}
+
+	// The status request of the last band is followed by an LF
+	if !bytes.HasSuffix(data, []byte{escpos.DLE, 0x04, 3, '\n'}) {
+		t.Fatalf("sent % x without an LF at the end", data)
+	}
+}
+
+func TestPrintImage24CtxDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.PrintImage24Ctx(ctx, image.NewGray(image.Rect(0, 0, 8, 24)), escpos.DoubleDensity)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("print returned %v instead of %v", err, context.Canceled)
+	}
+
+	if !bytes.Equal(sink.Bytes(), []byte{'\n'}) {
+		t.Fatalf("sent % x instead of an LF", sink.Bytes())
+	}
+
+	// Bad arguments fail before the context is checked
+	sink.Reset()
+	err = printer.PrintImage24Ctx(ctx, image.NewGray(image.Rect(0, 0, 8, 24)), escpos.Density(2))
+	if err == nil || errors.Is(err, context.Canceled) {
+		t.Fatalf("density 2 returned %v", err)
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x after failing", sink.Bytes())
+	}
+}
+
+func TestNewIpPrinterContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer listener.Close()
+
+	printer, err := escpos.NewIpPrinterContext(context.Background(), listener.Addr().String())
+	if err != nil {
+		t.Fatalf("could not connect: %v", err)
+	}
+	defer printer.Close()
+}
+
+func TestNewIpPrinterContextClosedPort(t *testing.T) {
+	// Take a free port and close it so nothing is listening on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err = escpos.NewIpPrinterContext(ctx, addr)
+	if err == nil {
+		t.Fatalf("connected to a closed port")
+	}
+	if !strings.Contains(err.Error(), addr) {
+		t.Fatalf("error %q does not have the address %s", err, addr)
+	}
+	if time.Since(start) >= time.Second {
+		t.Fatalf("took %v to fail", time.Since(start))
+	}
+}
+
+func TestNewIpPrinterContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := escpos.NewIpPrinterContext(ctx, "127.0.0.1:9100")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("returned %v instead of context.Canceled", err)
+	}
+}
+
+func TestOpenCashDrawer(t *testing.T) {
+	cases := []struct {
+		name    string
+		pin     escpos.DrawerPin
+		onTime  time.Duration
+		offTime time.Duration
+		want    []byte
+	}{
+		{"pin 2", escpos.DrawerPin2, 100 * time.Millisecond, 100 * time.Millisecond, []byte{escpos.ESC, 'p', 0, 50, 50}},
+		{"pin 5", escpos.DrawerPin5, 50 * time.Millisecond, 200 * time.Millisecond, []byte{escpos.ESC, 'p', 1, 25, 100}},
+		{"longest pulse", escpos.DrawerPin2, 510 * time.Millisecond, 0, []byte{escpos.ESC, 'p', 0, 255, 0}},
+		// Times are rounded down to the 2ms units
+		{"odd time", escpos.DrawerPin2, 5 * time.Millisecond, time.Millisecond, []byte{escpos.ESC, 'p', 0, 2, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.OpenCashDrawer(c.pin, c.onTime, c.offTime)
+			if err != nil {
+				t.Fatalf("could not open cash drawer: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestOpenCashDrawerErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		pin     escpos.DrawerPin
+		onTime  time.Duration
+		offTime time.Duration
+	}{
+		{"unknown pin", escpos.DrawerPin(2), 100 * time.Millisecond, 100 * time.Millisecond},
+		{"on time too long", escpos.DrawerPin2, 512 * time.Millisecond, 100 * time.Millisecond},
+		{"off time too long", escpos.DrawerPin2, 100 * time.Millisecond, time.Second},
+		{"negative time", escpos.DrawerPin2, -2 * time.Millisecond, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.OpenCashDrawer(c.pin, c.onTime, c.offTime)
+			if err == nil {
+				t.Fatalf("opening the cash drawer did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestCut(t *testing.T) {
+	cases := []struct {
+		name string
+		cut  func(escpos.Printer) error
+		want []byte
+	}{
+		{"full", escpos.Printer.Cut, []byte{escpos.GS, 'V', 0}},
+		{"partial", escpos.Printer.PartialCut, []byte{escpos.GS, 'V', 1}},
+		{"feed then full", func(p escpos.Printer) error { return p.CutWith(escpos.CutFull, 0) }, []byte{escpos.GS, 'V', 65, 0}},
+		{"feed then partial", func(p escpos.Printer) error { return p.CutWith(escpos.CutPartial, 255) }, []byte{escpos.GS, 'V', 66, 255}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.cut(printer)
+			if err != nil {
+				t.Fatalf("could not cut: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestCutErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		cut     func(escpos.Printer) error
+	}{
+		{"unknown mode", escpos.ProfileHoin, func(p escpos.Printer) error { return p.CutWith(escpos.CutMode(2), 0) }},
+		{"feed too far", escpos.ProfileHoin, func(p escpos.Printer) error { return p.CutWith(escpos.CutFull, 256) }},
+		{"negative feed", escpos.ProfileHoin, func(p escpos.Printer) error { return p.CutWith(escpos.CutPartial, -1) }},
+		{"full without a cutter", escpos.ProfileGeneric58, escpos.Printer.Cut},
+		{"partial without a cutter", escpos.ProfileGeneric58, escpos.Printer.PartialCut},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := c.cut(printer)
+			if err == nil {
+				t.Fatalf("cut did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestSetDoubleStrike(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	// Double-strike is its own command, so it is combined with bold instead
+	// of changing it
+	for _, step := range []func() error{
+		func() error { return printer.SetBold(true) },
+		func() error { return printer.SetDoubleStrike(true) },
+		func() error { return printer.SetDoubleStrike(false) },
+	} {
+		err := step()
+		if err != nil {
+			t.Fatalf("could not set the style: %v", err)
+		}
+	}
+
+	want := []byte{escpos.ESC, 'E', 1, escpos.ESC, 'G', 1, escpos.ESC, 'G', 0}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+	if !printer.SnapshotState().Bold {
+		t.Fatalf("turning double-strike off turned bold off")
+	}
+}
+
+func TestSetDoubleStrikeError(t *testing.T) {
+	err := escpos.NewPrinter(brokenConn{}).SetDoubleStrike(true)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("error %v is not the connection error", err)
+	}
+	if !strings.Contains(err.Error(), "double-strike to true") {
+		t.Fatalf("error %q does not say what was set", err)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	conn, other := net.Pipe()
+	t.Cleanup(func() { conn.Close(); other.Close() })
+
+	printer := escpos.NewPrinter(conn)
+	printer.SetWriteTimeout(20 * time.Millisecond)
+
+	// Nothing reads the other end, so the write blocks like a printer with a
+	// full buffer
+	start := time.Now()
+	err := printer.Print("stuck")
+	if !errors.Is(err, escpos.ErrTimeout) {
+		t.Fatalf("write returned %v instead of ErrTimeout", err)
+	}
+	if time.Since(start) >= time.Second {
+		t.Fatalf("took %v to time out", time.Since(start))
+	}
+
+	// The deadline of the failed write is cleared, so the next write gets its
+	// own timeout and finishes once the other end reads
+	go func() {
+		time.Sleep(40 * time.Millisecond)
+		io.ReadAll(other)
+	}()
+	printer.SetWriteTimeout(time.Second)
+	err = printer.Print("sent")
+	if err != nil {
+		t.Fatalf("could not write once the other end reads: %v", err)
+	}
+}
+
+func TestWriteTimeoutWithoutDeadline(t *testing.T) {
+	// Connections without SetWriteDeadline ignore the timeout
+	sink, printer := escpos.NewCapturePrinter()
+	printer.SetWriteTimeout(time.Nanosecond)
+
+	err := printer.Print("sent")
+	if err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+	if string(sink.Bytes()) != "sent" {
+		t.Fatalf("sent %q instead of \"sent\"", sink.Bytes())
+	}
+}
+
+// limitConn takes limit bytes in total and then fails with errBroken
+type limitConn struct {
+	bytes.Buffer
+	limit int
+}
+
+func (c *limitConn) Write(b []byte) (int, error) {
+	if c.Len()+len(b) > c.limit {
+		n, _ := c.Buffer.Write(b[:c.limit-c.Len()])
+		return n, errBroken
+	}
+	return c.Buffer.Write(b)
+}
+
+func (c *limitConn) Close() error { return nil }
+
+func TestPrinterWriter(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	text := strings.Repeat("streamed line\n", 100)
+	n, err := io.Copy(printer, bytes.NewReader([]byte(text)))
+	if err != nil {
+		t.Fatalf("could not copy: %v", err)
+	}
+	if n != int64(len(text)) {
+		t.Fatalf("copied %d bytes instead of %d", n, len(text))
+	}
+
+	_, err = fmt.Fprintf(printer, "total %d\n", 42)
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	if got := string(sink.Bytes()); got != text+"total 42\n" {
+		t.Fatalf("printer got %q", got)
+	}
+}
+
+func TestPrinterWriterShortWrites(t *testing.T) {
+	// Short writes without an error are sent again until everything is
+	// written
+	conn := &partialConn{size: 3}
+	n, err := escpos.NewPrinter(conn).Write([]byte("hello world"))
+	if err != nil || n != len("hello world") {
+		t.Fatalf("wrote %d, %v instead of %d, nil", n, err, len("hello world"))
+	}
+	if conn.String() != "hello world" {
+		t.Fatalf("sent %q instead of \"hello world\"", conn.String())
+	}
+}
+
+func TestPrinterWriterError(t *testing.T) {
+	conn := &limitConn{limit: 4}
+	n, err := escpos.NewPrinter(conn).Write([]byte("hello world"))
+	if n != 4 {
+		t.Fatalf("wrote %d bytes instead of the 4 that were taken", n)
+	}
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("error was %v instead of the connection error", err)
+	}
+}
+
+func TestPrintPosition(t *testing.T) {
+	cases := []struct {
+		name string
+		set  func(escpos.Printer) error
+		want []byte
+	}{
+		{"absolute", func(p escpos.Printer) error { return p.SetAbsolutePosition(300) }, []byte{escpos.ESC, '$', 0x2C, 0x01}},
+		// The default profile is 576 dots wide
+		{"absolute end of line", func(p escpos.Printer) error { return p.SetAbsolutePosition(p.MaxWidthDots()) }, []byte{escpos.ESC, '$', 0x40, 0x02}},
+		{"relative right", func(p escpos.Printer) error { return p.SetRelativePosition(260) }, []byte{escpos.ESC, '\\', 0x04, 0x01}},
+		// Negative moves are two's complement
+		{"relative left", func(p escpos.Printer) error { return p.SetRelativePosition(-2) }, []byte{escpos.ESC, '\\', 0xFE, 0xFF}},
+		{"relative most left", func(p escpos.Printer) error { return p.SetRelativePosition(-32768) }, []byte{escpos.ESC, '\\', 0x00, 0x80}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.set(printer)
+			if err != nil {
+				t.Fatalf("could not set position: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestPrintPositionErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		set  func(escpos.Printer) error
+	}{
+		{"absolute negative", func(p escpos.Printer) error { return p.SetAbsolutePosition(-1) }},
+		{"absolute past the paper", func(p escpos.Printer) error { return p.SetAbsolutePosition(p.MaxWidthDots() + 1) }},
+		{"relative too far right", func(p escpos.Printer) error { return p.SetRelativePosition(32768) }},
+		{"relative too far left", func(p escpos.Printer) error { return p.SetRelativePosition(-32769) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.set(printer)
+			if err == nil {
+				t.Fatalf("position did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestSetPrintMode(t *testing.T) {
+	cases := []struct {
+		name string
+		mode escpos.PrintMode
+		want byte
+	}{
+		{"off", 0, 0x00},
+		{"bold double height underline", escpos.Emphasized | escpos.DoubleHeight | escpos.Underline, 0x98},
+		{"font B double width", escpos.ThinFont | escpos.DoubleWidth, 0x21},
+		{"everything", escpos.ThinFont | escpos.Bold | escpos.DoubleHeight | escpos.DoubleWidth | escpos.Underline, 0xB9},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetPrintMode(c.mode)
+			if err != nil {
+				t.Fatalf("could not set print mode: %v", err)
+			}
+
+			want := []byte{escpos.ESC, '!', c.want}
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSetPrintModeState(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+
+	err := printer.SetPrintMode(escpos.ThinFont | escpos.Emphasized | escpos.Underline)
+	if err != nil {
+		t.Fatalf("could not set print mode: %v", err)
+	}
+
+	state := printer.SnapshotState()
+	if state.Font != escpos.FontB || !state.Bold || state.Underline != escpos.UnderlineThin {
+		t.Fatalf("state was %+v after the print mode", state)
+	}
+}
+
+func TestSetPrintModeErrors(t *testing.T) {
+	for _, mode := range []escpos.PrintMode{1 << 1, 1 << 2, 1 << 6, escpos.Bold | 1<<8} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetPrintMode(mode)
+		if err == nil {
+			t.Fatalf("print mode %08b did not fail", mode)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+// echoConn captures what is written and answers each DLE EOT n status
+// request with n, so a status read that got another request's answer can be
+// found
+type echoConn struct {
+	escpos.CaptureSink
+	answers []byte
+}
+
+func (c *echoConn) Write(b []byte) (int, error) {
+	if len(b) == 3 && b[0] == escpos.DLE && b[1] == 0x04 {
+		c.answers = append(c.answers, b[2])
+	}
+	return c.CaptureSink.Write(b)
+}
+
+func (c *echoConn) Read(b []byte) (int, error) {
+	if len(c.answers) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.answers)
+	c.answers = c.answers[n:]
+	return n, nil
+}
+
+func TestConcurrentPrinter(t *testing.T) {
+	conn := &echoConn{}
+	printer := escpos.NewPrinter(conn)
+	img := checkerboard(64, 48, 4)
+
+	const workers, rounds = 16, 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				n := escpos.StatusType(r%4 + 1)
+				status, err := printer.RealtimeStatus(n)
+				if err == nil && status.Raw != byte(n) {
+					err = fmt.Errorf("status %d got the answer %d", n, status.Raw)
+				}
+				if err == nil {
+					err = printer.SetBold(r%2 == 0)
+				}
+				if err == nil {
+					err = printer.Println(fmt.Sprintf("worker %02d round %02d", w, r))
+				}
+				if err == nil && r%5 == 0 {
+					err = printer.PrintImage24(img, escpos.DoubleDensity)
+				}
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	// Every command is whole, so the stream decodes without RAW bytes and
+	// each line of text is printed in one piece
+	var lines int
+	for _, cmd := range conn.Commands() {
+		switch {
+		case strings.HasPrefix(cmd, "RAW"):
+			t.Fatalf("commands were mixed together at %s", cmd)
+		case strings.HasPrefix(cmd, "PRINT"):
+			if len(cmd) != len(`PRINT "worker 00 round 00"`) {
+				t.Fatalf("text was split by another command: %s", cmd)
+			}
+			lines++
+		case strings.HasPrefix(cmd, "IMAGE") && cmd != "IMAGE mode 33 width 64":
+			t.Fatalf("image band was mixed with other commands: %s", cmd)
+		}
+	}
+	if lines != workers*rounds {
+		t.Fatalf("printed %d lines instead of %d", lines, workers*rounds)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	cases := []struct {
+		name string
+		mode escpos.SelfTestMode
+		m    byte
+	}{
+		{"hex dump", escpos.SelfTestHexDump, '1'},
+		{"status", escpos.SelfTestStatus, '2'},
+		{"rolling pattern", escpos.SelfTestRollingPattern, '3'},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SelfTest(c.mode)
+			if err != nil {
+				t.Fatalf("could not run self test: %v", err)
+			}
+
+			want := []byte{escpos.GS, '(', 'A', 2, 0, '2', c.m}
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSelfTestErrors(t *testing.T) {
+	for _, mode := range []escpos.SelfTestMode{0, 4} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SelfTest(mode)
+		if err == nil {
+			t.Fatalf("self test mode %d did not fail", mode)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+func TestPrintArea(t *testing.T) {
+	cases := []struct {
+		name string
+		set  func(escpos.Printer) error
+		want []byte
+	}{
+		{"no margin", func(p escpos.Printer) error { return p.SetLeftMargin(0) }, []byte{escpos.GS, 'L', 0, 0}},
+		{"margin", func(p escpos.Printer) error { return p.SetLeftMargin(60) }, []byte{escpos.GS, 'L', 0x3C, 0}},
+		{"margin high byte", func(p escpos.Printer) error { return p.SetLeftMargin(300) }, []byte{escpos.GS, 'L', 0x2C, 0x01}},
+		// The default profile is 576 dots wide
+		{"widest margin", func(p escpos.Printer) error { return p.SetLeftMargin(575) }, []byte{escpos.GS, 'L', 0x3F, 0x02}},
+		{"narrowest width", func(p escpos.Printer) error { return p.SetPrintAreaWidth(1) }, []byte{escpos.GS, 'W', 1, 0}},
+		{"width", func(p escpos.Printer) error { return p.SetPrintAreaWidth(512) }, []byte{escpos.GS, 'W', 0x00, 0x02}},
+		{"widest width", func(p escpos.Printer) error { return p.SetPrintAreaWidth(576) }, []byte{escpos.GS, 'W', 0x40, 0x02}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.set(printer)
+			if err != nil {
+				t.Fatalf("could not set print area: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestPrintAreaErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		set     func(escpos.Printer) error
+	}{
+		{"negative margin", escpos.ProfileHoin, func(p escpos.Printer) error { return p.SetLeftMargin(-1) }},
+		{"margin past the paper", escpos.ProfileHoin, func(p escpos.Printer) error { return p.SetLeftMargin(576) }},
+		{"margin past 58mm paper", escpos.ProfileGeneric58, func(p escpos.Printer) error { return p.SetLeftMargin(384) }},
+		{"no width", escpos.ProfileHoin, func(p escpos.Printer) error { return p.SetPrintAreaWidth(0) }},
+		{"width past the paper", escpos.ProfileHoin, func(p escpos.Printer) error { return p.SetPrintAreaWidth(577) }},
+		{"width past 58mm paper", escpos.ProfileGeneric58, func(p escpos.Printer) error { return p.SetPrintAreaWidth(385) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := c.set(printer)
+			if err == nil {
+				t.Fatalf("print area did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestReverseFeed(t *testing.T) {
+	cases := []struct {
+		name string
+		feed func(escpos.Printer) error
+		want []byte
+	}{
+		{"no lines", func(p escpos.Printer) error { return p.ReverseFeed(0) }, []byte{escpos.ESC, 'e', 0}},
+		{"lines", func(p escpos.Printer) error { return p.ReverseFeed(2) }, []byte{escpos.ESC, 'e', 2}},
+		{"most lines", func(p escpos.Printer) error { return p.ReverseFeed(255) }, []byte{escpos.ESC, 'e', 255}},
+		{"no units", func(p escpos.Printer) error { return p.ReverseFeedUnits(0) }, []byte{escpos.ESC, 'K', 0}},
+		{"units", func(p escpos.Printer) error { return p.ReverseFeedUnits(48) }, []byte{escpos.ESC, 'K', 48}},
+		{"most units", func(p escpos.Printer) error { return p.ReverseFeedUnits(255) }, []byte{escpos.ESC, 'K', 255}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.feed(printer)
+			if err != nil {
+				t.Fatalf("could not reverse feed: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestReverseFeedErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		feed func(escpos.Printer) error
+	}{
+		{"negative lines", func(p escpos.Printer) error { return p.ReverseFeed(-1) }},
+		{"too many lines", func(p escpos.Printer) error { return p.ReverseFeed(256) }},
+		{"negative units", func(p escpos.Printer) error { return p.ReverseFeedUnits(-1) }},
+		{"too many units", func(p escpos.Printer) error { return p.ReverseFeedUnits(256) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.feed(printer)
+			if err == nil {
+				t.Fatalf("reverse feed did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestBuildCommand(t *testing.T) {
+	got := escpos.BuildCommand(escpos.ESC, '!', 0b0011_1000)
+	want := []byte{escpos.ESC, '!', 0b0011_1000}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("built % x instead of % x", got, want)
+	}
+
+	got = escpos.BuildCommand(escpos.GS, 'V')
+	want = []byte{escpos.GS, 'V'}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("built % x instead of % x", got, want)
+	}
+}
+
+func TestSendCommand(t *testing.T) {
+	cases := []struct {
+		name   string
+		prefix byte
+		cmd    byte
+		args   []byte
+	}{
+		{"ESC", escpos.ESC, '!', []byte{0b0011_1000}},
+		{"GS", escpos.GS, 'B', []byte{1}},
+		{"FS", escpos.FS, '.', nil},
+		{"DLE", escpos.DLE, 0x14, []byte{1, 0, 1}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SendCommand(c.prefix, c.cmd, c.args...)
+			if err != nil {
+				t.Fatalf("could not send command: %v", err)
+			}
+
+			want := escpos.BuildCommand(c.prefix, c.cmd, c.args...)
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSendCommandErrors(t *testing.T) {
+	for _, prefix := range []byte{'X', 0, escpos.LF} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SendCommand(prefix, '!', 0)
+		if err == nil {
+			t.Fatalf("prefix %#02x did not fail", prefix)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+
+	err := escpos.NewPrinter(brokenConn{}).SendCommand(escpos.ESC, '!', 0)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+func TestAppendCheckDigit(t *testing.T) {
+	cases := []struct {
+		barcodeType escpos.BarCode
+		data, want  string
+	}{
+		{escpos.BcEAN13, "400638133393", "4006381333931"},
+		{escpos.BcUPCA, "03600029145", "036000291452"},
+		{escpos.BcEAN8, "9638507", "96385074"},
+		// A sum that is already a multiple of 10 has a check digit of 0
+		{escpos.BcEAN8, "0000000", "00000000"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.data, func(t *testing.T) {
+			got, err := escpos.AppendCheckDigit(c.data, c.barcodeType)
+			if err != nil {
+				t.Fatalf("could not add check digit: %v", err)
+			}
+			if got != c.want {
+				t.Fatalf("check digit of %s was %s instead of %s", c.data, got, c.want)
+			}
+		})
+	}
+}
+
+func TestAppendCheckDigitErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		barcodeType escpos.BarCode
+		data        string
+	}{
+		{"no check digit", escpos.BcCODE39, "12345"},
+		{"too short", escpos.BcEAN13, "40063813339"},
+		{"already has the check digit", escpos.BcEAN13, "4006381333931"},
+		{"letters", escpos.BcUPCA, "0360002914A"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := escpos.AppendCheckDigit(c.data, c.barcodeType)
+			if err == nil {
+				t.Fatalf("%s did not fail", c.data)
+			}
+		})
+	}
+}
+
+func TestSetLineSpacingMM(t *testing.T) {
+	cases := []struct {
+		name      string
+		dotsPerMM float64
+		mm        float64
+		want      byte
+	}{
+		{"none", 8, 0, 0},
+		{"8 dots per mm", 8, 2, 16},
+		{"rounds up", 8, 0.07, 1},
+		{"rounds down", 8, 0.06, 0},
+		{"most", 8, 31.9, 255},
+		{"12 dots per mm", 12, 2, 24},
+		{"fraction dots per mm", 7.5, 4, 30},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetDotsPerMM(c.dotsPerMM)
+			if err != nil {
+				t.Fatalf("could not set dots per mm: %v", err)
+			}
+
+			err = printer.SetLineSpacingMM(c.mm)
+			if err != nil {
+				t.Fatalf("could not set line spacing: %v", err)
+			}
+
+			want := []byte{escpos.ESC, '3', c.want}
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSetLineSpacingMMErrors(t *testing.T) {
+	for _, mm := range []float64{-1, 40, math.NaN(), math.Inf(1)} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetLineSpacingMM(mm)
+		if err == nil {
+			t.Fatalf("line spacing of %vmm did not fail", mm)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+
+	_, printer := escpos.NewCapturePrinter()
+	for _, dots := range []float64{0, -8} {
+		if printer.SetDotsPerMM(dots) == nil {
+			t.Fatalf("%v dots per mm did not fail", dots)
+		}
+	}
+}
+
+func TestSetTabStops(t *testing.T) {
+	cases := []struct {
+		name      string
+		positions []int
+		want      []byte
+	}{
+		{"clear", nil, []byte{escpos.ESC, 'D', 0}},
+		{"one", []int{8}, []byte{escpos.ESC, 'D', 8, 0}},
+		{"uneven", []int{8, 20, 34}, []byte{escpos.ESC, 'D', 8, 20, 34, 0}},
+		{"last position", []int{1, 255}, []byte{escpos.ESC, 'D', 1, 255, 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetTabStops(c.positions...)
+			if err != nil {
+				t.Fatalf("could not set tab stops: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestSetTabStopsErrors(t *testing.T) {
+	many := make([]int, 33)
+	for i := range many {
+		many[i] = i + 1
+	}
+
+	cases := []struct {
+		name      string
+		positions []int
+	}{
+		{"out of order", []int{20, 8}},
+		{"same position", []int{8, 8}},
+		{"position 0", []int{0, 8}},
+		{"position 256", []int{8, 256}},
+		{"33 positions", many},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetTabStops(c.positions...)
+			if err == nil {
+				t.Fatalf("tab stops did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestFeedAndCut(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		want    []byte
+	}{
+		{"feed in the cut command", escpos.ProfileHoin, []byte{escpos.ESC, 'd', 3, escpos.GS, 'V', 66, 0}},
+		{"feed before the cut", escpos.Profile{SupportsCut: true}, []byte{escpos.ESC, 'd', 3, escpos.GS, 'V', 1}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.FeedAndCut(3, escpos.CutPartial)
+			if err != nil {
+				t.Fatalf("could not feed and cut: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestFeedAndCutErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		lines   int
+		mode    escpos.CutMode
+	}{
+		{"negative lines", escpos.ProfileHoin, -1, escpos.CutFull},
+		{"too many lines", escpos.ProfileHoin, 256, escpos.CutFull},
+		{"cut mode 2", escpos.ProfileHoin, 3, escpos.CutMode(2)},
+		{"no cutter", escpos.ProfileGeneric58, 3, escpos.CutFull},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.FeedAndCut(c.lines, c.mode)
+			if err == nil {
+				t.Fatalf("feed and cut did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPrintBlackBar(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		height  int
+		columns byte
+	}{
+		// 576 dots is 72 bytes across and 384 dots is 48 bytes
+		{"80mm", escpos.ProfileHoin, 10, 72},
+		{"58mm", escpos.ProfileGeneric58, 24, 48},
+		{"one row", escpos.ProfileHoin, 1, 72},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.PrintBlackBar(c.height)
+			if err != nil {
+				t.Fatalf("could not print black bar: %v", err)
+			}
+
+			want := []byte{escpos.GS, 'v', '0', 0, c.columns, 0, byte(c.height), 0}
+			want = append(want, bytes.Repeat([]byte{0xFF}, int(c.columns)*c.height)...)
+			want = append(want, escpos.DLE, 0x04, 3)
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestPrintBlackBarErrors(t *testing.T) {
+	for _, height := range []int{0, -1, 2304} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.PrintBlackBar(height)
+		if err == nil {
+			t.Fatalf("height of %d did not fail", height)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+func TestMotionUnits(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.SetMotionUnits(180, 180)
+	if err != nil {
+		t.Fatalf("could not set motion units: %v", err)
+	}
+
+	want := []byte{escpos.GS, 'P', 180, 180}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+
+	x, y := printer.MotionUnits()
+	if x != 180 || y != 180 {
+		t.Fatalf("motion units were %d, %d instead of 180, 180", x, y)
+	}
+
+	// 254 units an inch is 10 units a mm for the mm helpers
+	sink.Reset()
+	printer.SetMotionUnits(0, 254)
+	printer.SetLineSpacingMM(3)
+
+	want = []byte{escpos.GS, 'P', 0, 254, escpos.ESC, '3', 30}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+
+	printer.Initialize()
+	if x, y := printer.MotionUnits(); x != 0 || y != 0 {
+		t.Fatalf("motion units were %d, %d after initialize", x, y)
+	}
+}
+
+func TestMotionUnitsErrors(t *testing.T) {
+	for _, units := range [][2]int{{-1, 0}, {256, 0}, {0, -1}, {0, 256}} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetMotionUnits(units[0], units[1])
+		if err == nil {
+			t.Fatalf("motion units %v did not fail", units)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+		if x, y := printer.MotionUnits(); x != 0 || y != 0 {
+			t.Fatalf("motion units were set to %d, %d after failing", x, y)
+		}
+	}
+}
+
+func TestImageMaxWidth(t *testing.T) {
+	width := escpos.ProfileHoin.DotWidth
+	tooWide := image.NewGray(image.Rect(0, 0, width+1, 24))
+	exact := image.NewGray(image.Rect(0, 0, width, 24))
+
+	cases := []struct {
+		name  string
+		print func(escpos.Printer, image.Image) error
+	}{
+		{"24 dot image", func(p escpos.Printer, img image.Image) error { return p.PrintImage24(img, escpos.DoubleDensity) }},
+		{"8 dot image", func(p escpos.Printer, img image.Image) error { return p.PrintImage8(img, escpos.DoubleDensity) }},
+		{"raster image", func(p escpos.Printer, img image.Image) error { return p.PrintImageRaster(img, escpos.RasterNormal) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, printer := newPrinter(escpos.ProfileHoin)
+			if printer.MaxWidthDots() != width {
+				t.Fatalf("max width was %d instead of the profile width of %d", printer.MaxWidthDots(), width)
+			}
+
+			err := c.print(printer, tooWide)
+			if !errors.Is(err, escpos.ErrImageTooWide) {
+				t.Fatalf("image %d dots wide returned %v instead of ErrImageTooWide", width+1, err)
+			}
+
+			err = c.print(printer, exact)
+			if err != nil {
+				t.Fatalf("image at the max width did not print: %v", err)
+			}
+		})
+	}
+}
+
+func TestImageMaxWidthProfile(t *testing.T) {
+	sink, printer := newPrinter(escpos.ProfileGeneric58)
+	if printer.MaxWidthDots() != 384 {
+		t.Fatalf("max width was %d instead of 384", printer.MaxWidthDots())
+	}
+
+	// The error says how wide the image is and how wide it can be
+	err := printer.PrintImageRaster(image.NewGray(image.Rect(0, 0, 392, 8)), escpos.RasterNormal)
+	if !errors.Is(err, escpos.ErrImageTooWide) || !strings.Contains(err.Error(), "392") || !strings.Contains(err.Error(), "384") {
+		t.Fatalf("image 392 dots wide returned %v", err)
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x after failing", sink.Bytes())
+	}
+}
+
+func TestSetPanelButtons(t *testing.T) {
+	// n is 1 to turn the buttons off and 0 to turn them on
+	for _, c := range []struct {
+		enabled bool
+		want    byte
+	}{
+		{false, 1},
+		{true, 0},
+	} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetPanelButtons(c.enabled)
+		if err != nil {
+			t.Fatalf("could not set panel buttons: %v", err)
+		}
+
+		want := []byte{escpos.ESC, 'c', '5', c.want}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+		}
+	}
+
+	err := escpos.NewPrinter(brokenConn{}).SetPanelButtons(true)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+func TestSelectPeripheral(t *testing.T) {
+	for _, device := range []escpos.Peripheral{escpos.PeripheralPrinter, escpos.PeripheralDisplay} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SelectPeripheral(device)
+		if err != nil {
+			t.Fatalf("could not select peripheral %d: %v", device, err)
+		}
+
+		want := []byte{escpos.ESC, '=', byte(device)}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+		}
+	}
+
+	for _, device := range []escpos.Peripheral{0, 3} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SelectPeripheral(device)
+		if err == nil {
+			t.Fatalf("peripheral %d did not fail", device)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	// ESC p would open the cash drawer and GS V would cut the paper
+	text := "Note: \x1bp\x00\x19\xfa leave at door\x1dV\x00\tthanks\r\n"
+
+	cases := []struct {
+		name     string
+		sanitize bool
+		want     string
+	}{
+		{"off", false, text},
+		{"on", true, "Note: p\xfa leave at doorV\tthanks\r\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+			printer.SetSanitizeText(c.sanitize)
+
+			err := printer.Printf("%s", text)
+			if err != nil {
+				t.Fatalf("could not print text: %v", err)
+			}
+			if string(sink.Bytes()) != c.want {
+				t.Fatalf("printed %q instead of %q", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestFeedMM(t *testing.T) {
+	cases := []struct {
+		mm   float64
+		want []byte
+	}{
+		{10, []byte{escpos.ESC, 'J', 80}},
+		{0, nil},
+		{40, []byte{escpos.ESC, 'J', 255, escpos.ESC, 'J', 65}},
+		{63.75, []byte{escpos.ESC, 'J', 255, escpos.ESC, 'J', 255}},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%vmm", c.mm), func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetDotsPerMM(8)
+			if err != nil {
+				t.Fatalf("could not set dots per mm: %v", err)
+			}
+
+			err = printer.FeedMM(c.mm)
+			if err != nil {
+				t.Fatalf("could not feed: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestFeedMMErrors(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.FeedMM(-1)
+	if err == nil {
+		t.Fatalf("negative feed did not fail")
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x after failing", sink.Bytes())
+	}
+
+	err = escpos.NewPrinter(brokenConn{}).FeedMM(10)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+// chunkConn records the height of each raster block sent and every status
+// request, and answers the status requests with no errors
+type chunkConn struct {
+	events []string
+	reply  int
+}
+
+func (c *chunkConn) Write(b []byte) (int, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte{escpos.GS, 'v', '0'}):
+		c.events = append(c.events, fmt.Sprintf("block %d", int(b[6])|int(b[7])<<8))
+	case bytes.Equal(b, []byte{escpos.DLE, 0x04, 3}):
+		c.events = append(c.events, "status")
+		c.reply++
+	}
+	return len(b), nil
+}
+
+func (c *chunkConn) Read(b []byte) (int, error) {
+	if c.reply == 0 {
+		return 0, io.EOF
+	}
+	c.reply--
+	b[0] = 0x12
+	return 1, nil
+}
+
+func (c *chunkConn) Close() error { return nil }
+
+func TestImageChunkBands(t *testing.T) {
+	cases := []struct {
+		name  string
+		bands int
+		want  []string
+	}{
+		{"one block", 0, []string{"block 120", "status"}},
+		{"2 bands", 2, []string{"block 48", "status", "block 48", "status", "block 24", "status"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conn := &chunkConn{}
+			printer := escpos.NewPrinter(conn)
+
+			err := printer.SetImageChunkBands(c.bands)
+			if err != nil {
+				t.Fatalf("could not set chunk bands: %v", err)
+			}
+
+			err = printer.PrintImageRaster(checkerboard(64, 24*5, 8), escpos.RasterNormal)
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+			if !reflect.DeepEqual(conn.events, c.want) {
+				t.Fatalf("image sent %v instead of %v", conn.events, c.want)
+			}
+		})
+	}
+}
+
+func TestImageChunkBandsErrors(t *testing.T) {
+	for _, bands := range []int{-1, 96} {
+		err := escpos.NewPrinter(&chunkConn{}).SetImageChunkBands(bands)
+		if err == nil {
+			t.Fatalf("%d bands did not fail", bands)
+		}
+	}
+}
+
+func TestClosePolicy(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy escpos.ClosePolicy
+		want   []byte
+	}{
+		{"nothing", escpos.ClosePolicy{}, []byte("end")},
+		{"feed and cut", escpos.ClosePolicy{FeedLines: 4, Cut: true, CutMode: escpos.CutPartial}, []byte{'e', 'n', 'd', escpos.ESC, 'd', 4, escpos.GS, 'V', 66, 0}},
+		{"feed only", escpos.ClosePolicy{FeedLines: 2}, []byte{'e', 'n', 'd', escpos.ESC, 'd', 2}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink := &escpos.CaptureSink{}
+			printer := escpos.NewPrinterWithClosePolicy(sink, c.policy)
+
+			err := printer.SetBuffered(true)
+			if err != nil {
+				t.Fatalf("could not buffer: %v", err)
+			}
+
+			err = printer.Print("end")
+			if err != nil {
+				t.Fatalf("could not print: %v", err)
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("buffered text was sent before close")
+			}
+
+			err = printer.Close()
+			if err != nil {
+				t.Fatalf("could not close: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("close sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestClosePolicyErrors(t *testing.T) {
+	policy := escpos.ClosePolicy{FeedLines: 4, Cut: true, CutMode: escpos.CutPartial}
+
+	err := escpos.NewPrinterWithClosePolicy(brokenConn{}, policy).Close()
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+func TestLineSpacing(t *testing.T) {
+	// 1/6 inch at 8 dots per mm
+	const defaultSpacing = 34
+
+	cases := []struct {
+		name string
+		set  func(escpos.Printer) error
+		want int
+	}{
+		{"new printer", func(escpos.Printer) error { return nil }, defaultSpacing},
+		{"set", func(p escpos.Printer) error { return p.SetLineSpacing(60) }, 60},
+		{
+			"reset",
+			func(p escpos.Printer) error {
+				err := p.SetLineSpacing(60)
+				if err != nil {
+					return err
+				}
+				return p.ResetLineSpacing()
+			},
+			defaultSpacing,
+		},
+		{"1/180 inch units", func(p escpos.Printer) error { return p.SetMotionUnits(0, 180) }, 30},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, printer := escpos.NewCapturePrinter()
+
+			err := c.set(printer)
+			if err != nil {
+				t.Fatalf("could not set line spacing: %v", err)
+			}
+			if got := printer.LineSpacing(); got != c.want {
+				t.Fatalf("line spacing was %d instead of %d", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLineSpacingErrors(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+	want := printer.LineSpacing()
+
+	err := printer.SetLineSpacing(256)
+	if err == nil {
+		t.Fatalf("line spacing of 256 did not fail")
+	}
+	if got := printer.LineSpacing(); got != want {
+		t.Fatalf("line spacing was %d instead of %d after failing", got, want)
+	}
+
+	printer = escpos.NewPrinter(brokenConn{})
+	err = printer.SetLineSpacing(60)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+	if got := printer.LineSpacing(); got != want {
+		t.Fatalf("line spacing was %d instead of %d after failing", got, want)
+	}
+}
+
+func TestWritePacing(t *testing.T) {
+	data := bytes.Repeat([]byte("paced "), 100)
+
+	sink, printer := escpos.NewCapturePrinter()
+	printer.SetWritePacing(2000)
+
+	start := time.Now()
+	_, err := printer.Write(data)
+	if err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+
+	// 600 bytes at 2000 bytes a second is 300ms
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("writing %d bytes at 2000 bytes a second took %v", len(data), elapsed)
+	}
+	if !bytes.Equal(sink.Bytes(), data) {
+		t.Fatalf("paced write changed the data")
+	}
+
+	printer.SetWritePacing(0)
+
+	start = time.Now()
+	_, err = printer.Write(data)
+	if err != nil {
+		t.Fatalf("could not write: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("write without pacing took %v", elapsed)
+	}
+}
+
+func TestWritePacingError(t *testing.T) {
+	printer := escpos.NewPrinter(brokenConn{})
+	printer.SetWritePacing(2000)
+
+	_, err := printer.Write([]byte("paced"))
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+func TestSetHRIFont(t *testing.T) {
+	cases := []struct {
+		font escpos.Font
+		want []byte
+	}{
+		{escpos.FontA, []byte{escpos.GS, 'f', 0}},
+		{escpos.FontB, []byte{escpos.GS, 'f', 1}},
+	}
+
+	for _, c := range cases {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetHRIFont(c.font)
+		if err != nil {
+			t.Fatalf("could not set HRI font %v: %v", c.font, err)
+		}
+		if !bytes.Equal(sink.Bytes(), c.want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+		}
+	}
+
+	sink, printer := escpos.NewCapturePrinter()
+	err := printer.SetHRIFont(escpos.FontC)
+	if err == nil {
+		t.Fatalf("HRI font C should fail")
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x after failing", sink.Bytes())
+	}
+}
+
+// stuckConn never takes any bytes and never returns an error
+type stuckConn struct{}
+
+func (stuckConn) Write(b []byte) (int, error) { return 0, nil }
+
+func (stuckConn) Read(b []byte) (int, error) { return 0, io.EOF }
+
+func (stuckConn) Close() error { return nil }
+
+func TestShortWrites(t *testing.T) {
+	text := "Short writes should still send everything"
+
+	cases := []struct {
+		name   string
+		pacing int
+	}{
+		{"unpaced", 0},
+		{"paced", 100000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conn := &partialConn{size: 3}
+			printer := escpos.NewPrinter(conn)
+			printer.SetWritePacing(c.pacing)
+
+			err := printer.SetBold(true)
+			if err != nil {
+				t.Fatalf("could not set bold: %v", err)
+			}
+
+			err = printer.Println(text)
+			if err != nil {
+				t.Fatalf("could not print: %v", err)
+			}
+
+			want := append([]byte{escpos.ESC, 'E', 1}, text+"\n"...)
+			if !bytes.Equal(conn.Bytes(), want) {
+				t.Fatalf("partial writes sent %q instead of %q", conn.Bytes(), want)
+			}
+			if conn.writes < len(want)/conn.size {
+				t.Fatalf("only %d writes were made for %d bytes", conn.writes, len(want))
+			}
+		})
+	}
+}
+
+func TestShortWritesStuck(t *testing.T) {
+	_, err := escpos.NewPrinter(stuckConn{}).Write([]byte("stuck"))
+	if !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("write that takes nothing returned %v instead of a short write", err)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	cases := []struct {
+		name string
+		feed func(escpos.Printer) error
+		want []byte
+	}{
+		{"0 units", func(p escpos.Printer) error { return p.Feed(0) }, []byte{escpos.ESC, 'J', 0}},
+		{"255 units", func(p escpos.Printer) error { return p.Feed(255) }, []byte{escpos.ESC, 'J', 255}},
+		{"256 units", func(p escpos.Printer) error { return p.Feed(256) }, []byte{escpos.ESC, 'J', 255, escpos.ESC, 'J', 1}},
+		{"510 units", func(p escpos.Printer) error { return p.Feed(510) }, []byte{escpos.ESC, 'J', 255, escpos.ESC, 'J', 255}},
+		{
+			"600 units",
+			func(p escpos.Printer) error { return p.Feed(600) },
+			[]byte{escpos.ESC, 'J', 255, escpos.ESC, 'J', 255, escpos.ESC, 'J', 90},
+		},
+		{"300 lines", func(p escpos.Printer) error { return p.FeedLines(300) }, []byte{escpos.ESC, 'd', 255, escpos.ESC, 'd', 45}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.feed(printer)
+			if err != nil {
+				t.Fatalf("could not feed: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestFeedErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		feed func(escpos.Printer) error
+	}{
+		{"-1 units", func(p escpos.Printer) error { return p.Feed(-1) }},
+		{"-1 lines", func(p escpos.Printer) error { return p.FeedLines(-1) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.feed(printer)
+			if err == nil {
+				t.Fatalf("negative feed did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestSetNewline(t *testing.T) {
+	cases := []struct {
+		name  string
+		mode  escpos.NewlineMode
+		print func(escpos.Printer) error
+		want  string
+	}{
+		{"LF", escpos.NewlineLF, func(p escpos.Printer) error { return p.Println("Line") }, "Line\n"},
+		{"CR", escpos.NewlineCR, func(p escpos.Printer) error { return p.Println("Line") }, "Line\r"},
+		{"CRLF", escpos.NewlineCRLF, func(p escpos.Printer) error { return p.Println("Line") }, "Line\r\n"},
+		// The text has mixed line endings and all of them come out as CRLF
+		{
+			"wrapped",
+			escpos.NewlineCRLF,
+			func(p escpos.Printer) error { return p.PrintWrapped("one two three\r\nfour\rfive\nsix", 8) },
+			"one two\r\nthree\r\nfour\r\nfive\r\nsix\r\n",
+		},
+		{
+			"reader",
+			escpos.NewlineCRLF,
+			func(p escpos.Printer) error { return p.PrintReader(strings.NewReader("first\r\nsecond\n"), 0) },
+			"first\r\nsecond\r\n",
+		},
+		{"formatted", escpos.NewlineCRLF, func(p escpos.Printer) error { return p.Printfw(0, "a\r\nb\n") }, "a\r\nb\r\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetNewline(c.mode)
+			if err != nil {
+				t.Fatalf("could not set newline: %v", err)
+			}
+
+			err = c.print(printer)
+			if err != nil {
+				t.Fatalf("could not print: %v", err)
+			}
+			if string(sink.Bytes()) != c.want {
+				t.Fatalf("printed %q instead of %q", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestSetNewlineErrors(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.SetNewline(escpos.NewlineMode(3))
+	if err == nil {
+		t.Fatalf("newline mode 3 did not fail")
+	}
+
+	// The newline is still LF after failing
+	err = printer.Println("Line")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+	if string(sink.Bytes()) != "Line\n" {
+		t.Fatalf("printed %q instead of %q", sink.Bytes(), "Line\n")
+	}
+}
+
+// countConn counts the writes to it
+type countConn struct {
+	bytes.Buffer
+	writes int
+}
+
+func (c *countConn) Write(b []byte) (int, error) {
+	c.writes++
+	return c.Buffer.Write(b)
+}
+
+func (c *countConn) Close() error { return nil }
+
+// label prints a few commands that are each sent on their own without a
+// batch
+func label(p escpos.Printer) error {
+	err := p.SetBold(true)
+	if err != nil {
+		return err
+	}
+
+	err = p.Println("Label 1")
+	if err != nil {
+		return err
+	}
+
+	err = p.SetBold(false)
+	if err != nil {
+		return err
+	}
+
+	err = p.QRCode("LABEL-1", escpos.QRModel2, 4, escpos.QRErrorM)
+	if err != nil {
+		return err
+	}
+
+	return p.PrintBarCode(escpos.BcCODE39, "LABEL1")
+}
+
+func TestBatch(t *testing.T) {
+	conn := &countConn{}
+	printer := escpos.NewPrinter(conn)
+	printer.SetLineFlush(true)
+
+	err := printer.Batch(label)
+	if err != nil {
+		t.Fatalf("could not print batch: %v", err)
+	}
+	if conn.writes != 1 {
+		t.Fatalf("batch made %d writes instead of 1", conn.writes)
+	}
+
+	// The same commands without the batch
+	unbatched := &countConn{}
+	err = label(escpos.NewPrinter(unbatched))
+	if err != nil {
+		t.Fatalf("could not print label: %v", err)
+	}
+	if !bytes.Equal(conn.Bytes(), unbatched.Bytes()) {
+		t.Fatalf("batch sent % x instead of % x like the commands on their own", conn.Bytes(), unbatched.Bytes())
+	}
+
+	// Buffering is off again after the batch
+	conn.Reset()
+	conn.writes = 0
+	err = printer.Println("After")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+	if conn.writes != 1 || conn.String() != "After\n" {
+		t.Fatalf("write after the batch was not sent right away")
+	}
+}
+
+func TestBatchFails(t *testing.T) {
+	errLabel := errors.New("label failed")
+
+	conn := &countConn{}
+	printer := escpos.NewPrinter(conn)
+
+	// A failed batch sends none of its commands
+	err := printer.Batch(func(p escpos.Printer) error {
+		p.Println("Half a label")
+		return errLabel
+	})
+	if !errors.Is(err, errLabel) {
+		t.Fatalf("failed batch returned %v instead of the label error", err)
+	}
+
+	err = printer.Flush()
+	if err != nil {
+		t.Fatalf("could not flush: %v", err)
+	}
+	if conn.writes != 0 {
+		t.Fatalf("failed batch sent %q", conn.String())
+	}
+}
+
+func TestBatchBuffered(t *testing.T) {
+	conn := &countConn{}
+	printer := escpos.NewPrinter(conn)
+
+	// With buffering already on the batches stay in the buffer
+	err := printer.SetBuffered(true)
+	if err != nil {
+		t.Fatalf("could not buffer: %v", err)
+	}
+
+	err = printer.Println("Before")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	err = printer.Batch(func(p escpos.Printer) error {
+		p.Println("Thrown away")
+		return errors.New("label failed")
+	})
+	if err == nil {
+		t.Fatalf("failed batch did not return the error")
+	}
+
+	err = printer.Batch(func(p escpos.Printer) error { return p.Println("Batch") })
+	if err != nil {
+		t.Fatalf("could not print batch: %v", err)
+	}
+	if conn.writes != 0 {
+		t.Fatalf("batch while buffering sent %q before the flush", conn.String())
+	}
+
+	err = printer.SetBuffered(false)
+	if err != nil {
+		t.Fatalf("could not stop buffering: %v", err)
+	}
+	if conn.writes != 1 || conn.String() != "Before\nBatch\n" {
+		t.Fatalf("buffer after the batches sent %q", conn.String())
+	}
+}
+
+func TestDoubleSize(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	// Each step keeps the size the others set
+	steps := []struct {
+		set  func() error
+		want string
+	}{
+		{func() error { return printer.SetCharacterSize(3, 0) }, "CHARACTER SIZE 3x0"},
+		{func() error { return printer.SetDoubleHeight(true) }, "CHARACTER SIZE 3x1"},
+		{func() error { return printer.SetDoubleWidth(true) }, "CHARACTER SIZE 1x1"},
+		{func() error { return printer.SetDoubleHeight(false) }, "CHARACTER SIZE 1x0"},
+		{func() error { return printer.SetCharacterSize(0, 5) }, "CHARACTER SIZE 0x5"},
+		{func() error { return printer.SetDoubleWidth(true) }, "CHARACTER SIZE 1x5"},
+		{func() error { return printer.SetDoubleWidth(false) }, "CHARACTER SIZE 0x5"},
+	}
+
+	for i, step := range steps {
+		sink.Reset()
+
+		err := step.set()
+		if err != nil {
+			t.Fatalf("step %d failed: %v", i+1, err)
+		}
+
+		got := sink.Commands()
+		if len(got) != 1 || got[0] != step.want {
+			t.Fatalf("step %d sent %q instead of %q", i+1, got, step.want)
+		}
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.Println("a\tb")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+	if string(sink.Bytes()) != "a\tb\n" {
+		t.Fatalf("tabs were changed to %q before turning on expand tabs", sink.Bytes())
+	}
+
+	err = printer.SetExpandTabs(8)
+	if err != nil {
+		t.Fatalf("could not expand tabs: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		print func() error
+		want  string
+	}{
+		{"println", func() error { return printer.Println("Qty\tItem\tPrice") }, "Qty     Item    Price\n"},
+		{"full stop", func() error { return printer.Println("12345678\tX") }, "12345678        X\n"},
+		{"printf", func() error { return printer.Printf("%d\t%s\n\t%s\n", 2, "Tea", "Milk") }, "2       Tea\n        Milk\n"},
+		{"pieces", func() error {
+			for _, part := range []string{"abc", "\tde", "\tf\n"} {
+				err := printer.Print(part)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}, "abc     de      f\n"},
+		{"after LF", func() error {
+			err := printer.Print("abc")
+			if err != nil {
+				return err
+			}
+			err = printer.LF()
+			if err != nil {
+				return err
+			}
+			return printer.Print("\tx\r\ty\n")
+		}, "abc\n        x\r        y\n"},
+	}
+
+	for _, c := range cases {
+		sink.Reset()
+
+		err := c.print()
+		if err != nil {
+			t.Fatalf("%s failed: %v", c.name, err)
+		}
+		if string(sink.Bytes()) != c.want {
+			t.Fatalf("%s printed %q instead of %q", c.name, sink.Bytes(), c.want)
+		}
+	}
+
+	err = printer.SetExpandTabs(-1)
+	if err == nil {
+		t.Fatalf("a negative tab width did not fail")
+	}
+}
+
+func TestRotate90State(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	steps := []struct {
+		name     string
+		set      func() error
+		want     []string
+		rotate90 bool
+		o        escpos.Orientation
+	}{
+		{"rotate on", func() error { return printer.SetRotate90(true) }, []string{"ROTATE90 on"}, true, escpos.OrientationRotate90},
+		{"upside-down on", func() error { return printer.SetUpsideDown(true) }, []string{"UPSIDE-DOWN on"}, true, escpos.OrientationRotate270},
+		{"rotate off", func() error { return printer.SetRotate90(false) }, []string{"ROTATE90 off"}, false, escpos.OrientationUpsideDown},
+		{"orientation 90", func() error { return printer.SetOrientation(escpos.OrientationRotate90) }, []string{"UPSIDE-DOWN off", "ROTATE90 on"}, true, escpos.OrientationRotate90},
+		{"initialize", printer.Initialize, []string{"INITIALIZE"}, false, escpos.OrientationNormal},
+		{"orientation 270", func() error { return printer.SetOrientation(escpos.OrientationRotate270) }, []string{"UPSIDE-DOWN on", "ROTATE90 on"}, true, escpos.OrientationRotate270},
+		{"reset formatting", printer.ResetFormatting, nil, false, escpos.OrientationNormal},
+	}
+
+	for _, step := range steps {
+		sink.Reset()
+
+		err := step.set()
+		if err != nil {
+			t.Fatalf("%s failed: %v", step.name, err)
+		}
+
+		got := sink.Commands()
+		if step.want != nil && !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s sent %q instead of %q", step.name, got, step.want)
+		}
+
+		if printer.Rotate90Enabled() != step.rotate90 {
+			t.Fatalf("rotate 90 was %t after %s", printer.Rotate90Enabled(), step.name)
+		}
+
+		if printer.Orientation() != step.o {
+			t.Fatalf("orientation was %d after %s instead of %d", printer.Orientation(), step.name, step.o)
+		}
+	}
+}
+
+// jobConn takes at most size bytes a write and answers GS r 1 only once the
+// job of want bytes has all arrived.  Real-time status requests are answered
+// right away.
+type jobConn struct {
+	bytes.Buffer
+	size    int
+	want    int
+	answers chan byte
+}
+
+func (c *jobConn) Write(b []byte) (int, error) {
+	if c.size > 0 && len(b) > c.size {
+		b = b[:c.size]
+	}
+	c.Buffer.Write(b)
+
+	if bytes.Equal(b, []byte{escpos.DLE, 0x04, 1}) {
+		c.answers <- 0x16
+	}
+
+	data := c.Bytes()
+	if bytes.HasSuffix(data, []byte{escpos.GS, 'r', 1}) && len(data) == c.want {
+		c.answers <- 0x00
+	}
+	return len(b), nil
+}
+
+func (c *jobConn) Read(b []byte) (int, error) {
+	b[0] = <-c.answers
+	return 1, nil
+}
+
+func (c *jobConn) Close() error { return nil }
+
+// confirmJob prints a few labels and cuts the paper
+func confirmJob(p escpos.Printer) error {
+	for i := 0; i < 10; i++ {
+		err := p.Printf("Label %d\n", i)
+		if err != nil {
+			return err
+		}
+	}
+	return p.Cut()
+}
+
+func TestConfirmJobs(t *testing.T) {
+	sink, capture := escpos.NewCapturePrinter()
+	err := confirmJob(capture)
+	if err != nil {
+		t.Fatalf("could not print job: %v", err)
+	}
+
+	// The job with the status requests around it
+	want := append(append([]byte{escpos.DLE, 0x04, 1}, sink.Bytes()...), escpos.GS, 'r', 1)
+
+	conn := &jobConn{size: 7, want: len(want), answers: make(chan byte, 2)}
+	printer := escpos.NewPrinter(conn)
+	printer.SetConfirmJobs(true)
+	printer.SetReadTimeout(time.Second)
+
+	err = printer.Batch(confirmJob)
+	if err != nil {
+		t.Fatalf("confirmed batch failed: %v", err)
+	}
+	if !bytes.Equal(conn.Bytes(), want) {
+		t.Fatalf("confirmed batch sent % x instead of % x", conn.Bytes(), want)
+	}
+}
+
+func TestConfirmJobsLost(t *testing.T) {
+	sink, capture := escpos.NewCapturePrinter()
+	err := confirmJob(capture)
+	if err != nil {
+		t.Fatalf("could not print job: %v", err)
+	}
+
+	// The printer never answers when part of the job is lost
+	conn := &jobConn{want: 3 + len(sink.Bytes()) + 3 + 1, answers: make(chan byte, 2)}
+	printer := escpos.NewPrinter(conn)
+	printer.SetConfirmJobs(true)
+	printer.SetReadTimeout(50 * time.Millisecond)
+
+	err = printer.Batch(confirmJob)
+	if !errors.Is(err, escpos.ErrNotConfirmed) {
+		t.Fatalf("batch returned %v instead of ErrNotConfirmed", err)
+	}
+}
+
+func TestPresentTicket(t *testing.T) {
+	kiosk := escpos.ProfileHoin
+	kiosk.Name = "Kiosk 80mm"
+	kiosk.Presenter = &escpos.Presenter{
+		Present: []byte{escpos.GS, 'e', 3},
+		Retract: []byte{escpos.GS, 'e', 5, 0},
+	}
+	sink, printer := newPrinter(kiosk)
+
+	err := printer.Cut()
+	if err != nil {
+		t.Fatalf("could not cut: %v", err)
+	}
+
+	err = printer.PresentTicket(160)
+	if err != nil {
+		t.Fatalf("could not present: %v", err)
+	}
+
+	err = printer.RetractTicket()
+	if err != nil {
+		t.Fatalf("could not retract: %v", err)
+	}
+
+	want := []byte{escpos.GS, 'V', 0, escpos.GS, 'e', 3, 160, escpos.GS, 'e', 5, 0}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+
+	// The present command of the profile must not be changed by the eject
+	// length
+	if len(kiosk.Presenter.Present) != 3 {
+		t.Fatalf("present command was changed to % x", kiosk.Presenter.Present)
+	}
+
+	if printer.PresentTicket(256) == nil {
+		t.Fatalf("an eject of 256 dots didn't fail")
+	}
+}
+
+func TestPresentTicketUnsupported(t *testing.T) {
+	// There is nothing to send without a presenter, so profiles that aren't
+	// strict fail too
+	for _, profile := range []escpos.Profile{escpos.ProfileHoin, escpos.ProfileUnknown} {
+		t.Run(profile.Name, func(t *testing.T) {
+			sink, printer := newPrinter(profile)
+
+			err := printer.PresentTicket(0)
+			var unsupported *escpos.UnsupportedError
+			if !errors.As(err, &unsupported) || unsupported.Command != "PresentTicket" {
+				t.Fatalf("present without a presenter returned %v instead of an unsupported error for PresentTicket", err)
+			}
+
+			err = printer.RetractTicket()
+			if !errors.As(err, &unsupported) || unsupported.Command != "RetractTicket" {
+				t.Fatalf("retract without a presenter returned %v instead of an unsupported error for RetractTicket", err)
+			}
+
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("sent % x without a presenter", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestSetLeftMarginChars(t *testing.T) {
+	none := func(escpos.Printer) error { return nil }
+
+	cases := []struct {
+		name    string
+		profile escpos.Profile
+		cols    int
+		setup   func(escpos.Printer) error
+		want    int
+	}{
+		// 576 dots / 48 columns is 12 dots a character
+		{"font A", escpos.ProfileHoin, 4, none, 48},
+		{"double width", escpos.ProfileHoin, 4, func(p escpos.Printer) error { return p.SetCharacterSize(1, 0) }, 96},
+		{"SetDoubleWidth", escpos.ProfileHoin, 4, func(p escpos.Printer) error { return p.SetDoubleWidth(true) }, 96},
+		// 576 dots / 64 columns is 9 dots a character
+		{"font B", escpos.ProfileHoin, 4, func(p escpos.Printer) error { return p.SetFont(escpos.FontB) }, 36},
+		{"58mm", escpos.ProfileGeneric58, 4, none, 48},
+		{"no margin", escpos.ProfileHoin, 0, none, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := c.setup(printer)
+			if err != nil {
+				t.Fatalf("could not set up the printer: %v", err)
+			}
+
+			sink.Reset()
+			err = printer.SetLeftMarginChars(c.cols)
+			if err != nil {
+				t.Fatalf("could not set margin: %v", err)
+			}
+
+			b := sink.Bytes()
+			if len(b) != 4 || b[0] != escpos.GS || b[1] != 'L' {
+				t.Fatalf("sent % x instead of GS L", b)
+			}
+			if got := int(b[2]) | int(b[3])<<8; got != c.want {
+				t.Fatalf("%d columns was %d dots instead of %d", c.cols, got, c.want)
+			}
+		})
+	}
+}
+
+func TestSetLeftMarginCharsErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		cols  int
+		setup func(escpos.Printer) error
+	}{
+		// The margin has to leave at least a column
+		{"all columns", 24, func(p escpos.Printer) error { return p.SetCharacterSize(1, 1) }},
+		{"negative", -1, func(escpos.Printer) error { return nil }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(escpos.ProfileHoin)
+
+			err := c.setup(printer)
+			if err != nil {
+				t.Fatalf("could not set up the printer: %v", err)
+			}
+
+			sink.Reset()
+			err = printer.SetLeftMarginChars(c.cols)
+			if err == nil {
+				t.Fatalf("a margin of %d columns didn't fail", c.cols)
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestBuzzerProfile(t *testing.T) {
+	cases := []struct {
+		name    string
+		command string
+		beep    func(escpos.Printer) error
+	}{
+		{"Beep", "Beep", func(p escpos.Printer) error { return p.Beep(2, 3) }},
+		{"BeepSequence", "BeepSequence", func(p escpos.Printer) error {
+			return p.BeepSequence([]escpos.Beep{{Count: 2, Duration: 3}})
+		}},
+		{"BeepPattern", "BeepPattern", func(p escpos.Printer) error { return p.BeepPattern(escpos.BeepAttention) }},
+		{"MorsePrint", "Beep", func(p escpos.Printer) error { return p.MorsePrint("E") }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := newPrinter(escpos.ProfileGeneric58)
+
+			err := c.beep(printer)
+			var unsupported *escpos.UnsupportedError
+			if !errors.Is(err, escpos.ErrUnsupported) || !errors.As(err, &unsupported) || unsupported.Command != c.command {
+				t.Fatalf("without a buzzer returned %v instead of ErrUnsupported", err)
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("without a buzzer sent % x", sink.Bytes())
+			}
+		})
+	}
+
+	// A profile that only sets a few fields isn't strict so it beeps too
+	partial := escpos.Profile{Name: "Partial", DotWidth: 384}
+
+	for _, profile := range []escpos.Profile{escpos.ProfileHoin, escpos.ProfileUnknown, partial} {
+		sink, printer := newPrinter(profile)
+
+		err := printer.Beep(2, 3)
+		if err != nil {
+			t.Fatalf("could not beep on %s: %v", profile.Name, err)
+		}
+
+		want := []byte{escpos.ESC, 'B', 2, 3}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("beep on %s sent % x instead of % x", profile.Name, sink.Bytes(), want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package escpos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnsupported is returned by commands the printer profile says the printer
+// can't do.  The commands return an *UnsupportedError with the name of the
+// command, which matches ErrUnsupported with errors.Is.
+var ErrUnsupported = errors.New("not supported by the printer")
+
+// UnsupportedError is returned by commands the printer profile says the
+// printer can't do.  It matches ErrUnsupported with errors.Is.
+type UnsupportedError struct {
+	// Command is the method that was called, like "Cut"
+	Command string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is %v", e.Command, ErrUnsupported)
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// supports returns an *UnsupportedError for the command when the profile is
+// strict and says the printer can't do it
+func (p Printer) supports(command string, supported bool) error {
+	if supported || !p.Profile().Strict {
+		return nil
+	}
+	return &UnsupportedError{Command: command}
+}
+
+// Profile describes what a printer model can do
+type Profile struct {
+	Name string
+	// DotWidth is the number of dots in a line at 180dpi
+	DotWidth int
+	// FontAColumns, FontBColumns, and FontCColumns are the number of
+	// characters in a line for each font.  A font with 0 columns isn't on the
+	// printer, other than font A which every printer has.
+	FontAColumns, FontBColumns, FontCColumns int
+	// SupportsCut is set when the printer has an auto cutter
+	SupportsCut bool
+	// SupportsFeedCut is set when the printer has GS V 65 and 66 to feed the
+	// paper to the cutter before cutting
+	SupportsFeedCut bool
+	// SupportsBarCode is set when the printer can print GS k bar codes
+	SupportsBarCode bool
+	// SupportsQR is set when the printer can print QR codes with GS ( k.
+	// It is only used by SmartQR, the QR code functions send the command
+	// either way.
+	SupportsQR bool
+	// SupportsBuzzer is set when the printer has a buzzer for ESC B n t
+	SupportsBuzzer bool
+	// DotsPerMM is the number of vertical motion units in a millimeter
+	DotsPerMM float64
+	// Presenter is the present and retract commands of kiosk printers, and
+	// nil for printers that drop the ticket after the cut
+	Presenter *Presenter
+	// Strict makes commands the Supports flags don't allow fail with
+	// ErrUnsupported instead of being sent.  Without it the commands are
+	// sent anyway and the printer ignores the ones it can't do, so a
+	// Profile that only sets a few fields still prints everything.
+	Strict bool
+}
+
+// Presenter is the commands of a kiosk printer that holds the cut ticket and
+// pushes it out of the slot.  They aren't part of ESC/POS, so the bytes are
+// different for every model and come from its manual.
+type Presenter struct {
+	// Present ejects the ticket.  It is sent followed by the eject length in
+	// dots as one byte.
+	Present []byte
+	// Retract pulls a ticket that wasn't taken back into the printer
+	Retract []byte
+}
+
+var (
+	// ProfileHoin is for 80mm Hoin printers like the HOP-E802, which is the
+	// default profile
+	ProfileHoin = Profile{
+		Name:            "Hoin 80mm",
+		DotWidth:        DefaultDotWidth,
+		FontAColumns:    48,
+		FontBColumns:    64,
+		SupportsCut:     true,
+		SupportsFeedCut: true,
+		SupportsBarCode: true,
+		SupportsQR:      true,
+		SupportsBuzzer:  true,
+		DotsPerMM:       8,
+	}
+
+	// ProfileGeneric58 is for the common 58mm printers without a cutter
+	ProfileGeneric58 = Profile{
+		Name:            "Generic 58mm",
+		DotWidth:        384,
+		FontAColumns:    32,
+		FontBColumns:    42,
+		SupportsCut:     false,
+		SupportsBarCode: true,
+		DotsPerMM:       8,
+		Strict:          true,
+	}
+
+	// ProfileUnknown is for 80mm printers that aren't known.  None of the
+	// Supports flags are set, but it isn't strict, so every command is sent
+	// and the printer ignores the ones it can't do.
+	ProfileUnknown = Profile{
+		Name:         "Unknown 80mm",
+		DotWidth:     DefaultDotWidth,
+		FontAColumns: 48,
+		FontBColumns: 64,
+		DotsPerMM:    8,
+	}
+)
+
+// NewPrinterWithProfile creates a printer that checks commands against the
+// profile instead of ProfileHoin
+func NewPrinterWithProfile(dst io.ReadWriteCloser, profile Profile) Printer {
+	printer := NewPrinter(dst)
+	printer.config.profile = profile
+	return printer
+}
+
+// Profile returns the profile of the printer
+func (p Printer) Profile() Profile {
+	if p.config == nil {
+		return ProfileHoin
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	return p.config.profile
+}
+
+// MaxWidthDots returns the number of dots in a printed line.  It comes from the
+// DotWidth of the profile, or DefaultDotWidth if the profile doesn't set one.
+// Images wider than this return ErrImageTooWide instead of printing.
+func (p Printer) MaxWidthDots() int {
+	width := p.Profile().DotWidth
+	if width <= 0 {
+		return DefaultDotWidth
+	}
+	return width
+}
+
+// Columns returns the number of characters in a line for the font and
+// character width that were last set.  The columns come from the profile
+// and are divided by the width from SetCharacterSize or the DoubleWidth print
+// mode, so double width font A on an 80mm printer is 24 columns.
+//
+// Only changes made through the printer are known, so sending font or size
+// commands with Write will make this wrong.
+func (p Printer) Columns() int {
+	if p.config == nil {
+		return ProfileHoin.FontAColumns
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	// A profile that isn't strict can set a font it has no columns for, and font A
+	// is the closest guess for it
+	columns := p.config.profile.FontColumns(p.config.font)
+	if columns == 0 {
+		columns = p.config.profile.FontAColumns
+	}
+	return columns / (p.config.charWidth + 1)
+}
+
+// FontColumns returns the number of characters in a line for the font
+func (pr Profile) FontColumns(f Font) int {
+	switch f {
+	case FontB:
+		return pr.FontBColumns
+	case FontC:
+		return pr.FontCColumns
+	}
+	return pr.FontAColumns
+}
@@ -0,0 +1,80 @@
+package escpos_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestUnsupportedCommand(t *testing.T) {
+	// Without Strict the cut is sent even though there is no cutter
+	lenient := escpos.ProfileGeneric58
+	lenient.Name = "Lenient 58mm"
+	lenient.Strict = false
+
+	cases := []struct {
+		profile     escpos.Profile
+		unsupported bool
+	}{
+		{escpos.ProfileGeneric58, true},
+		{lenient, false},
+		{escpos.ProfileHoin, false},
+		{escpos.ProfileUnknown, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.profile.Name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.Cut()
+			if !c.unsupported {
+				if err != nil {
+					t.Fatalf("could not cut: %v", err)
+				}
+				if got := sink.Commands(); !reflect.DeepEqual(got, []string{"CUT"}) {
+					t.Fatalf("cut sent %q", got)
+				}
+				return
+			}
+
+			var unsupported *escpos.UnsupportedError
+			if !errors.As(err, &unsupported) || unsupported.Command != "Cut" {
+				t.Fatalf("cut returned %v instead of an unsupported error for Cut", err)
+			}
+			if !errors.Is(err, escpos.ErrUnsupported) {
+				t.Fatalf("cut returned %v which isn't ErrUnsupported", err)
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("cut sent % x", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestUnsupportedCommandStrict(t *testing.T) {
+	noBarCode := escpos.ProfileUnknown
+	noBarCode.Strict = true
+	_, printer := newPrinter(noBarCode)
+
+	err := printer.PrintBarCode(escpos.BcCODE39, "1234")
+	var unsupported *escpos.UnsupportedError
+	if !errors.As(err, &unsupported) || unsupported.Command != "PrintBarCode" {
+		t.Fatalf("bar code returned %v instead of an unsupported error for PrintBarCode", err)
+	}
+}
+
+func TestPartialProfile(t *testing.T) {
+	// None of the Supports flags are set, but the profile isn't strict so
+	// the commands are still sent
+	sink, printer := newPrinter(escpos.Profile{Name: "Partial", DotWidth: 384})
+
+	err := printer.Cut()
+	if err != nil {
+		t.Fatalf("could not cut: %v", err)
+	}
+	if got := sink.Commands(); !reflect.DeepEqual(got, []string{"CUT"}) {
+		t.Fatalf("cut sent %q", got)
+	}
+}
@@ -0,0 +1,610 @@
+package escpos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QRModel selects the QR code model to print
+type QRModel int
+
+const (
+	QRModel1 QRModel = iota + 1
+	QRModel2
+	// QRMicro is a Micro QR code, which is smaller but can only hold up to
+	// 35 digits, 21 alphanumeric characters, or 15 bytes and can't use
+	// QRErrorH
+	QRMicro
+)
+
+// QRErrorCorrection selects the error correction level of a QR code
+type QRErrorCorrection int
+
+const (
+	// QRErrorL recovers about 7% of the data
+	QRErrorL QRErrorCorrection = iota
+	// QRErrorM recovers about 15% of the data
+	QRErrorM
+	// QRErrorQ recovers about 25% of the data
+	QRErrorQ
+	// QRErrorH recovers about 30% of the data
+	QRErrorH
+)
+
+// QREncodeMode selects how the data of a QR code is encoded
+type QREncodeMode int
+
+const (
+	// QRModeAuto uses the mode that holds the data in the fewest bits
+	QRModeAuto QREncodeMode = iota
+	// QRModeNumeric can only hold the digits 0 to 9
+	QRModeNumeric
+	// QRModeAlphanumeric can only hold digits, upper case letters, space, and
+	// $%*+-./:
+	QRModeAlphanumeric
+	// QRModeByte holds any bytes
+	QRModeByte
+	// QRModeKanji can only hold Shift JIS double byte characters
+	QRModeKanji
+)
+
+// symbolCode sends a GS ( k command for the symbol type cn with function fn
+func (p Printer) symbolCode(cn, fn byte, params ...byte) error {
+	length := len(params) + 2
+	data := []byte{GS, '(', 'k', byte(length), byte(length >> 8), cn, fn}
+
+	_, err := p.Write(append(data, params...))
+	if err != nil {
+		return fmt.Errorf("could not send symbol function %d: %w", fn, err)
+	}
+	return nil
+}
+
+// QRCode prints data as a QR code
+//
+// The size is the width of a module in dots and must be between 1 and 16.
+// Data can be up to 7089 bytes, but how much actually fits depends on the
+// data and the error correction level.  QRMicro is checked against the much
+// smaller Micro QR capacity before anything is sent.  Not all printers
+// support QRModel1 and QRMicro.
+func (p Printer) QRCode(data string, model QRModel, size int, ecLevel QRErrorCorrection) error {
+	return p.QRCodeMode(data, model, size, ecLevel, QRModeAuto)
+}
+
+// QRCodeMode prints data as a QR code like QRCode, but first checks that the
+// data can be encoded with mode.  An error is returned without printing
+// anything when it can't, like letters with QRModeNumeric.
+//
+// The printer picks how the data is encoded itself, so the mode doesn't
+// change what is sent.  Use QRCodeSizeMode to find how big the code is with
+// the mode.
+func (p Printer) QRCodeMode(data string, model QRModel, size int, ecLevel QRErrorCorrection, mode QREncodeMode) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	errMsg := "could not print QR code: %w"
+
+	err := p.storeQRCode(data, model, size, ecLevel, mode)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 181: print the symbol data in the symbol storage area
+	err = p.symbolCode('1', 'Q', '0')
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// storeQRCode checks the QR code settings and stores the data in the symbol
+// storage area without printing it
+func (p Printer) storeQRCode(data string, model QRModel, size int, ecLevel QRErrorCorrection, mode QREncodeMode) error {
+	_, err := qrSelectMode(data, mode)
+	if err != nil {
+		return err
+	}
+
+	err = checkEnum(model, QRModel1, QRModel2, QRMicro)
+	if err != nil {
+		return err
+	}
+
+	err = checkRange(size, 1, 16, "size")
+	if err != nil {
+		return err
+	}
+
+	err = checkEnum(ecLevel, QRErrorL, QRErrorM, QRErrorQ, QRErrorH)
+	if err != nil {
+		return err
+	}
+
+	err = checkRange(len(data), 1, 7089, "data length")
+	if err != nil {
+		return err
+	}
+
+	if model == QRMicro {
+		err = checkMicroQR(data, ecLevel)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Function 165: select the model
+	err = p.symbolCode('1', 'A', byte(model)+'0', 0)
+	if err != nil {
+		return err
+	}
+
+	// Function 167: set the module size
+	err = p.symbolCode('1', 'C', byte(size))
+	if err != nil {
+		return err
+	}
+
+	// Function 169: select the error correction level
+	err = p.symbolCode('1', 'E', byte(ecLevel)+'0')
+	if err != nil {
+		return err
+	}
+
+	// Function 180: store the data in the symbol storage area
+	return p.symbolCode('1', 'P', append([]byte{'0'}, data...)...)
+}
+
+// QRCodeTransmitSize stores the data as a model 2 QR code and asks the
+// printer for the size of it in dots with function 182, without printing
+// it.  Unlike QRCodeSize this is the size the firmware will actually print,
+// so it can be checked before printing.  Like QROptions the size defaults
+// to 6.
+//
+// An error is returned when the printer says the code can't be printed, and
+// ErrStatusTimeout is returned when the printer doesn't answer within the
+// read timeout, which printers without function 182 do.
+func (p Printer) QRCodeTransmitSize(data string, opts QROptions) (width, height int, err error) {
+	errMsg := "could not transmit QR code size: %w"
+
+	if opts.Size == 0 {
+		opts.Size = 6
+	}
+
+	err = opts.upgradeEC(data)
+	if err != nil {
+		return 0, 0, fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err = p.storeQRCode(data, QRModel2, opts.Size, opts.ErrorCorrection, opts.Mode)
+	if err != nil {
+		return 0, 0, fmt.Errorf(errMsg, err)
+	}
+
+	// Function 182: transmit the size information of the symbol data
+	err = p.symbolCode('1', 'R', '0')
+	if err != nil {
+		return 0, 0, fmt.Errorf(errMsg, err)
+	}
+
+	response, err := p.readUntilNUL()
+	if err != nil {
+		return 0, 0, fmt.Errorf(errMsg, err)
+	}
+
+	// The response is 0x37 0x36, the width and height in decimal, and if the
+	// code can be printed, split by 0x1F
+	fields := strings.Split(string(response), "\x1F")
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], "\x37\x36") {
+		return 0, 0, fmt.Errorf(errMsg, fmt.Errorf("unexpected response % x", response))
+	}
+
+	width, err = strconv.Atoi(strings.TrimPrefix(fields[0], "\x37\x36"))
+	if err != nil {
+		return 0, 0, fmt.Errorf(errMsg, fmt.Errorf("unexpected width in response % x", response))
+	}
+
+	height, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf(errMsg, fmt.Errorf("unexpected height in response % x", response))
+	}
+
+	if fields[2] != "0" {
+		return width, height, fmt.Errorf(errMsg, fmt.Errorf("printer says the %dx%d QR code can't be printed", width, height))
+	}
+
+	return width, height, nil
+}
+
+// QRCodeCentered prints the data as a model 2 QR code in the center of the
+// paper.  The justification is set to center for the QR code and then put
+// back to what it was, so text after it isn't centered.  Like QROptions, the
+// size defaults to 6.
+func (p Printer) QRCodeCentered(data string, opts QROptions) error {
+	errMsg := "could not print centered QR code: %w"
+
+	if opts.Size == 0 {
+		opts.Size = 6
+	}
+
+	err := checkRange(opts.QuietZone, 0, 255, "quiet zone")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = opts.upgradeEC(data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	previous := LeftJustify
+	if p.config != nil {
+		previous = p.config.justify
+	}
+
+	err = p.Justify(CenterJustify)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.qrQuietZone(opts.QuietZone, opts.Size, func() error {
+		return p.QRCodeMode(data, QRModel2, opts.Size, opts.ErrorCorrection, opts.Mode)
+	})
+	if err != nil {
+		p.Justify(previous)
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.Justify(previous)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// qrQuietZone calls print with modules * size dots of blank space around
+// it.  The paper is fed before and after print, and when the justification is
+// left the left margin is set for print and put back to 0 after it.
+func (p Printer) qrQuietZone(modules, size int, print func() error) error {
+	if modules == 0 {
+		return print()
+	}
+
+	dots := modules * size
+
+	err := p.Feed(dots)
+	if err != nil {
+		return err
+	}
+
+	if p.config == nil || p.config.justify == LeftJustify {
+		err = p.SetLeftMargin(dots)
+		if err != nil {
+			return err
+		}
+
+		err = print()
+		marginErr := p.SetLeftMargin(0)
+		if err != nil {
+			return err
+		}
+		if marginErr != nil {
+			return marginErr
+		}
+	} else {
+		err = print()
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.Feed(dots)
+}
+
+// qrDataCodewords is the number of data codewords for each model 2 version
+// and error correction level
+var qrDataCodewords = [40][4]int{
+	{19, 16, 13, 9}, {34, 28, 22, 16}, {55, 44, 34, 26}, {80, 64, 48, 36},
+	{108, 86, 62, 46}, {136, 108, 76, 60}, {156, 124, 88, 66}, {194, 154, 110, 86},
+	{232, 182, 132, 100}, {274, 216, 154, 122}, {324, 254, 180, 140}, {370, 290, 206, 158},
+	{428, 334, 244, 180}, {461, 365, 261, 197}, {523, 415, 295, 223}, {589, 453, 325, 253},
+	{647, 507, 367, 283}, {721, 563, 397, 313}, {795, 627, 445, 341}, {861, 669, 485, 385},
+	{932, 714, 512, 406}, {1006, 782, 568, 442}, {1094, 860, 614, 464}, {1174, 914, 664, 514},
+	{1276, 1000, 718, 538}, {1370, 1062, 754, 596}, {1468, 1128, 808, 628}, {1531, 1193, 871, 661},
+	{1631, 1267, 911, 701}, {1735, 1373, 985, 745}, {1843, 1455, 1033, 793}, {1955, 1541, 1115, 845},
+	{2071, 1631, 1171, 901}, {2191, 1725, 1231, 961}, {2306, 1812, 1286, 986}, {2434, 1914, 1354, 1054},
+	{2566, 1992, 1426, 1096}, {2702, 2102, 1502, 1142}, {2812, 2216, 1582, 1222}, {2956, 2334, 1666, 1276},
+}
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrMode returns if the data can be encoded in numeric or alphanumeric mode
+func qrMode(data string) (numeric, alphanumeric bool) {
+	numeric, alphanumeric = true, true
+	for _, c := range []byte(data) {
+		if c < '0' || c > '9' {
+			numeric = false
+		}
+		if strings.IndexByte(qrAlphanumeric, c) < 0 {
+			alphanumeric = false
+		}
+	}
+	return numeric, alphanumeric
+}
+
+// qrKanji returns if the data is all Shift JIS double byte characters that
+// can be encoded in kanji mode
+func qrKanji(data string) bool {
+	if len(data)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(data); i += 2 {
+		c := int(data[i])<<8 | int(data[i+1])
+		if (c < 0x8140 || c > 0x9FFC) && (c < 0xE040 || c > 0xEBBF) {
+			return false
+		}
+	}
+	return true
+}
+
+// qrSelectMode returns the mode the data is encoded with.  QRModeAuto picks
+// numeric, alphanumeric, kanji, or byte mode, whichever is the first that
+// can hold the data.  The other modes are returned if the data can be
+// encoded with them.
+func qrSelectMode(data string, mode QREncodeMode) (QREncodeMode, error) {
+	err := checkEnum(mode, QRModeAuto, QRModeNumeric, QRModeAlphanumeric, QRModeByte, QRModeKanji)
+	if err != nil {
+		return 0, err
+	}
+
+	numeric, alphanumeric := qrMode(data)
+	kanji := qrKanji(data)
+
+	switch mode {
+	case QRModeAuto:
+		switch {
+		case numeric:
+			return QRModeNumeric, nil
+		case alphanumeric:
+			return QRModeAlphanumeric, nil
+		case kanji:
+			return QRModeKanji, nil
+		}
+		return QRModeByte, nil
+	case QRModeNumeric:
+		if !numeric {
+			return 0, fmt.Errorf("numeric mode can only hold the digits 0 to 9")
+		}
+	case QRModeAlphanumeric:
+		if !alphanumeric {
+			return 0, fmt.Errorf("alphanumeric mode can only hold the characters %q", qrAlphanumeric)
+		}
+	case QRModeKanji:
+		if !kanji {
+			return 0, fmt.Errorf("kanji mode can only hold Shift JIS double byte characters")
+		}
+	}
+	return mode, nil
+}
+
+// microQRCapacity is how many digits, alphanumeric characters, or bytes the
+// largest Micro QR code holds for QRErrorL, QRErrorM, and QRErrorQ
+var microQRCapacity = [3][3]int{
+	{35, 21, 15},
+	{30, 18, 13},
+	{21, 13, 9},
+}
+
+// checkMicroQR checks that the data fits in a Micro QR code
+func checkMicroQR(data string, ecLevel QRErrorCorrection) error {
+	if ecLevel == QRErrorH {
+		return fmt.Errorf("micro QR codes can't use error correction H")
+	}
+
+	numeric, alphanumeric := qrMode(data)
+	capacity, kind := microQRCapacity[ecLevel][2], "bytes"
+	if numeric {
+		capacity, kind = microQRCapacity[ecLevel][0], "digits"
+	} else if alphanumeric {
+		capacity, kind = microQRCapacity[ecLevel][1], "alphanumeric characters"
+	}
+
+	if len(data) > capacity {
+		return fmt.Errorf("micro QR codes with error correction %d can only hold %d %s but there were %d", ecLevel, capacity, kind, len(data))
+	}
+	return nil
+}
+
+// qrBits returns the number of bits needed for the data in a version when
+// the whole data is encoded with mode, which can't be QRModeAuto
+func qrBits(data string, version int, mode QREncodeMode) int {
+	// The size of the character count depends on the version
+	sizeIndex := 0
+	if version >= 27 {
+		sizeIndex = 2
+	} else if version >= 10 {
+		sizeIndex = 1
+	}
+
+	n := len(data)
+	switch mode {
+	case QRModeNumeric:
+		return 4 + []int{10, 12, 14}[sizeIndex] + 10*(n/3) + []int{0, 4, 7}[n%3]
+	case QRModeAlphanumeric:
+		return 4 + []int{9, 11, 13}[sizeIndex] + 11*(n/2) + 6*(n%2)
+	case QRModeKanji:
+		return 4 + []int{8, 10, 12}[sizeIndex] + 13*(n/2)
+	default:
+		return 4 + []int{8, 16, 16}[sizeIndex] + 8*n
+	}
+}
+
+// QRCodeSize returns the width and height in dots that QRCode will print the
+// data at, so the rest of a receipt can be laid out around it.  This doesn't
+// talk to the printer.
+//
+// The size is found from the smallest QR version that fits the data with the
+// error correction level, with each module being size dots.  The data
+// is counted as a single numeric, alphanumeric, kanji, or byte mode segment,
+// so printers that mix modes can print a code that is a version smaller.
+// Only QRModel2 is supported.
+func QRCodeSize(data string, model QRModel, size int, ecLevel QRErrorCorrection) (int, error) {
+	return QRCodeSizeMode(data, model, size, ecLevel, QRModeAuto)
+}
+
+// QRCodeSizeMode returns the size like QRCodeSize with the data encoded in
+// mode.  An error is returned when the data can't be encoded with the mode.
+func QRCodeSizeMode(data string, model QRModel, size int, ecLevel QRErrorCorrection, mode QREncodeMode) (int, error) {
+	errMsg := "could not get QR code size: %w"
+
+	if model != QRModel2 {
+		return 0, fmt.Errorf(errMsg, fmt.Errorf("only QRModel2 is supported"))
+	}
+
+	err := checkRange(size, 1, 16, "size")
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(ecLevel, QRErrorL, QRErrorM, QRErrorQ, QRErrorH)
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(len(data), 1, 7089, "data length")
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	mode, err = qrSelectMode(data, mode)
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	version, ok := qrVersion(func(version int) int { return qrBits(data, version, mode) }, ecLevel)
+	if !ok {
+		return 0, fmt.Errorf(errMsg, fmt.Errorf("%d bytes of data doesn't fit in a QR code with error correction %d", len(data), ecLevel))
+	}
+	return (17 + 4*version) * size, nil
+}
+
+// qrVersion returns the smallest version where the bits of the data fit with
+// the error correction level
+func qrVersion(bits func(version int) int, ecLevel QRErrorCorrection) (int, bool) {
+	for version := 1; version <= 40; version++ {
+		if bits(version) <= qrDataCodewords[version-1][ecLevel]*8 {
+			return version, true
+		}
+	}
+	return 0, false
+}
+
+// qrUpgradeLevel returns the highest error correction level from ecLevel up
+// where the bits of the data still fit in the version
+func qrUpgradeLevel(bits func(version int) int, version int, ecLevel QRErrorCorrection) QRErrorCorrection {
+	for level := QRErrorH; level > ecLevel; level-- {
+		if bits(version) <= qrDataCodewords[version-1][level]*8 {
+			return level
+		}
+	}
+	return ecLevel
+}
+
+// QRCodeErrorCorrection returns the error correction level the QR code
+// functions that take QROptions use for the data.  It is the
+// ErrorCorrection of opts, unless AutoUpgradeEC is set and a higher level
+// fits in the same version.  Like QRCodeSize the data is counted as a single
+// segment, so this doesn't talk to the printer.
+func QRCodeErrorCorrection(data string, opts QROptions) (QRErrorCorrection, error) {
+	errMsg := "could not get QR code error correction: %w"
+
+	// The size is checked to get the version, but doesn't change it
+	_, err := QRCodeSizeMode(data, QRModel2, 1, opts.ErrorCorrection, opts.Mode)
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	if !opts.AutoUpgradeEC {
+		return opts.ErrorCorrection, nil
+	}
+
+	mode, err := qrSelectMode(data, opts.Mode)
+	if err != nil {
+		return 0, fmt.Errorf(errMsg, err)
+	}
+
+	bits := func(version int) int { return qrBits(data, version, mode) }
+	version, _ := qrVersion(bits, opts.ErrorCorrection)
+	return qrUpgradeLevel(bits, version, opts.ErrorCorrection), nil
+}
+
+// upgradeEC sets the error correction of opts to the level from
+// QRCodeErrorCorrection when AutoUpgradeEC is set
+func (opts *QROptions) upgradeEC(data string) error {
+	if !opts.AutoUpgradeEC {
+		return nil
+	}
+
+	ecLevel, err := QRCodeErrorCorrection(data, *opts)
+	if err != nil {
+		return err
+	}
+	opts.ErrorCorrection = ecLevel
+	return nil
+}
+
+// QRCodeFit prints data as a model 2 QR code with the largest module size
+// from 1 to 16 that keeps the code and the QuietZone on both sides of it
+// within maxWidthDots, which is found with QRCodeSizeMode with the Mode of
+// opts.  A maxWidthDots of 0 uses
+// MaxWidthDots.  The Size of opts is ignored.  An error is returned without
+// printing anything when the code doesn't fit even with a module size of 1.
+func (p Printer) QRCodeFit(data string, maxWidthDots int, opts QROptions) error {
+	errMsg := "could not print fitted QR code: %w"
+
+	if maxWidthDots == 0 {
+		maxWidthDots = p.MaxWidthDots()
+	}
+
+	err := checkRange(opts.QuietZone, 0, 255, "quiet zone")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = opts.upgradeEC(data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	width, err := QRCodeSizeMode(data, QRModel2, 1, opts.ErrorCorrection, opts.Mode)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	width += 2 * opts.QuietZone
+
+	size := maxWidthDots / width
+	if size < 1 {
+		return fmt.Errorf(errMsg, fmt.Errorf("QR code is %d dots wide at the smallest size which is more than %d", width, maxWidthDots))
+	}
+	if size > 16 {
+		size = 16
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err = p.qrQuietZone(opts.QuietZone, size, func() error {
+		return p.QRCodeMode(data, QRModel2, size, opts.ErrorCorrection, opts.Mode)
+	})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
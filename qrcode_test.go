@@ -0,0 +1,527 @@
+package escpos_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// qr returns the GS ( k command for function fn of QR codes
+func qr(fn byte, params ...byte) []byte {
+	length := len(params) + 2
+	return append([]byte{escpos.GS, '(', 'k', byte(length), byte(length >> 8), '1', fn}, params...)
+}
+
+func TestQRCode(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		model   escpos.QRModel
+		size    int
+		ecLevel escpos.QRErrorCorrection
+	}{
+		{"model 1", "HELLO", escpos.QRModel1, 1, escpos.QRErrorL},
+		{"model 2", "HELLO", escpos.QRModel2, 6, escpos.QRErrorM},
+		// Micro QR holds up to 35 digits or 21 letters at L
+		{"micro", "HELLO", escpos.QRMicro, 6, escpos.QRErrorL},
+		{"micro digits", strings.Repeat("1", 35), escpos.QRMicro, 4, escpos.QRErrorL},
+		{"micro letters", strings.Repeat("A", 21), escpos.QRMicro, 4, escpos.QRErrorL},
+		{"largest modules", "https://github.com/joeyak/go-escpos", escpos.QRModel2, 16, escpos.QRErrorQ},
+		{"error correction H", strings.Repeat("0123456789", 30), escpos.QRModel2, 3, escpos.QRErrorH},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.QRCode(c.data, c.model, c.size, c.ecLevel)
+			if err != nil {
+				t.Fatalf("could not print QR code: %v", err)
+			}
+
+			// Functions 165, 167, 169, 180 then 181
+			var want []byte
+			want = append(want, qr('A', byte(c.model)+'0', 0)...)
+			want = append(want, qr('C', byte(c.size))...)
+			want = append(want, qr('E', byte(c.ecLevel)+'0')...)
+			want = append(want, qr('P', append([]byte{'0'}, c.data...)...)...)
+			want = append(want, qr('Q', '0')...)
+
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestQRCodeBytes(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.QRCode("AB", escpos.QRModel2, 4, escpos.QRErrorM)
+	if err != nil {
+		t.Fatalf("could not print QR code: %v", err)
+	}
+
+	// The length is the data plus cn, fn and m, and the error correction
+	// level M is 49
+	want := []byte{
+		escpos.GS, '(', 'k', 4, 0, 49, 65, 50, 0,
+		escpos.GS, '(', 'k', 3, 0, 49, 67, 4,
+		escpos.GS, '(', 'k', 3, 0, 49, 69, 49,
+		escpos.GS, '(', 'k', 5, 0, 49, 80, 48, 'A', 'B',
+		escpos.GS, '(', 'k', 3, 0, 49, 81, 48,
+	}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+}
+
+func TestQRCodeErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(escpos.Printer) error
+	}{
+		{"no data", func(p escpos.Printer) error { return p.QRCode("", escpos.QRModel2, 4, escpos.QRErrorM) }},
+		{
+			"too much data",
+			func(p escpos.Printer) error {
+				return p.QRCode(strings.Repeat("A", 7090), escpos.QRModel2, 4, escpos.QRErrorM)
+			},
+		},
+		{"size 0", func(p escpos.Printer) error { return p.QRCode("HELLO", escpos.QRModel2, 0, escpos.QRErrorM) }},
+		{"size 17", func(p escpos.Printer) error { return p.QRCode("HELLO", escpos.QRModel2, 17, escpos.QRErrorM) }},
+		{"model 0", func(p escpos.Printer) error { return p.QRCode("HELLO", escpos.QRModel(0), 4, escpos.QRErrorM) }},
+		{
+			"error correction 4",
+			func(p escpos.Printer) error {
+				return p.QRCode("HELLO", escpos.QRModel2, 4, escpos.QRErrorCorrection(4))
+			},
+		},
+		{"model 4", func(p escpos.Printer) error { return p.QRCode("HELLO", escpos.QRModel(4), 4, escpos.QRErrorM) }},
+		{
+			"too long for micro QR",
+			func(p escpos.Printer) error {
+				return p.QRCode(strings.Repeat("A", 22), escpos.QRMicro, 6, escpos.QRErrorL)
+			},
+		},
+		{
+			"too many digits for micro QR",
+			func(p escpos.Printer) error {
+				return p.QRCode(strings.Repeat("1", 36), escpos.QRMicro, 6, escpos.QRErrorL)
+			},
+		},
+		{"micro QR at H", func(p escpos.Printer) error { return p.QRCode("1", escpos.QRMicro, 6, escpos.QRErrorH) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.run(printer)
+			if err == nil {
+				t.Fatalf("QR code did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestQRCodeStructuredAppend(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.QRCodeStructuredAppend("ABCDEFGH", 3, escpos.QROptions{Size: 2})
+	if err != nil {
+		t.Fatalf("could not print QR codes: %v", err)
+	}
+
+	// Each version 1 symbol is 21 modules and a quiet zone of 4 on each side
+	images := 0
+	for _, command := range sink.Commands() {
+		if strings.HasPrefix(command, "RASTER IMAGE") {
+			if command != "RASTER IMAGE mode 0 64x58" {
+				t.Fatalf("printed %s", command)
+			}
+			images++
+		}
+	}
+	if images != 3 {
+		t.Fatalf("printed %d symbols instead of 3 in %q", images, sink.Commands())
+	}
+}
+
+func TestQRCodeStructuredAppendErrors(t *testing.T) {
+	cases := []struct {
+		name         string
+		data         string
+		maxPerSymbol int
+		opts         escpos.QROptions
+	}{
+		{"empty", "", 10, escpos.QROptions{}},
+		{"17 symbols", strings.Repeat("A", 17), 1, escpos.QROptions{}},
+		{"no bytes per symbol", "ABC", 0, escpos.QROptions{}},
+		{"size 17", "ABC", 1, escpos.QROptions{Size: 17}},
+		{"error correction", "ABC", 1, escpos.QROptions{ErrorCorrection: escpos.QRErrorCorrection(4)}},
+		{"negative quiet zone", "ABC", 1, escpos.QROptions{QuietZone: -1}},
+		// The 20 bit header leaves no room for the most bytes of a QR code
+		{"symbol doesn't fit", strings.Repeat("A", 2953*2), 2953, escpos.QROptions{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.QRCodeStructuredAppend(c.data, c.maxPerSymbol, c.opts)
+			if err == nil {
+				t.Fatalf("QR codes did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestQRCodeFit(t *testing.T) {
+	// The size is sent with function 67
+	sizeCommand := []byte{escpos.GS, '(', 'k', 3, 0, 49, 67}
+
+	cases := []struct {
+		name     string
+		data     string
+		maxWidth int
+		want     byte
+	}{
+		// Version 1 is 21 modules so 9 dots is 189 and 10 dots is 210
+		{"version 1", "HELLO", 200, 9},
+		{"one dot modules", "HELLO", 21, 1},
+		// Version 3 at error correction M is 29 modules
+		{"version 3", strings.Repeat("a", 40), 300, 10},
+		{"largest size", "HELLO", 1000, 16},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.QRCodeFit(c.data, c.maxWidth, escpos.QROptions{ErrorCorrection: escpos.QRErrorM})
+			if err != nil {
+				t.Fatalf("could not print QR code: %v", err)
+			}
+
+			data := sink.Bytes()
+			i := bytes.Index(data, sizeCommand)
+			if i < 0 || i+len(sizeCommand) >= len(data) {
+				t.Fatalf("QR code size was not sent")
+			}
+			if got := data[i+len(sizeCommand)]; got != c.want {
+				t.Fatalf("QR code in %d dots was size %d instead of %d", c.maxWidth, got, c.want)
+			}
+		})
+	}
+}
+
+func TestQRCodeFitErrors(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	// Version 1 is 21 modules so it can't fit in 20 dots
+	err := printer.QRCodeFit("HELLO", 20, escpos.QROptions{ErrorCorrection: escpos.QRErrorM})
+	if err == nil {
+		t.Fatalf("QR code that doesn't fit did not fail")
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x after failing", sink.Bytes())
+	}
+}
+
+func TestQRCodeSizeMode(t *testing.T) {
+	data := strings.Repeat("0123456789", 10)
+
+	size := func(mode escpos.QREncodeMode) int {
+		t.Helper()
+		n, err := escpos.QRCodeSizeMode(data, escpos.QRModel2, 1, escpos.QRErrorM, mode)
+		if err != nil {
+			t.Fatalf("could not get the size in mode %d: %v", mode, err)
+		}
+		return n
+	}
+
+	numeric, byteMode, auto := size(escpos.QRModeNumeric), size(escpos.QRModeByte), size(escpos.QRModeAuto)
+	if numeric >= byteMode {
+		t.Fatalf("numeric mode was %d modules wide which is not smaller than %d in byte mode", numeric, byteMode)
+	}
+	if auto != numeric {
+		t.Fatalf("auto mode was %d modules wide instead of %d for numeric mode", auto, numeric)
+	}
+
+	// "\x88\x9f" is a Shift JIS kanji
+	_, err := escpos.QRCodeSizeMode("\x88\x9f", escpos.QRModel2, 1, escpos.QRErrorM, escpos.QRModeKanji)
+	if err != nil {
+		t.Fatalf("could not get the size of a kanji: %v", err)
+	}
+}
+
+func TestQRCodeSizeModeErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		mode escpos.QREncodeMode
+	}{
+		{"letter in numeric", "12A", escpos.QRModeNumeric},
+		{"lower case in alphanumeric", "abc", escpos.QRModeAlphanumeric},
+		{"ASCII in kanji", "abc", escpos.QRModeKanji},
+		{"half a kanji", "\x88\x9f\x88", escpos.QRModeKanji},
+		{"unknown mode", "123", escpos.QREncodeMode(9)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := escpos.QRCodeSizeMode(c.data, escpos.QRModel2, 1, escpos.QRErrorM, c.mode)
+			if err == nil {
+				t.Fatalf("%q was encoded in mode %d", c.data, c.mode)
+			}
+		})
+	}
+}
+
+// qrSizeConn answers the QR code size function with a canned response
+type qrSizeConn struct {
+	response []byte
+	reply    []byte
+	writes   [][]byte
+}
+
+func (c *qrSizeConn) Write(b []byte) (int, error) {
+	c.writes = append(c.writes, append([]byte{}, b...))
+	if bytes.Equal(b, []byte{escpos.GS, '(', 'k', 3, 0, '1', 'R', '0'}) {
+		c.reply = append(c.reply, c.response...)
+	}
+	return len(b), nil
+}
+
+func (c *qrSizeConn) Read(b []byte) (int, error) {
+	if len(c.reply) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.reply)
+	c.reply = c.reply[n:]
+	return n, nil
+}
+
+func (c *qrSizeConn) Close() error { return nil }
+
+func TestQRCodeTransmitSize(t *testing.T) {
+	conn := &qrSizeConn{response: append([]byte("\x37\x36174\x1F174\x1F0"), 0)}
+	printer := escpos.NewPrinter(conn)
+
+	width, height, err := printer.QRCodeTransmitSize("https://github.com/joeyak/go-escpos", escpos.QROptions{})
+	if err != nil {
+		t.Fatalf("could not get QR code size: %v", err)
+	}
+	if width != 174 || height != 174 {
+		t.Fatalf("QR code size was %dx%d instead of 174x174", width, height)
+	}
+
+	// The data is stored but not printed with function 181
+	for _, w := range conn.writes {
+		if bytes.HasPrefix(w, []byte{escpos.GS, '(', 'k', 3, 0, '1', 'Q'}) {
+			t.Fatalf("transmitting the size printed the QR code")
+		}
+	}
+}
+
+func TestQRCodeTransmitSizeErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		response []byte
+	}{
+		{"not printable", append([]byte("\x37\x36174\x1F174\x1F1"), 0)},
+		{"no printable field", append([]byte("\x37\x36174\x1F174"), 0)},
+		{"width is not a number", append([]byte("\x37\x36wide\x1F174\x1F0"), 0)},
+		{"cut off", []byte("\x37\x36174")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			printer := escpos.NewPrinter(&qrSizeConn{response: c.response})
+
+			_, _, err := printer.QRCodeTransmitSize("https://github.com/joeyak/go-escpos", escpos.QROptions{})
+			if err == nil {
+				t.Fatalf("size response % x did not fail", c.response)
+			}
+		})
+	}
+}
+
+func TestQRQuietZone(t *testing.T) {
+	symbol := []string{
+		"SYMBOL 49 function 65",
+		"SYMBOL 49 function 67",
+		"SYMBOL 49 function 69",
+		"SYMBOL 49 function 80",
+		"SYMBOL 49 function 81",
+	}
+
+	withZone := func(before, after []string) []string {
+		return append(append(append([]string{}, before...), symbol...), after...)
+	}
+
+	cases := []struct {
+		name string
+		run  func(escpos.Printer) error
+		want []string
+	}{
+		{
+			"centered without a quiet zone",
+			func(p escpos.Printer) error {
+				return p.QRCodeCentered("quiet", escpos.QROptions{Size: 3})
+			},
+			withZone([]string{"JUSTIFY center"}, []string{"JUSTIFY left"}),
+		},
+		{
+			"centered",
+			func(p escpos.Printer) error {
+				return p.QRCodeCentered("quiet", escpos.QROptions{Size: 3, QuietZone: 4})
+			},
+			withZone([]string{"JUSTIFY center", "FEED 12"}, []string{"FEED 12", "JUSTIFY left"}),
+		},
+		{
+			// The code fits the paper at the largest module size of 16, so
+			// the quiet zone is 4 * 16 dots
+			"fit",
+			func(p escpos.Printer) error {
+				return p.QRCodeFit("quiet", 0, escpos.QROptions{QuietZone: 4})
+			},
+			withZone([]string{"FEED 64", "LEFT MARGIN 64"}, []string{"LEFT MARGIN 0", "FEED 64"}),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.run(printer)
+			if err != nil {
+				t.Fatalf("could not print QR code: %v", err)
+			}
+
+			got := sink.Commands()
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("sent %q instead of %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestQRQuietZoneErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(escpos.Printer) error
+	}{
+		{"centered", func(p escpos.Printer) error {
+			return p.QRCodeCentered("quiet", escpos.QROptions{Size: 3, QuietZone: -1})
+		}},
+		{"fit", func(p escpos.Printer) error { return p.QRCodeFit("quiet", 0, escpos.QROptions{QuietZone: -1}) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.run(printer)
+			if err == nil {
+				t.Fatalf("negative quiet zone did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestQRCodeErrorCorrection(t *testing.T) {
+	// "HELLO WORLD" is 74 bits in alphanumeric mode, which is a version 1 code
+	// from L to Q but doesn't fit H
+	cases := []struct {
+		name string
+		data string
+		opts escpos.QROptions
+		want escpos.QRErrorCorrection
+	}{
+		{"upgraded", "HELLO WORLD", escpos.QROptions{ErrorCorrection: escpos.QRErrorL, AutoUpgradeEC: true}, escpos.QRErrorQ},
+		{"not upgraded", "HELLO WORLD", escpos.QROptions{ErrorCorrection: escpos.QRErrorL}, escpos.QRErrorL},
+		// A code that fills its version keeps its level
+		{"full", strings.Repeat("A", 20), escpos.QROptions{ErrorCorrection: escpos.QRErrorM, AutoUpgradeEC: true}, escpos.QRErrorM},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := escpos.QRCodeErrorCorrection(c.data, c.opts)
+			if err != nil {
+				t.Fatalf("could not choose error correction: %v", err)
+			}
+			if got != c.want {
+				t.Fatalf("error correction was %d instead of %d", got, c.want)
+			}
+		})
+	}
+
+	// Upgrading never changes the size of the code
+	chosen, err := escpos.QRCodeSizeMode("HELLO WORLD", escpos.QRModel2, 1, escpos.QRErrorL, escpos.QRModeAuto)
+	if err != nil {
+		t.Fatalf("could not size QR code: %v", err)
+	}
+	upgraded, err := escpos.QRCodeSizeMode("HELLO WORLD", escpos.QRModel2, 1, escpos.QRErrorQ, escpos.QRModeAuto)
+	if err != nil {
+		t.Fatalf("could not size QR code: %v", err)
+	}
+	if chosen != upgraded || chosen != 21 {
+		t.Fatalf("upgrading changed the size from %d to %d", chosen, upgraded)
+	}
+}
+
+func TestQRCodeCenteredAutoUpgradeEC(t *testing.T) {
+	cases := []struct {
+		upgrade bool
+		want    byte
+	}{
+		{false, '0'},
+		{true, '2'},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.upgrade), func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.QRCodeCentered("HELLO WORLD", escpos.QROptions{AutoUpgradeEC: c.upgrade})
+			if err != nil {
+				t.Fatalf("could not print QR code: %v", err)
+			}
+
+			// The level is sent with function 69
+			for _, cmd := range sink.Decoded() {
+				if cmd.Name == "SYMBOL 49 function 69" {
+					if got := cmd.Data[len(cmd.Data)-1]; got != c.want {
+						t.Fatalf("sent error correction %q instead of %q", got, c.want)
+					}
+					return
+				}
+			}
+			t.Fatalf("no error correction was sent")
+		})
+	}
+}
+
+func TestQRCodeErrorCorrectionErrors(t *testing.T) {
+	// Letters can't be encoded in numeric mode
+	_, err := escpos.QRCodeErrorCorrection("HELLO", escpos.QROptions{AutoUpgradeEC: true, Mode: escpos.QRModeNumeric})
+	if err == nil {
+		t.Fatalf("letters in numeric mode did not fail")
+	}
+}
@@ -0,0 +1,689 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// QROptions controls how QR codes drawn by the library are printed
+type QROptions struct {
+	// Size is the width of a module in dots from 1 to 16, the default is 6
+	Size int
+	// ErrorCorrection is the error correction level of each symbol
+	ErrorCorrection QRErrorCorrection
+	// Mode is how the data is encoded, the default is QRModeAuto
+	Mode QREncodeMode
+	// QuietZone is the number of modules of blank space around the symbol,
+	// scanners want 4 for QR codes.  The printer doesn't add a quiet zone to
+	// 2D codes, so this is done in software by feeding the paper before and
+	// after the symbol and, when the justification is left, moving the
+	// symbol with the left margin.
+	QuietZone int
+	// AutoUpgradeEC raises the error correction to the highest level that
+	// still fits the data in the version picked for ErrorCorrection, so
+	// capacity that would be padding makes the code easier to scan without
+	// making it bigger.  QRCodeErrorCorrection returns the level that is
+	// used.
+	AutoUpgradeEC bool
+}
+
+// qrECCodewords is the number of error correction codewords in each block for
+// each version and error correction level
+var qrECCodewords = [40][4]int{
+	{7, 10, 13, 17}, {10, 16, 22, 28}, {15, 26, 18, 22}, {20, 18, 26, 16},
+	{26, 24, 18, 22}, {18, 16, 24, 28}, {20, 18, 18, 26}, {24, 22, 22, 26},
+	{30, 22, 20, 24}, {18, 26, 24, 28}, {20, 30, 28, 24}, {24, 22, 26, 28},
+	{26, 22, 24, 22}, {30, 24, 20, 24}, {22, 24, 30, 24}, {24, 28, 24, 30},
+	{28, 28, 28, 28}, {30, 26, 28, 28}, {28, 26, 26, 26}, {28, 26, 30, 28},
+	{28, 26, 28, 30}, {28, 28, 30, 24}, {30, 28, 30, 30}, {30, 28, 30, 30},
+	{26, 28, 30, 30}, {28, 28, 28, 30}, {30, 28, 30, 30}, {30, 28, 30, 30},
+	{30, 28, 30, 30}, {30, 28, 30, 30}, {30, 28, 30, 30}, {30, 28, 30, 30},
+	{30, 28, 30, 30}, {30, 28, 30, 30}, {30, 28, 30, 30}, {30, 28, 30, 30},
+	{30, 28, 30, 30}, {30, 28, 30, 30}, {30, 28, 30, 30}, {30, 28, 30, 30},
+}
+
+// qrECBlocks is the number of error correction blocks for each version and
+// error correction level
+var qrECBlocks = [40][4]int{
+	{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 2, 2}, {1, 2, 2, 4},
+	{1, 2, 4, 4}, {2, 4, 4, 4}, {2, 4, 6, 5}, {2, 4, 6, 6},
+	{2, 5, 8, 8}, {4, 5, 8, 8}, {4, 5, 8, 11}, {4, 8, 10, 11},
+	{4, 9, 12, 16}, {4, 9, 16, 16}, {6, 10, 12, 18}, {6, 10, 17, 16},
+	{6, 11, 16, 19}, {6, 13, 18, 21}, {7, 14, 21, 25}, {8, 16, 20, 25},
+	{8, 17, 23, 25}, {9, 17, 23, 34}, {9, 18, 25, 30}, {10, 20, 27, 32},
+	{12, 21, 29, 35}, {12, 23, 34, 37}, {12, 25, 34, 40}, {13, 26, 35, 42},
+	{14, 28, 38, 45}, {15, 29, 40, 48}, {16, 31, 43, 51}, {17, 33, 45, 54},
+	{18, 35, 48, 57}, {19, 37, 51, 60}, {19, 38, 53, 63}, {20, 40, 56, 66},
+	{21, 43, 59, 70}, {22, 45, 62, 74}, {24, 47, 65, 77}, {25, 49, 68, 81},
+}
+
+// qrFormatLevel is the error correction level as it is stored in the format
+// information, which isn't in the same order as the levels
+var qrFormatLevel = [4]int{QRErrorL: 1, QRErrorM: 0, QRErrorQ: 3, QRErrorH: 2}
+
+// qrBitWriter appends bits to codewords with the most significant bit first
+type qrBitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *qrBitWriter) write(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if value>>i&1 == 1 {
+			w.data[w.bits/8] |= 0x80 >> (w.bits % 8)
+		}
+		w.bits++
+	}
+}
+
+// gfMultiply multiplies in the Galois field used by the QR error correction
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// qrErrorCorrection returns the Reed-Solomon error correction codewords of
+// the data
+func qrErrorCorrection(data []byte, length int) []byte {
+	// The generator polynomial without the leading 1
+	divisor := make([]byte, length)
+	divisor[length-1] = 1
+	root := byte(1)
+	for i := 0; i < length; i++ {
+		for j := range divisor {
+			divisor[j] = gfMultiply(divisor[j], root)
+			if j+1 < length {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+
+	result := make([]byte, length)
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[length-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// qrRawModules returns the number of modules in a version that can hold
+// codewords
+func qrRawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// qrAlignmentPositions returns the centers of the alignment patterns
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	align := version/7 + 2
+	step := (version*8 + align*3 + 5) / (align*4 - 4) * 2
+	positions := make([]int, align)
+	positions[0] = 6
+	for i, pos := align-1, version*4+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// qrAppendCodewords adds the error correction to the data codewords and
+// interleaves the blocks
+func qrAppendCodewords(data []byte, version int, ecLevel QRErrorCorrection) []byte {
+	numBlocks := qrECBlocks[version-1][ecLevel]
+	ecLength := qrECCodewords[version-1][ecLevel]
+	total := qrRawModules(version) / 8
+	shortBlocks := numBlocks - total%numBlocks
+	shortLength := total/numBlocks - ecLength
+
+	var blocks, ecBlocks [][]byte
+	for i := 0; i < numBlocks; i++ {
+		length := shortLength
+		if i >= shortBlocks {
+			length++
+		}
+		blocks = append(blocks, data[:length])
+		ecBlocks = append(ecBlocks, qrErrorCorrection(data[:length], ecLength))
+		data = data[length:]
+	}
+
+	var result []byte
+	for i := 0; i <= shortLength; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < ecLength; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// qrMatrix holds the modules of a QR code with true as dark
+type qrMatrix struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := version*4 + 17
+	m := &qrMatrix{size: size}
+	for i := 0; i < size; i++ {
+		m.modules = append(m.modules, make([]bool, size))
+		m.function = append(m.function, make([]bool, size))
+	}
+	return m
+}
+
+func (m *qrMatrix) setFunction(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.function[y][x] = true
+}
+
+// qrDistance returns how many rings out from the center of a pattern dx, dy is
+func qrDistance(dx, dy int) int {
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// drawPatterns draws the finder, alignment, and timing patterns and the
+// version information
+func (m *qrMatrix) drawPatterns(version int) {
+	for i := 0; i < m.size; i++ {
+		m.setFunction(6, i, i%2 == 0)
+		m.setFunction(i, 6, i%2 == 0)
+	}
+
+	for _, center := range [][2]int{{3, 3}, {m.size - 4, 3}, {3, m.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x < 0 || y < 0 || x >= m.size || y >= m.size {
+					continue
+				}
+				dist := qrDistance(dx, dy)
+				m.setFunction(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	positions := qrAlignmentPositions(version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// The corners with finder patterns don't get alignment patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.setFunction(x+dx, y+dy, qrDistance(dx, dy) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format information so the codewords skip it
+	m.drawFormat(0)
+
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := m.size-11+i%3, i/3
+			m.setFunction(a, b, dark)
+			m.setFunction(b, a, dark)
+		}
+	}
+}
+
+// drawFormat draws the format information for the error correction level
+// and mask, with format as the 15 bits with the BCH code
+func (m *qrMatrix) drawFormat(format int) {
+	bit := func(i int) bool { return format>>i&1 == 1 }
+
+	for i := 0; i < 6; i++ {
+		m.setFunction(8, i, bit(i))
+	}
+	m.setFunction(8, 7, bit(6))
+	m.setFunction(8, 8, bit(7))
+	m.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.setFunction(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.setFunction(8, m.size-15+i, bit(i))
+	}
+	m.setFunction(8, m.size-8, true)
+}
+
+// qrFormatBits returns the 15 bit format information
+func qrFormatBits(ecLevel QRErrorCorrection, mask int) int {
+	data := qrFormatLevel[ecLevel]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawCodewords places the codewords in the zigzag going up and down two
+// columns at a time from the bottom right
+func (m *qrMatrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		// Skip the vertical timing pattern
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert
+				}
+				if m.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				m.modules[y][x] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// qrMaskBit reports if the mask flips the module at x, y
+func qrMaskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask flips the modules that aren't part of a pattern
+func (m *qrMatrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if !m.function[y][x] && qrMaskBit(mask, x, y) {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the matrix is to scan, lower is better
+func (m *qrMatrix) penalty() int {
+	score := 0
+	dark := 0
+
+	// line returns the i-th module of row or column n
+	for _, line := range []func(n, i int) bool{
+		func(n, i int) bool { return m.modules[n][i] },
+		func(n, i int) bool { return m.modules[i][n] },
+	} {
+		for n := 0; n < m.size; n++ {
+			run := 1
+			for i := 1; i <= m.size; i++ {
+				if i < m.size && line(n, i) == line(n, i-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+
+			// Patterns that look like a finder with light on one side
+			for i := 0; i+11 <= m.size; i++ {
+				pattern := 0
+				for j := 0; j < 11; j++ {
+					pattern <<= 1
+					if line(n, i+j) {
+						pattern |= 1
+					}
+				}
+				if pattern == 0b10111010000 || pattern == 0b00001011101 {
+					score += 40
+				}
+			}
+		}
+	}
+
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.modules[y][x]
+				if c == m.modules[y][x+1] && c == m.modules[y+1][x] && c == m.modules[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+
+	total := m.size * m.size
+	balance := dark*20 - total*10
+	if balance < 0 {
+		balance = -balance
+	}
+	score += balance / total * 10
+	return score
+}
+
+// qrStructuredAppend is the structured append header of a symbol
+type qrStructuredAppend struct {
+	// position is the 0 based position of the symbol
+	position int
+	// total is the number of symbols
+	total int
+	// parity is the XOR of every byte of the whole data
+	parity byte
+}
+
+// qrSplit splits the data into symbols of up to maxPerSymbol bytes each and
+// returns the structured append header of each symbol
+func qrSplit(data string, maxPerSymbol int) ([]string, []qrStructuredAppend) {
+	parity := byte(0)
+	for i := 0; i < len(data); i++ {
+		parity ^= data[i]
+	}
+
+	var parts []string
+	for len(data) > maxPerSymbol {
+		parts = append(parts, data[:maxPerSymbol])
+		data = data[maxPerSymbol:]
+	}
+	parts = append(parts, data)
+
+	headers := make([]qrStructuredAppend, len(parts))
+	for i := range parts {
+		headers[i] = qrStructuredAppend{position: i, total: len(parts), parity: parity}
+	}
+	return parts, headers
+}
+
+// encodeQR encodes the data in byte mode after the structured append header
+// into the smallest version that fits, and a header with a total of 0 isn't
+// written.  When upgrade is set the error correction is raised to the
+// highest level that fits the same version.
+func encodeQR(data string, header qrStructuredAppend, ecLevel QRErrorCorrection, upgrade bool) (*qrMatrix, error) {
+	// The structured append header is 20 bits before the byte mode segment
+	headerBits := 20
+	if header.total == 0 {
+		headerBits = 0
+	}
+	bits := func(version int) int { return headerBits + qrBits(data, version, QRModeByte) }
+
+	version, ok := qrVersion(bits, ecLevel)
+	if !ok {
+		return nil, fmt.Errorf("%d bytes of data doesn't fit in a QR code with error correction %d", len(data), ecLevel)
+	}
+	if upgrade {
+		ecLevel = qrUpgradeLevel(bits, version, ecLevel)
+	}
+
+	codewords := qrAppendCodewords(qrData(data, header, version, ecLevel), version, ecLevel)
+
+	var best *qrMatrix
+	bestPenalty := 0
+	for mask := 0; mask < 8; mask++ {
+		m := newQRMatrix(version)
+		m.drawPatterns(version)
+		m.drawCodewords(codewords)
+		m.applyMask(mask)
+		m.drawFormat(qrFormatBits(ecLevel, mask))
+
+		penalty := m.penalty()
+		if best == nil || penalty < bestPenalty {
+			best, bestPenalty = m, penalty
+		}
+	}
+	return best, nil
+}
+
+// qrData writes the structured append header and the data in byte mode, and
+// pads it to the data codewords of the version
+func qrData(data string, header qrStructuredAppend, version int, ecLevel QRErrorCorrection) []byte {
+	capacity := qrDataCodewords[version-1][ecLevel] * 8
+	w := &qrBitWriter{}
+
+	// Structured append mode with the position, total, and parity
+	if header.total > 0 {
+		w.write(0b0011, 4)
+		w.write(header.position, 4)
+		w.write(header.total-1, 4)
+		w.write(int(header.parity), 8)
+	}
+
+	// Byte mode
+	w.write(0b0100, 4)
+	if version >= 10 {
+		w.write(len(data), 16)
+	} else {
+		w.write(len(data), 8)
+	}
+	for i := 0; i < len(data); i++ {
+		w.write(int(data[i]), 8)
+	}
+
+	// Terminator, then padding to a whole codeword
+	terminator := capacity - w.bits
+	if terminator > 4 {
+		terminator = 4
+	}
+	w.write(0, terminator)
+	w.write(0, (8-w.bits%8)%8)
+	for pad := 0xEC; w.bits < capacity; pad ^= 0xEC ^ 0x11 {
+		w.write(pad, 8)
+	}
+	return w.data
+}
+
+// image draws the matrix with size dots per module and a 4 module quiet zone
+func (m *qrMatrix) image(size int) *image.Gray {
+	width := (m.size + 8) * size
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			mx, my := x/size-4, y/size-4
+			c := color.White
+			if mx >= 0 && my >= 0 && mx < m.size && my < m.size && m.modules[my][mx] {
+				c = color.Black
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// QRCodeStructuredAppend prints data that is too long for one QR code as a
+// chain of up to 16 QR codes with structured append.  The data is split into
+// symbols of up to maxPerSymbol bytes, and scanners that support structured
+// append put the data back together in order.
+//
+// The printer QR code functions can't do structured append, so the symbols
+// are drawn by the library and printed with PrintImageRaster one after the
+// other.  The symbols are model 2 in byte mode, so the Mode of opts is
+// ignored.
+func (p Printer) QRCodeStructuredAppend(data string, maxPerSymbol int, opts QROptions) error {
+	errMsg := "could not print structured append QR code: %w"
+
+	if opts.Size == 0 {
+		opts.Size = 6
+	}
+
+	err := checkRange(opts.Size, 1, 16, "size")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(opts.ErrorCorrection, QRErrorL, QRErrorM, QRErrorQ, QRErrorH)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(maxPerSymbol, 1, 2953, "max bytes per symbol")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(opts.QuietZone, 0, 255, "quiet zone")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if len(data) == 0 {
+		return fmt.Errorf(errMsg, fmt.Errorf("data is empty"))
+	}
+
+	parts, headers := qrSplit(data, maxPerSymbol)
+	if len(parts) > 16 {
+		return fmt.Errorf(errMsg, fmt.Errorf("%d symbols are needed but structured append can only have 16", len(parts)))
+	}
+
+	// Encode every symbol before printing so nothing is printed when one
+	// doesn't fit
+	var images []image.Image
+	for i, part := range parts {
+		m, err := encodeQR(part, headers[i], opts.ErrorCorrection, opts.AutoUpgradeEC)
+		if err != nil {
+			return fmt.Errorf(errMsg, fmt.Errorf("symbol %d: %w", i+1, err))
+		}
+		images = append(images, m.image(opts.Size))
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	for _, img := range images {
+		err = p.qrQuietZone(opts.QuietZone, opts.Size, func() error { return p.PrintImageRaster(img, RasterNormal) })
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+	return nil
+}
+
+// SmartQR prints the data as a model 2 QR code with the printer when the
+// SupportsQR of the profile is set, and otherwise draws it and prints it as
+// an image, so the same code works on printers without QR codes.  Like
+// QROptions the size defaults to 6.  ProfileUnknown doesn't set SupportsQR so
+// it gets the image, since that works on every printer.
+//
+// The drawn QR code is in byte mode so the Mode of opts is ignored, and it is
+// drawn with a 4 module quiet zone on top of the QuietZone of opts.
+func (p Printer) SmartQR(data string, opts QROptions) error {
+	errMsg := "could not print QR code: %w"
+
+	if opts.Size == 0 {
+		opts.Size = 6
+	}
+
+	err := checkRange(opts.Size, 1, 16, "size")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(opts.ErrorCorrection, QRErrorL, QRErrorM, QRErrorQ, QRErrorH)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(opts.QuietZone, 0, 255, "quiet zone")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	render := func() error {
+		err := opts.upgradeEC(data)
+		if err != nil {
+			return err
+		}
+		return p.QRCodeMode(data, QRModel2, opts.Size, opts.ErrorCorrection, opts.Mode)
+	}
+
+	if !p.Profile().SupportsQR {
+		err = checkRange(len(data), 1, 2953, "data length")
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		m, err := encodeQR(data, qrStructuredAppend{}, opts.ErrorCorrection, opts.AutoUpgradeEC)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		img := m.image(opts.Size)
+		render = func() error { return p.PrintImageRaster(img, RasterNormal) }
+	}
+
+	err = p.qrQuietZone(opts.QuietZone, opts.Size, render)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
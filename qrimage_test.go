@@ -0,0 +1,59 @@
+package escpos_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// noCodes is a profile that has to draw its QR and bar codes
+func noCodes() escpos.Profile {
+	profile := escpos.ProfileGeneric58
+	profile.Name = "No codes 58mm"
+	profile.SupportsBarCode = false
+	return profile
+}
+
+func TestSmartQR(t *testing.T) {
+	cases := []struct {
+		profile escpos.Profile
+		want    []string
+	}{
+		{
+			escpos.ProfileHoin,
+			[]string{"SYMBOL 49 function 65", "SYMBOL 49 function 67", "SYMBOL 49 function 69", "SYMBOL 49 function 80", "SYMBOL 49 function 81"},
+		},
+		// The version 1 code is 21 modules and a quiet zone of 4 on each side
+		// at 6 dots a module
+		{noCodes(), []string{"RASTER IMAGE mode 0 176x174", "STATUS 3"}},
+		{escpos.ProfileUnknown, []string{"RASTER IMAGE mode 0 176x174", "STATUS 3"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.profile.Name, func(t *testing.T) {
+			sink, printer := newPrinter(c.profile)
+
+			err := printer.SmartQR("HELLO", escpos.QROptions{})
+			if err != nil {
+				t.Fatalf("could not print QR code: %v", err)
+			}
+			if got := sink.Commands(); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("sent %q instead of %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSmartQRTooWide(t *testing.T) {
+	_, printer := newPrinter(noCodes())
+
+	// The drawn code is the same as one symbol without structured append
+	// would be, so it has to be in the width of the paper
+	err := printer.SmartQR(strings.Repeat("x", 500), escpos.QROptions{})
+	if !errors.Is(err, escpos.ErrImageTooWide) {
+		t.Fatalf("a big drawn QR code returned %v instead of ErrImageTooWide", err)
+	}
+}
@@ -0,0 +1,203 @@
+package escpos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ReconnectingConn is a tcp connection to a printer that dials the printer
+// again when a write fails because the connection was dropped.  After
+// reconnecting the printer is initialized so it doesn't keep any formatting
+// from before the connection dropped.
+type ReconnectingConn struct {
+	Addr string
+	// Retries is how many times to reconnect before returning the error
+	Retries int
+	// Backoff is how long to wait before the first reconnect.  The wait
+	// doubles after every failed reconnect.
+	Backoff time.Duration
+	// Dial is used to connect to the printer, net.Dial is used if it is nil
+	Dial func(network, addr string) (net.Conn, error)
+
+	conn net.Conn
+}
+
+// NewReconnectingIpPrinter connects to the printer at addr with a
+// ReconnectingConn
+func NewReconnectingIpPrinter(addr string, retries int, backoff time.Duration) (Printer, error) {
+	rc := &ReconnectingConn{
+		Addr:    addr,
+		Retries: retries,
+		Backoff: backoff,
+	}
+
+	err := rc.dial()
+	if err != nil {
+		return Printer{}, err
+	}
+	return NewPrinter(rc), nil
+}
+
+// isConnBroken reports if the error is from the connection being dropped
+func isConnBroken(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, io.EOF)
+}
+
+func (rc *ReconnectingConn) dial() error {
+	dial := rc.Dial
+	if dial == nil {
+		dial = net.Dial
+	}
+
+	conn, err := dial("tcp", rc.Addr)
+	if err != nil {
+		return fmt.Errorf("unable to dial %s: %w", rc.Addr, err)
+	}
+	rc.conn = conn
+	return nil
+}
+
+// reconnect closes the old connection, dials the printer again, and
+// initializes it
+func (rc *ReconnectingConn) reconnect() error {
+	if rc.conn != nil {
+		rc.conn.Close()
+		rc.conn = nil
+	}
+
+	err := rc.dial()
+	if err != nil {
+		return err
+	}
+
+	_, err = rc.conn.Write([]byte{ESC, '@'})
+	if err != nil {
+		return fmt.Errorf("could not initialize printer after reconnecting: %w", err)
+	}
+	return nil
+}
+
+// Write writes b to the printer, and reconnects when the connection was
+// dropped.  After reconnecting only the bytes that weren't written yet are
+// sent, so n counts each byte once like io.Writer.  The bytes the old
+// connection took before it dropped may not have reached the printer, since
+// tcp doesn't say what the other side got, so part of a job can be missing
+// after a reconnect.
+func (rc *ReconnectingConn) Write(b []byte) (int, error) {
+	if rc.conn == nil {
+		err := rc.dial()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	written, err := rc.conn.Write(b)
+	if err == nil || !isConnBroken(err) {
+		return written, err
+	}
+
+	delay := rc.Backoff
+	for i := 0; i < rc.Retries; i++ {
+		time.Sleep(delay)
+		delay *= 2
+
+		rerr := rc.reconnect()
+		if rerr != nil {
+			err = rerr
+			continue
+		}
+
+		var n int
+		n, err = rc.conn.Write(b[written:])
+		written += n
+		if err == nil || !isConnBroken(err) {
+			return written, err
+		}
+	}
+
+	return written, fmt.Errorf("could not reconnect after %d retries: %w", rc.Retries, err)
+}
+
+func (rc *ReconnectingConn) Read(b []byte) (int, error) {
+	if rc.conn == nil {
+		err := rc.dial()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return rc.conn.Read(b)
+}
+
+func (rc *ReconnectingConn) Close() error {
+	if rc.conn == nil {
+		return nil
+	}
+
+	err := rc.conn.Close()
+	rc.conn = nil
+	return err
+}
+
+// How long Ping waits to see if the connection was closed
+const pingTimeout = 10 * time.Millisecond
+
+// Ping checks that the tcp connection to the printer is still up without
+// sending anything to it.  It reads from the connection for a moment, and an
+// error is returned when the read finds that the printer closed the
+// connection.  Anything the printer sent is kept for the next status read.
+//
+// A ReconnectingConn that isn't connected right now fails the ping.
+// Connections that aren't a net.Conn or ReconnectingConn, like USB and serial
+// printers, can't be checked this way and always pass.
+func (p Printer) Ping() error {
+	errMsg := "printer is not connected: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	var conn net.Conn
+	switch dst := p.dst.(type) {
+	case net.Conn:
+		conn = dst
+	case *ReconnectingConn:
+		if dst.conn == nil {
+			return fmt.Errorf(errMsg, net.ErrClosed)
+		}
+		conn = dst.conn
+	default:
+		return nil
+	}
+
+	// A read that is waiting on the printer would take the response
+	if p.config != nil && p.config.pendingRead != nil {
+		return nil
+	}
+
+	err := conn.SetReadDeadline(time.Now().Add(pingTimeout))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	data := make([]byte, 1)
+	n, err := conn.Read(data)
+	if n > 0 && p.config != nil {
+		pending := make(chan readResult, 1)
+		pending <- readResult{data: data[:n]}
+		p.config.pendingRead = pending
+	}
+
+	if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
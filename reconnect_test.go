@@ -0,0 +1,186 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// fakeConn is a net.Conn that keeps what is written in got.  When err is set
+// it takes at most accept bytes of each write and fails with err.
+type fakeConn struct {
+	net.Conn
+	got    bytes.Buffer
+	accept int
+	err    error
+}
+
+func (c *fakeConn) Write(b []byte) (int, error) {
+	if c.err == nil {
+		return c.got.Write(b)
+	}
+	n := c.accept
+	if n > len(b) {
+		n = len(b)
+	}
+	c.got.Write(b[:n])
+	return n, c.err
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+// dialer returns the connections in order, and fails when it runs out
+func dialer(conns ...*fakeConn) (func(network, addr string) (net.Conn, error), *int) {
+	calls := new(int)
+	return func(network, addr string) (net.Conn, error) {
+		*calls++
+		if len(conns) == 0 {
+			return nil, syscall.ECONNREFUSED
+		}
+		conn := conns[0]
+		conns = conns[1:]
+		return conn, nil
+	}, calls
+}
+
+func TestReconnectingConnWrite(t *testing.T) {
+	data := []byte("receipt")
+
+	cases := []struct {
+		name   string
+		broken *fakeConn
+		sent   string
+	}{
+		{"nothing written", &fakeConn{err: syscall.EPIPE}, ""},
+		{"partial write", &fakeConn{accept: 3, err: syscall.EPIPE}, "rec"},
+		{"connection reset", &fakeConn{accept: 2, err: syscall.ECONNRESET}, "re"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			good := &fakeConn{}
+			dial, calls := dialer(c.broken, good)
+			rc := &escpos.ReconnectingConn{Addr: "printer:9100", Retries: 2, Dial: dial}
+
+			n, err := rc.Write(data)
+			if err != nil {
+				t.Fatalf("write failed after reconnecting: %v", err)
+			}
+			if n != len(data) {
+				t.Fatalf("write returned %d instead of %d", n, len(data))
+			}
+			if *calls != 2 {
+				t.Fatalf("dialed %d times instead of 2", *calls)
+			}
+
+			if c.broken.got.String() != c.sent {
+				t.Fatalf("broken connection got %q instead of %q", c.broken.got.String(), c.sent)
+			}
+
+			// The printer is initialized and only gets what wasn't written
+			want := append([]byte{escpos.ESC, '@'}, data[len(c.sent):]...)
+			if !bytes.Equal(good.got.Bytes(), want) {
+				t.Fatalf("new connection got %q instead of %q", good.got.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestReconnectingConnWriteFails(t *testing.T) {
+	t.Run("retries run out", func(t *testing.T) {
+		dial, calls := dialer(&fakeConn{err: syscall.EPIPE})
+		rc := &escpos.ReconnectingConn{Addr: "printer:9100", Retries: 3, Dial: dial}
+
+		_, err := rc.Write([]byte("receipt"))
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			t.Fatalf("write returned %v instead of the dial error", err)
+		}
+		if *calls != 4 {
+			t.Fatalf("dialed %d times instead of 4", *calls)
+		}
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		errOther := errors.New("printer on fire")
+		dial, calls := dialer(&fakeConn{err: errOther}, &fakeConn{})
+		rc := &escpos.ReconnectingConn{Addr: "printer:9100", Retries: 3, Dial: dial}
+
+		_, err := rc.Write([]byte("receipt"))
+		if !errors.Is(err, errOther) {
+			t.Fatalf("write returned %v instead of the write error", err)
+		}
+		if *calls != 1 {
+			t.Fatalf("dialed %d times instead of once", *calls)
+		}
+	})
+}
+
+func TestPing(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	printer := escpos.NewPrinter(client)
+
+	err := printer.Ping()
+	if err != nil {
+		t.Fatalf("ping failed while connected: %v", err)
+	}
+
+	// What the printer sends is kept for the next status read
+	sent := make(chan struct{})
+	go func() {
+		server.Write([]byte{0x16})
+		close(sent)
+		io.ReadFull(server, make([]byte, 3))
+	}()
+
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		err = printer.Ping()
+		if err != nil {
+			t.Fatalf("ping failed while the printer was sending: %v", err)
+		}
+
+		select {
+		case <-sent:
+			done = true
+		case <-timeout:
+			t.Fatalf("ping never read what the printer sent")
+		default:
+		}
+	}
+
+	status, err := printer.TransmitPrinterStatus()
+	if err != nil {
+		t.Fatalf("could not read status: %v", err)
+	}
+	if !status.DrawerOpen || status.Offline {
+		t.Fatalf("status read after the ping was %+v instead of the drawer being open", status)
+	}
+
+	server.Close()
+
+	err = printer.Ping()
+	if err == nil {
+		t.Fatalf("ping passed after the printer hung up")
+	}
+}
+
+func TestPingUnchecked(t *testing.T) {
+	err := escpos.NewPrinter(&escpos.ReconnectingConn{Addr: "127.0.0.1:9100"}).Ping()
+	if err == nil {
+		t.Fatalf("ping of a reconnecting conn that isn't connected did not fail")
+	}
+
+	_, printer := escpos.NewCapturePrinter()
+	err = printer.Ping()
+	if err != nil {
+		t.Fatalf("ping of a connection that can't be checked failed: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package escpos
+
+// SetAutoRecover turns on recovering from printer errors while writing.  When
+// a write fails the error status is read with RealtimeStatus, and if the
+// printer has a recoverable error, like an auto cutter jam that was cleared,
+// RequestRecover is sent and the rest of the write is sent again.  This is
+// tried up to maxRetries times before the write error is returned.
+//
+// Unrecoverable errors, and printers that don't send back a status, return
+// the write error right away.  A maxRetries of 0 turns it off, which is the
+// default.
+func (p Printer) SetAutoRecover(maxRetries int) {
+	if p.config == nil {
+		return
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.autoRecover = maxRetries
+}
+
+// writeRecover writes to the printer and retries with recoverError when the
+// write fails and auto recover is on
+func (p Printer) writeRecover(b []byte) (int, error) {
+	n, err := p.write(b)
+
+	if p.config == nil || p.config.recovering {
+		return n, err
+	}
+
+	for retry := 0; err != nil && retry < p.config.autoRecover; retry++ {
+		if !p.recoverError() {
+			break
+		}
+
+		var m int
+		m, err = p.write(b[n:])
+		n += m
+	}
+	return n, err
+}
+
+// recoverError sends RequestRecover if the error status says the printer has
+// an error that can be recovered from
+func (p Printer) recoverError() bool {
+	p.config.recovering = true
+	defer func() { p.config.recovering = false }()
+
+	status, err := p.RealtimeStatus(StatusError)
+	if err != nil || status.UnRecoverable {
+		return false
+	}
+	if !status.AutoCutter && !status.Mechanical && !status.AutoRecoverable {
+		return false
+	}
+
+	return p.RealtimeRequest(RequestRecover) == nil
+}
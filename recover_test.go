@@ -0,0 +1,92 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+var errJam = errors.New("auto cutter jam")
+
+// recoverConn fails the first write like a printer with an auto cutter jam
+// and answers the error status request with status
+type recoverConn struct {
+	status byte
+	failed bool
+	reply  []byte
+	writes [][]byte
+}
+
+func (c *recoverConn) Write(b []byte) (int, error) {
+	if !c.failed {
+		c.failed = true
+		return 0, errJam
+	}
+
+	c.writes = append(c.writes, append([]byte{}, b...))
+	if bytes.Equal(b, []byte{escpos.DLE, 0x04, 3}) {
+		c.reply = append(c.reply, c.status)
+	}
+	return len(b), nil
+}
+
+func (c *recoverConn) Read(b []byte) (int, error) {
+	if len(c.reply) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.reply)
+	c.reply = c.reply[n:]
+	return n, nil
+}
+
+func (c *recoverConn) Close() error { return nil }
+
+func TestAutoRecover(t *testing.T) {
+	data := []byte("recovered\n")
+	recoverRequest := []byte{escpos.DLE, 0x05, 1}
+
+	// 0x12 are the fixed bits of the status and 0x08 is the auto cutter error
+	conn := &recoverConn{status: 0x12 | 0b0000_1000}
+	printer := escpos.NewPrinter(conn)
+	printer.SetAutoRecover(2)
+
+	_, err := printer.Write(data)
+	if err != nil {
+		t.Fatalf("write did not succeed after recovering: %v", err)
+	}
+
+	want := [][]byte{{escpos.DLE, 0x04, 3}, recoverRequest, data}
+	if !reflect.DeepEqual(conn.writes, want) {
+		t.Fatalf("auto recover sent % x instead of % x", conn.writes, want)
+	}
+}
+
+func TestAutoRecoverUnrecoverable(t *testing.T) {
+	// 0x20 is an unrecoverable error
+	conn := &recoverConn{status: 0x12 | 0b0010_0000}
+	printer := escpos.NewPrinter(conn)
+	printer.SetAutoRecover(2)
+
+	_, err := printer.Write([]byte("recovered\n"))
+	if !errors.Is(err, errJam) {
+		t.Fatalf("unrecoverable error returned %v instead of the write error", err)
+	}
+	for _, w := range conn.writes {
+		if bytes.Equal(w, []byte{escpos.DLE, 0x05, 1}) {
+			t.Fatalf("recover was sent for an unrecoverable error")
+		}
+	}
+}
+
+func TestAutoRecoverOff(t *testing.T) {
+	conn := &recoverConn{status: 0x12 | 0b0000_1000}
+
+	_, err := escpos.NewPrinter(conn).Write([]byte("recovered\n"))
+	if !errors.Is(err, errJam) || len(conn.writes) != 0 {
+		t.Fatalf("write without auto recover did not fail right away, got %v after % x", err, conn.writes)
+	}
+}
@@ -0,0 +1,198 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// FitOptions controls how PrintImageFitOpts scales an image
+type FitOptions struct {
+	// Width is the width in pixels to fit the image to.  A width of 0 uses
+	// the profile dot width, or half of it at SingleDensity.
+	Width int
+	// Upscale lets images smaller than the width be scaled up
+	Upscale bool
+}
+
+// scaleImage resizes the image to width pixels keeping the aspect ratio.  Each
+// pixel is the average of the source pixels it covers, which keeps thin lines
+// in logos from disappearing when scaling down.  Transparent pixels are put
+// on white.
+func scaleImage(img image.Image, width int) *image.Gray {
+	src := img.Bounds()
+	height := (src.Dy()*width + src.Dx()/2) / src.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := src.Min.Y + (y+1)*src.Dy()/height
+		if y1 == y0 {
+			y1++
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := src.Min.X + (x+1)*src.Dx()/width
+			if x1 == x0 {
+				x1++
+			}
+
+			sum, n := 0, 0
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += grayLevel(img.At(sx, sy), false, Rec601)
+					n++
+				}
+			}
+			dst.SetGray(x, y, color.Gray{Y: uint8(sum / n)})
+		}
+	}
+
+	return dst
+}
+
+// croppedImage is an image with smaller bounds, for images that don't have a
+// SubImage method
+type croppedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+func (c croppedImage) Bounds() image.Rectangle { return c.bounds }
+
+// cropImage returns the part of the image in r.  Images with a SubImage
+// method, like image.Gray and image.RGBA, keep their type so they are still
+// read from their pixels directly.
+func cropImage(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	return croppedImage{img, r}
+}
+
+// PrintImageRegion prints the part of the image inside region with
+// PrintImage24, like one label from a sheet of labels.  The region is in the
+// coordinates of the image and must be inside its bounds, and the width of
+// the region must fit on the paper.
+func (p Printer) PrintImageRegion(img image.Image, region image.Rectangle, density Density) error {
+	errMsg := "could not print image region: %w"
+
+	if region.Empty() {
+		return fmt.Errorf(errMsg, fmt.Errorf("region %v is empty", region))
+	}
+
+	if !region.In(img.Bounds()) {
+		return fmt.Errorf(errMsg, fmt.Errorf("region %v is not inside the image bounds %v", region, img.Bounds()))
+	}
+
+	err := p.checkImageWidth(region.Dx(), density)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.PrintImage24(cropImage(img, region), density)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// fitOverflow crops or scales an image that is too wide for the paper with
+// the OnOverflow of the options.  Images that fit are returned as they are.
+func (p Printer) fitOverflow(img image.Image, density Density, opts ImageOptions) (image.Image, error) {
+	err := p.checkImageWidth(img.Bounds().Dx(), density)
+	if err == nil || opts.OnOverflow == ImageOverflowError {
+		return img, err
+	}
+
+	maxWidth := p.MaxWidthDots()
+	if density == SingleDensity {
+		maxWidth /= 2
+	}
+
+	bounds := img.Bounds()
+	warn := func(format string, a ...any) {
+		if opts.WarnFunc != nil {
+			opts.WarnFunc(fmt.Sprintf(format, a...))
+		}
+	}
+
+	switch opts.OnOverflow {
+	case ImageOverflowCropLeft, ImageOverflowCropCenter:
+		left := bounds.Min.X
+		if opts.OnOverflow == ImageOverflowCropCenter {
+			left += (bounds.Dx() - maxWidth) / 2
+		}
+
+		warn("image is %d dots wide and was cropped to %d", bounds.Dx(), maxWidth)
+		return cropImage(img, image.Rect(left, bounds.Min.Y, left+maxWidth, bounds.Max.Y)), nil
+	}
+
+	warn("image is %d dots wide and was scaled to %d", bounds.Dx(), maxWidth)
+	return scaleImage(img, maxWidth), nil
+}
+
+// fitImage scales the image to fit the width from the options.  The image is
+// returned as is when it already fits.
+func (p Printer) fitImage(img image.Image, density Density, opts FitOptions) (image.Image, error) {
+	width := opts.Width
+	if width == 0 {
+		width = p.MaxWidthDots()
+		if density == SingleDensity {
+			width /= 2
+		}
+	}
+
+	if width < 1 {
+		return nil, fmt.Errorf("width must be more than 0")
+	}
+
+	err := p.checkImageWidth(width, density)
+	if err != nil {
+		return nil, err
+	}
+
+	if img.Bounds().Empty() {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	if img.Bounds().Dx() == width || (img.Bounds().Dx() < width && !opts.Upscale) {
+		return img, nil
+	}
+	return scaleImage(img, width), nil
+}
+
+// PrintImageFit prints the image with PrintImage24 after scaling it down to
+// fit the width of the paper.  Images that already fit are printed as they
+// are.
+func (p Printer) PrintImageFit(img image.Image, density Density) error {
+	return p.PrintImageFitOpts(img, density, FitOptions{})
+}
+
+// PrintImageFitOpts works the same as PrintImageFit() but opts can set the
+// width to fit the image to and allow scaling small images up.
+func (p Printer) PrintImageFitOpts(img image.Image, density Density, opts FitOptions) error {
+	errMsg := "could not print fitted image: %w"
+
+	err := checkEnum(density, SingleDensity, DoubleDensity)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	img, err = p.fitImage(img, density, opts)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.PrintImage24(img, density)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
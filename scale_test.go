@@ -0,0 +1,192 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// imageSize returns the width of the first ESC * band and the number of bands
+// in the data
+func imageSize(data []byte) (int, int) {
+	i := bytes.Index(data, []byte{escpos.ESC, '*'})
+	if i < 0 || i+5 > len(data) {
+		return 0, 0
+	}
+	return int(data[i+3]) | int(data[i+4])<<8, bytes.Count(data, []byte{escpos.ESC, '*'})
+}
+
+func TestPrintImageFit(t *testing.T) {
+	cases := []struct {
+		name          string
+		width, height int
+		density       escpos.Density
+		opts          escpos.FitOptions
+		wantWidth     int
+		wantBands     int
+	}{
+		{"oversized", 1152, 200, escpos.DoubleDensity, escpos.FitOptions{}, 576, 5},
+		{"oversized single density", 1152, 96, escpos.SingleDensity, escpos.FitOptions{}, 288, 1},
+		{"smaller", 100, 48, escpos.DoubleDensity, escpos.FitOptions{}, 100, 2},
+		{"exact", 576, 24, escpos.DoubleDensity, escpos.FitOptions{}, 576, 1},
+		{"smaller than width", 100, 48, escpos.DoubleDensity, escpos.FitOptions{Width: 200}, 100, 2},
+		{"upscale", 100, 48, escpos.DoubleDensity, escpos.FitOptions{Width: 200, Upscale: true}, 200, 4},
+		{"width", 1152, 200, escpos.DoubleDensity, escpos.FitOptions{Width: 300}, 300, 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			img := image.NewGray(image.Rect(0, 0, c.width, c.height))
+			var err error
+			if c.opts == (escpos.FitOptions{}) {
+				err = printer.PrintImageFit(img, c.density)
+			} else {
+				err = printer.PrintImageFitOpts(img, c.density, c.opts)
+			}
+			if err != nil {
+				t.Fatalf("could not print image: %v", err)
+			}
+
+			width, bands := imageSize(sink.Bytes())
+			if width != c.wantWidth || bands != c.wantBands {
+				t.Fatalf("printed %d bands %d dots wide instead of %d bands %d dots wide", bands, width, c.wantBands, c.wantWidth)
+			}
+		})
+	}
+}
+
+func TestPrintImageFitErrors(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 1152, 200))
+
+	cases := []struct {
+		name    string
+		img     image.Image
+		density escpos.Density
+		opts    escpos.FitOptions
+	}{
+		{"density", img, escpos.Density(2), escpos.FitOptions{}},
+		{"negative width", img, escpos.DoubleDensity, escpos.FitOptions{Width: -1}},
+		{"width too wide", img, escpos.DoubleDensity, escpos.FitOptions{Width: 577}},
+		{"width too wide single density", img, escpos.SingleDensity, escpos.FitOptions{Width: 289}},
+		{"empty", image.NewGray(image.Rectangle{}), escpos.DoubleDensity, escpos.FitOptions{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImageFitOpts(c.img, c.density, c.opts)
+			if err == nil {
+				t.Fatal("image did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+const labelW, labelH = 120, 50
+
+// sheetLabel makes the label at col and row of a sheet, with each label
+// having its own pattern
+func sheetLabel(col, row int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, labelW, labelH))
+	for y := 0; y < labelH; y++ {
+		for x := 0; x < labelW; x++ {
+			c := color.White
+			if (x/(col+2)+y/(row+3))%2 == 0 {
+				c = color.Black
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// sheet makes a sheet of 3 by 2 labels
+func sheet() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, labelW*3, labelH*2))
+	for row := 0; row < 2; row++ {
+		for col := 0; col < 3; col++ {
+			r := image.Rect(col*labelW, row*labelH, (col+1)*labelW, (row+1)*labelH)
+			draw.Draw(img, r, sheetLabel(col, row), image.Point{}, draw.Src)
+		}
+	}
+	return img
+}
+
+func TestPrintImageRegion(t *testing.T) {
+	want, wantPrinter := escpos.NewCapturePrinter()
+	err := wantPrinter.PrintImage24(sheetLabel(1, 1), escpos.DoubleDensity)
+	if err != nil {
+		t.Fatalf("could not print label: %v", err)
+	}
+
+	region := image.Rect(labelW, labelH, labelW*2, labelH*2)
+
+	cases := []struct {
+		name string
+		img  image.Image
+	}{
+		{"gray", sheet()},
+		{"generic", genericImage{sheet()}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImageRegion(c.img, region, escpos.DoubleDensity)
+			if err != nil {
+				t.Fatalf("could not print region: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), want.Bytes()) {
+				t.Fatalf("region printed something other than the label")
+			}
+		})
+	}
+}
+
+func TestPrintImageRegionErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		region  image.Rectangle
+		density escpos.Density
+	}{
+		{"empty", image.Rect(0, 0, 0, 10), escpos.DoubleDensity},
+		{"past the right", image.Rect(labelW*2, 0, labelW*3+1, labelH), escpos.DoubleDensity},
+		{"past the left", image.Rect(-1, 0, labelW, labelH), escpos.DoubleDensity},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintImageRegion(sheet(), c.region, c.density)
+			if err == nil {
+				t.Fatalf("region %v did not fail", c.region)
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPrintImageRegionTooWide(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+
+	img := sheet()
+	err := printer.PrintImageRegion(img, img.Bounds(), escpos.SingleDensity)
+	if !errors.Is(err, escpos.ErrImageTooWide) {
+		t.Fatalf("a region that is too wide returned %v instead of ErrImageTooWide", err)
+	}
+}
@@ -0,0 +1,47 @@
+package escpos
+
+import "fmt"
+
+// SensorFlags selects the roll paper sensors for SetSensorPaperEnd and
+// SetSensorStopPrinting.  Each sensor is two bits in the command, and both
+// bits are set for a flag.
+type SensorFlags int
+
+const (
+	// SensorNearEnd is the roll paper near-end sensor, bits 0 and 1
+	SensorNearEnd SensorFlags = 0b0011
+	// SensorEnd is the roll paper end sensor, bits 2 and 3
+	SensorEnd SensorFlags = 0b1100
+
+	// SensorAll selects both roll paper sensors
+	SensorAll = SensorNearEnd | SensorEnd
+)
+
+// SetSensorPaperEnd selects which sensors output the paper end signal on the
+// parallel interface with ESC c 3.  Flags of 0 turns the signal off.
+func (p Printer) SetSensorPaperEnd(flags SensorFlags) error {
+	return p.setSensor('3', flags, "could not set paper end sensors: %w")
+}
+
+// SetSensorStopPrinting selects which sensors stop printing when they detect
+// the paper is out with ESC c 4.  Flags of 0 keeps printing until the paper
+// runs out.
+//
+// Most printers always stop at the roll end sensor, so only SensorNearEnd
+// changes anything on them.  Turning off SensorNearEnd fixes printers that
+// stop too early when the near-end sensor trips.
+func (p Printer) SetSensorStopPrinting(flags SensorFlags) error {
+	return p.setSensor('4', flags, "could not set stop printing sensors: %w")
+}
+
+func (p Printer) setSensor(fn byte, flags SensorFlags, errMsg string) error {
+	if flags&^SensorAll != 0 {
+		return fmt.Errorf(errMsg, fmt.Errorf("%04b is not a valid sensor flag", flags))
+	}
+
+	_, err := p.Write([]byte{ESC, 'c', fn, byte(flags)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package escpos_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestSensors(t *testing.T) {
+	cases := []struct {
+		name  string
+		set   func(escpos.Printer, escpos.SensorFlags) error
+		flags escpos.SensorFlags
+		want  []byte
+	}{
+		{"paper end all", escpos.Printer.SetSensorPaperEnd, escpos.SensorAll, []byte{escpos.ESC, 'c', '3', 0b1111}},
+		{"paper end", escpos.Printer.SetSensorPaperEnd, escpos.SensorEnd, []byte{escpos.ESC, 'c', '3', 0b1100}},
+		{"stop printing near end", escpos.Printer.SetSensorStopPrinting, escpos.SensorNearEnd, []byte{escpos.ESC, 'c', '4', 0b0011}},
+		{"stop printing off", escpos.Printer.SetSensorStopPrinting, 0, []byte{escpos.ESC, 'c', '4', 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.set(printer, c.flags)
+			if err != nil {
+				t.Fatalf("could not set sensors: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sensors %04b sent % x instead of % x", c.flags, sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestSensorsInvalid(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.SetSensorStopPrinting(0b10000)
+	if err == nil {
+		t.Fatalf("invalid sensor flags did not fail")
+	}
+	if len(sink.Bytes()) > 0 {
+		t.Fatalf("sent % x for invalid sensor flags", sink.Bytes())
+	}
+}
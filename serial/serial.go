@@ -0,0 +1,89 @@
+// Package serial connects to ESC/POS printers over a serial port.
+//
+// This is kept out of the escpos package so it stays free of dependencies.
+package serial
+
+import (
+	"fmt"
+
+	"github.com/joeyak/go-escpos"
+	"go.bug.st/serial"
+)
+
+type Parity int
+
+const (
+	NoParity Parity = iota
+	OddParity
+	EvenParity
+)
+
+type StopBits int
+
+const (
+	OneStopBit StopBits = iota
+	TwoStopBits
+)
+
+// Options configures the serial port.  Zero values use the defaults of 9600
+// baud with 8 data bits, no parity, and one stop bit, which most printers use.
+type Options struct {
+	BaudRate int
+	DataBits int
+	Parity   Parity
+	StopBits StopBits
+}
+
+// mode converts the options to the serial port mode
+func (o Options) mode() (*serial.Mode, error) {
+	mode := &serial.Mode{
+		BaudRate: o.BaudRate,
+		DataBits: o.DataBits,
+	}
+
+	if mode.BaudRate == 0 {
+		mode.BaudRate = 9600
+	}
+
+	if mode.DataBits == 0 {
+		mode.DataBits = 8
+	}
+
+	switch o.Parity {
+	case NoParity:
+		mode.Parity = serial.NoParity
+	case OddParity:
+		mode.Parity = serial.OddParity
+	case EvenParity:
+		mode.Parity = serial.EvenParity
+	default:
+		return nil, fmt.Errorf("%v is not a valid parity", o.Parity)
+	}
+
+	switch o.StopBits {
+	case OneStopBit:
+		mode.StopBits = serial.OneStopBit
+	case TwoStopBits:
+		mode.StopBits = serial.TwoStopBits
+	default:
+		return nil, fmt.Errorf("%v is not a valid number of stop bits", o.StopBits)
+	}
+
+	return mode, nil
+}
+
+// NewPrinter opens the serial port, like /dev/ttyUSB0 or COM3, and returns a
+// printer using it
+func NewPrinter(port string, opts Options) (escpos.Printer, error) {
+	mode, err := opts.mode()
+	if err != nil {
+		return escpos.Printer{}, fmt.Errorf("invalid serial options: %w", err)
+	}
+
+	conn, err := serial.Open(port, mode)
+	if err != nil {
+		return escpos.Printer{}, fmt.Errorf("unable to open serial port %s: %w", port, err)
+	}
+
+	return escpos.NewPrinter(conn), nil
+}
@@ -0,0 +1,58 @@
+package serial
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPty opens a pseudo terminal and returns the controlling side and the
+// path of the port
+func openPty(t *testing.T) (*os.File, string) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminals: %v", err)
+	}
+	t.Cleanup(func() { ptmx.Close() })
+
+	var unlock int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+	if errno != 0 {
+		t.Skipf("could not unlock the pseudo terminal: %v", errno)
+	}
+
+	var n uint32
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n)))
+	if errno != 0 {
+		t.Skipf("could not get the pseudo terminal number: %v", errno)
+	}
+
+	return ptmx, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestNewPrinterPty(t *testing.T) {
+	ptmx, port := openPty(t)
+
+	printer, err := NewPrinter(port, Options{BaudRate: 19200})
+	if err != nil {
+		t.Fatalf("could not open %s: %v", port, err)
+	}
+	defer printer.Close()
+
+	err = printer.Print("hello")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	b := make([]byte, 5)
+	_, err = io.ReadFull(ptmx, b)
+	if err != nil {
+		t.Fatalf("could not read from the pseudo terminal: %v", err)
+	}
+	if string(b) != "hello" {
+		t.Fatalf("read %q instead of \"hello\"", b)
+	}
+}
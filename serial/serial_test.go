@@ -0,0 +1,67 @@
+package serial
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.bug.st/serial"
+)
+
+func TestOptionsMode(t *testing.T) {
+	cases := []struct {
+		name string
+		opts Options
+		want serial.Mode
+	}{
+		{"defaults", Options{}, serial.Mode{BaudRate: 9600, DataBits: 8, Parity: serial.NoParity, StopBits: serial.OneStopBit}},
+		{"19200 7E2", Options{BaudRate: 19200, DataBits: 7, Parity: EvenParity, StopBits: TwoStopBits}, serial.Mode{BaudRate: 19200, DataBits: 7, Parity: serial.EvenParity, StopBits: serial.TwoStopBits}},
+		{"odd parity", Options{Parity: OddParity}, serial.Mode{BaudRate: 9600, DataBits: 8, Parity: serial.OddParity, StopBits: serial.OneStopBit}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mode, err := c.opts.mode()
+			if err != nil {
+				t.Fatalf("could not convert options: %v", err)
+			}
+			if !reflect.DeepEqual(*mode, c.want) {
+				t.Fatalf("mode was %+v instead of %+v", *mode, c.want)
+			}
+		})
+	}
+}
+
+func TestOptionsModeErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts Options
+	}{
+		{"parity", Options{Parity: Parity(3)}},
+		{"stop bits", Options{StopBits: StopBits(2)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.opts.mode()
+			if err == nil {
+				t.Fatalf("options did not fail")
+			}
+
+			_, err = NewPrinter("/dev/does-not-exist", c.opts)
+			if err == nil || !strings.Contains(err.Error(), "invalid serial options") {
+				t.Fatalf("NewPrinter returned %v instead of an options error", err)
+			}
+		})
+	}
+}
+
+func TestNewPrinterMissingPort(t *testing.T) {
+	_, err := NewPrinter("/dev/does-not-exist", Options{})
+	if err == nil {
+		t.Fatalf("opened a port that doesn't exist")
+	}
+	if !strings.Contains(err.Error(), "/dev/does-not-exist") {
+		t.Fatalf("error %q does not have the port", err)
+	}
+}
@@ -0,0 +1,151 @@
+package escpos
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// SparklineOptions controls how PrintSparkline draws the chart.  Zero values
+// use the defaults.
+type SparklineOptions struct {
+	// Width is the width of the chart in dots, the default is MaxWidthDots.
+	// Each value gets the same share of the width, so it must be at least
+	// the number of values.
+	Width int
+	// Height is the height of the chart in dots, the default is 48
+	Height int
+	// Min and Max are the values at the bottom and top of the chart.  When
+	// both are 0 they are the lowest and highest values, and values outside
+	// of them are drawn at the bottom or top.
+	Min, Max float64
+	// Fill draws a bar for each value instead of a line
+	Fill bool
+	// Baseline draws a line across the bottom of the chart
+	Baseline bool
+}
+
+// sparklineImage draws the values as a chart, with maxWidth as the default
+// width
+func sparklineImage(values []float64, opts SparklineOptions, maxWidth int) (*image.Gray, error) {
+	if opts.Width == 0 {
+		opts.Width = maxWidth
+	}
+	if opts.Height == 0 {
+		opts.Height = 48
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("there are no values")
+	}
+
+	err := checkRange(opts.Width, len(values), maxWidth, "width")
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkRange(opts.Height, 1, rasterMaxHeight, "height")
+	if err != nil {
+		return nil, err
+	}
+
+	low, high := opts.Min, opts.Max
+	auto := low == 0 && high == 0
+	if auto {
+		low, high = math.Inf(1), math.Inf(-1)
+	}
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("value %d is %v", i, v)
+		}
+		if auto {
+			low, high = math.Min(low, v), math.Max(high, v)
+		}
+	}
+	if !auto && high <= low {
+		return nil, fmt.Errorf("max %v must be more than min %v", high, low)
+	}
+
+	// level is the number of dots from the bottom to the value
+	level := func(v float64) int {
+		if high == low {
+			return opts.Height
+		}
+		h := int(math.Round((v - low) / (high - low) * float64(opts.Height)))
+		if h < 0 {
+			return 0
+		}
+		if h > opts.Height {
+			return opts.Height
+		}
+		return h
+	}
+
+	img := image.NewGray(image.Rect(0, 0, opts.Width, opts.Height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+
+	fill := func(x0, x1, y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+
+	previous := -1
+	for i, v := range values {
+		x0, x1 := i*opts.Width/len(values), (i+1)*opts.Width/len(values)
+		top := opts.Height - level(v)
+
+		if opts.Fill {
+			// Leave a gap between bars that are wide enough for one
+			if x1-x0 >= 3 {
+				x1--
+			}
+			fill(x0, x1, top, opts.Height)
+			continue
+		}
+
+		// The line is drawn as steps, flat across each value and up or down
+		// to the next one at the edge between them
+		if top == opts.Height {
+			top--
+		}
+		fill(x0, x1, top, top+1)
+		if previous >= 0 && previous != top {
+			y0, y1 := previous, top
+			if y0 > y1 {
+				y0, y1 = y1, y0
+			}
+			fill(x0, x0+1, y0, y1+1)
+		}
+		previous = top
+	}
+
+	if opts.Baseline {
+		fill(0, opts.Width, opts.Height-1, opts.Height)
+	}
+
+	return img, nil
+}
+
+// PrintSparkline draws the values as a small chart and prints it with
+// PrintImageRaster, like a chart of recent readings on a monitoring ticket.
+// Values are drawn left to right, scaled between the Min and Max of opts.
+func (p Printer) PrintSparkline(values []float64, opts SparklineOptions) error {
+	errMsg := "could not print sparkline: %w"
+
+	img, err := sparklineImage(values, opts, p.MaxWidthDots())
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.PrintImageRaster(img, RasterNormal)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
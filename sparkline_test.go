@@ -0,0 +1,71 @@
+package escpos_test
+
+import (
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestPrintSparkline(t *testing.T) {
+	values := []float64{1, 5, 3, 5, 2}
+
+	for _, fill := range []bool{true, false} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.PrintSparkline(values, escpos.SparklineOptions{Width: 100, Height: 40, Fill: fill})
+		if err != nil {
+			t.Fatalf("could not print sparkline: %v", err)
+		}
+
+		cmds := sink.Decoded()
+		if len(cmds) == 0 || cmds[0].Name != "RASTER IMAGE mode 0 104x40" {
+			t.Fatalf("sparkline with fill %t sent %q instead of a 100x40 raster image", fill, sink.Commands())
+		}
+
+		rows := cmds[0].Data[8:]
+		dot := func(x, y int) bool {
+			return rows[y*13+x/8]&(0x80>>(x%8)) != 0
+		}
+
+		// The highest values reach the top and the lowest value is at the
+		// bottom.  The line goes up and down at the first dot of each value.
+		for x := 0; x < 100; x++ {
+			slot := x / 20
+			top := dot(x, 0)
+			if top && values[slot] != 5 && (fill || x%20 != 0) {
+				t.Fatalf("sparkline with fill %t has value %v at the top at x %d", fill, values[slot], x)
+			}
+			if slot == 1 && x < 39 && !top {
+				t.Fatalf("sparkline with fill %t doesn't reach the top at x %d", fill, x)
+			}
+			if slot == 0 && x > 0 && dot(x, 38) {
+				t.Fatalf("sparkline with fill %t has the lowest value above the bottom at x %d", fill, x)
+			}
+		}
+	}
+}
+
+func TestPrintSparklineErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		opts escpos.SparklineOptions
+	}{
+		{"too narrow", escpos.SparklineOptions{Width: 3}},
+		{"min above max", escpos.SparklineOptions{Min: 5, Max: 1}},
+		{"negative height", escpos.SparklineOptions{Height: -1}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintSparkline([]float64{1, 5, 3, 5, 2}, c.opts)
+			if err == nil {
+				t.Fatalf("sparkline options %+v did not fail", c.opts)
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent % x after failing", sink.Bytes())
+			}
+		})
+	}
+}
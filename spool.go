@@ -0,0 +1,57 @@
+package escpos
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// spoolConn writes the commands to a spool file
+type spoolConn struct {
+	*os.File
+}
+
+func (spoolConn) Read(b []byte) (int, error) {
+	return 0, io.EOF
+}
+
+// NewSpoolPrinter returns a printer that writes the commands to the file at
+// path instead of a printer, so receipts can be made while the printer isn't
+// connected and printed later with ReplaySpool.  The file is created, or
+// emptied if it already exists.  Close the printer to close the file.
+//
+// Reading from the printer returns io.EOF, so status commands fail.
+func NewSpoolPrinter(path string) (Printer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return Printer{}, fmt.Errorf("could not create spool file: %w", err)
+	}
+	return NewPrinter(spoolConn{f}), nil
+}
+
+// ReplaySpool sends the commands in the spool file at path to the target
+// printer exactly as they were written.  Buffered data on the target is
+// flushed after the file is sent.
+func ReplaySpool(path string, target Printer) error {
+	errMsg := "could not replay spool file: %w"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	defer f.Close()
+
+	target, unlock := target.lock()
+	defer unlock()
+
+	_, err = io.Copy(target, f)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = target.Flush()
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestReplaySpool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipt.spool")
+
+	receipt := func(p escpos.Printer) error {
+		return p.Builder().
+			Bold(true).
+			Println("Spooled receipt").
+			Bold(false).
+			Println("Printed from the spool file").
+			Err()
+	}
+
+	spool, err := escpos.NewSpoolPrinter(path)
+	if err != nil {
+		t.Fatalf("could not create spool: %v", err)
+	}
+
+	err = receipt(spool)
+	if err != nil {
+		t.Fatalf("could not print to spool: %v", err)
+	}
+
+	err = spool.Close()
+	if err != nil {
+		t.Fatalf("could not close spool: %v", err)
+	}
+
+	want, wantPrinter := escpos.NewCapturePrinter()
+	err = receipt(wantPrinter)
+	if err != nil {
+		t.Fatalf("could not print receipt: %v", err)
+	}
+
+	got, gotPrinter := escpos.NewCapturePrinter()
+	err = escpos.ReplaySpool(path, gotPrinter)
+	if err != nil {
+		t.Fatalf("could not replay spool: %v", err)
+	}
+
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Fatalf("replayed % x instead of % x", got.Bytes(), want.Bytes())
+	}
+}
+
+func TestSpoolErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := escpos.NewSpoolPrinter(filepath.Join(dir, "missing", "receipt.spool"))
+	if err == nil {
+		t.Fatalf("spool in a missing directory did not fail")
+	}
+
+	_, printer := escpos.NewCapturePrinter()
+	err = escpos.ReplaySpool(filepath.Join(dir, "missing.spool"), printer)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("replaying a missing spool returned %v", err)
+	}
+
+	path := filepath.Join(dir, "receipt.spool")
+	spool, err := escpos.NewSpoolPrinter(path)
+	if err != nil {
+		t.Fatalf("could not create spool: %v", err)
+	}
+	spool.Println("Spooled")
+	spool.Close()
+
+	err = escpos.ReplaySpool(path, escpos.NewPrinter(brokenConn{}))
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
@@ -0,0 +1,122 @@
+package escpos
+
+import "fmt"
+
+// PrinterState is the formatting the Printer keeps track of from the setters
+// that were last called.  Settings changed with Write or SendCommand aren't
+// in it.
+type PrinterState struct {
+	Bold      bool
+	Underline UnderlineMode
+	Font      Font
+	// Width and Height are the character size from 0 to 7 like
+	// SetCharacterSize
+	Width, Height int
+	Reverse       bool
+	Rotate90      bool
+	UpsideDown    bool
+	Justify       Justification
+	// LineSpacing is the spacing from SetLineSpacing and is only used when
+	// LineSpacingSet is true, otherwise the spacing is the default
+	LineSpacing    int
+	LineSpacingSet bool
+	CodePage       CodePage
+	// MotionX and MotionY are the units from SetMotionUnits, 0 is the
+	// printer default
+	MotionX, MotionY int
+}
+
+// SnapshotState returns the formatting that was last set.  Initialize resets
+// it to the printer defaults along with the printer, and RestoreState sends
+// it again.
+func (p Printer) SnapshotState() PrinterState {
+	if p.config == nil {
+		return PrinterState{}
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	c := p.config
+	s := PrinterState{
+		Bold:           c.bold,
+		Underline:      c.underline,
+		Font:           c.font,
+		Width:          c.charWidth,
+		Height:         c.charHeight,
+		Reverse:        c.reverse,
+		Rotate90:       c.rotate90,
+		UpsideDown:     c.upsideDown,
+		Justify:        c.justify,
+		LineSpacing:    c.lineSpacing,
+		LineSpacingSet: c.lineSpacingSet,
+		CodePage:       c.codePage,
+		MotionX:        c.motionX,
+		MotionY:        c.motionY,
+	}
+	if !s.LineSpacingSet {
+		s.LineSpacing = 0
+	}
+	return s
+}
+
+// RestoreState sends every setting of the state with its setter, so the
+// printer ends up with the formatting from SnapshotState no matter what was
+// set in between.  The motion units are sent first since the line spacing
+// depends on them.
+func (p Printer) RestoreState(s PrinterState) error {
+	errMsg := "could not restore printer state: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	lineSpacing := p.ResetLineSpacing
+	if s.LineSpacingSet {
+		lineSpacing = func() error { return p.SetLineSpacing(s.LineSpacing) }
+	}
+
+	restores := []func() error{
+		func() error { return p.SetMotionUnits(s.MotionX, s.MotionY) },
+		func() error { return p.SetCodePage(s.CodePage) },
+		func() error { return p.SetFont(s.Font) },
+		func() error { return p.SetCharacterSize(s.Width, s.Height) },
+		func() error { return p.SetBold(s.Bold) },
+		func() error { return p.SetUnderline(s.Underline) },
+		func() error { return p.SetReversePrinting(s.Reverse) },
+		func() error { return p.SetUpsideDown(s.UpsideDown) },
+		func() error { return p.SetRotate90(s.Rotate90) },
+		func() error { return p.Justify(s.Justify) },
+		lineSpacing,
+	}
+
+	for _, restore := range restores {
+		err := restore()
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+	return nil
+}
+
+// ReinitializePreserving initializes the printer with ESC @ to clear any
+// settings sent with Write or left by another program, and then sends the
+// formatting that was set before with RestoreState
+func (p Printer) ReinitializePreserving() error {
+	errMsg := "could not reinitialize printer: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	state := p.SnapshotState()
+
+	err := p.Initialize()
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.RestoreState(state)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
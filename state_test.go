@@ -0,0 +1,94 @@
+package escpos_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestRestoreState(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	setup := []func() error{
+		func() error { return printer.SetBold(true) },
+		func() error { return printer.SetUnderline(escpos.UnderlineThick) },
+		func() error { return printer.SetFont(escpos.FontB) },
+		func() error { return printer.SetCharacterSize(1, 2) },
+		func() error { return printer.SetCodePage(escpos.CP850) },
+		func() error { return printer.Justify(escpos.CenterJustify) },
+		func() error { return printer.SetLineSpacing(40) },
+		func() error { return printer.SetOrientation(escpos.OrientationUpsideDown) },
+	}
+	for _, set := range setup {
+		err := set()
+		if err != nil {
+			t.Fatalf("could not set up the printer: %v", err)
+		}
+	}
+
+	state := printer.SnapshotState()
+
+	err := printer.Initialize()
+	if err != nil {
+		t.Fatalf("could not initialize: %v", err)
+	}
+	if printer.SnapshotState() != (escpos.PrinterState{}) {
+		t.Fatalf("state after initialize is %+v instead of the defaults", printer.SnapshotState())
+	}
+
+	want := []string{
+		"MOTION UNITS 0 0",
+		"CODE PAGE 2",
+		"FONT B",
+		"CHARACTER SIZE 1x2",
+		"BOLD on",
+		"UNDERLINE 2",
+		"REVERSE off",
+		"UPSIDE-DOWN on",
+		"ROTATE90 off",
+		"JUSTIFY center",
+		"LINE SPACING 40",
+	}
+
+	sink.Reset()
+	err = printer.RestoreState(state)
+	if err != nil {
+		t.Fatalf("could not restore: %v", err)
+	}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("restore sent %q instead of %q", got, want)
+	}
+	if printer.SnapshotState() != state {
+		t.Fatalf("state after restore is %+v instead of %+v", printer.SnapshotState(), state)
+	}
+
+	sink.Reset()
+	err = printer.ReinitializePreserving()
+	if err != nil {
+		t.Fatalf("could not reinitialize: %v", err)
+	}
+	if got := sink.Commands(); !reflect.DeepEqual(got, append([]string{"INITIALIZE"}, want...)) {
+		t.Fatalf("reinitialize sent %q instead of initialize and %q", got, want)
+	}
+	if printer.SnapshotState() != state {
+		t.Fatalf("state after reinitialize is %+v instead of %+v", printer.SnapshotState(), state)
+	}
+}
+
+func TestRestoreStateErrors(t *testing.T) {
+	_, printer := escpos.NewCapturePrinter()
+
+	state := printer.SnapshotState()
+	state.Font = escpos.Font(3)
+	err := printer.RestoreState(state)
+	if err == nil {
+		t.Fatalf("restoring font 3 did not fail")
+	}
+
+	err = escpos.NewPrinter(brokenConn{}).RestoreState(escpos.PrinterState{})
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
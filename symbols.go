@@ -0,0 +1,198 @@
+package escpos
+
+import "fmt"
+
+// PDF417Options are the optional settings for PDF417 codes.  Zero values use
+// the printer defaults.
+type PDF417Options struct {
+	// ModuleWidth is the width of a module in dots and must be between 2 and 8
+	ModuleWidth int
+	// RowHeight is the height of a row as a multiple of the module width and
+	// must be between 2 and 8
+	RowHeight int
+	// Truncated prints the smaller truncated PDF417 without the right side
+	// row indicators
+	Truncated bool
+}
+
+// PDF417 prints data as a PDF417 code
+//
+// The columns can be between 1 and 30 and the rows between 3 and 90.  Either
+// can be 0 to let the printer pick.  The error correction level is between 0
+// and 8, each level doubles the number of error correction codewords.
+//
+// Data can be up to 1108 bytes, but less fits with higher error correction
+// levels or when both the columns and rows are set.
+func (p Printer) PDF417(data string, columns, rows int, ecLevel int, opts PDF417Options) error {
+	errMsg := "could not print PDF417 code: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	err := checkRange(columns, 0, 30, "columns")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if rows != 0 {
+		err = checkRange(rows, 3, 90, "rows")
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	err = checkRange(ecLevel, 0, 8, "error correction level")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if opts.ModuleWidth != 0 {
+		err = checkRange(opts.ModuleWidth, 2, 8, "module width")
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	if opts.RowHeight != 0 {
+		err = checkRange(opts.RowHeight, 2, 8, "row height")
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	// A symbol holds up to 928 codewords, two of them are used for the length
+	// and mode.  Byte compaction packs 6 bytes into 5 codewords.
+	codewords := 928
+	if columns > 0 && rows > 0 && columns*rows < codewords {
+		codewords = columns * rows
+	}
+	maxLength := (codewords - 2 - 1<<(ecLevel+1)) * 6 / 5
+	if maxLength < 1 {
+		return fmt.Errorf(errMsg, fmt.Errorf("%d columns and %d rows can't fit error correction level %d", columns, rows, ecLevel))
+	}
+
+	err = checkRange(len(data), 1, maxLength, "data length")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 065: set the number of columns
+	err = p.symbolCode('0', 'A', byte(columns))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 066: set the number of rows
+	err = p.symbolCode('0', 'B', byte(rows))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 067: set the module width
+	if opts.ModuleWidth != 0 {
+		err = p.symbolCode('0', 'C', byte(opts.ModuleWidth))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	// Function 068: set the row height
+	if opts.RowHeight != 0 {
+		err = p.symbolCode('0', 'D', byte(opts.RowHeight))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	// Function 069: set the error correction level
+	err = p.symbolCode('0', 'E', '0', byte(ecLevel)+'0')
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 070: select standard or truncated
+	err = p.symbolCode('0', 'F', boolToByte(opts.Truncated))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 080: store the data in the symbol storage area
+	err = p.symbolCode('0', 'P', append([]byte{'0'}, data...)...)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 081: print the symbol data in the symbol storage area
+	err = p.symbolCode('0', 'Q', '0')
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
+
+// DataMatrixOptions are the optional settings for DataMatrix codes.  Zero
+// values use the printer defaults.
+type DataMatrixOptions struct {
+	// Size is the width of a module in dots and must be between 2 and 16
+	Size int
+	// Rectangle prints a rectangle code instead of a square
+	Rectangle bool
+}
+
+// DataMatrix prints data as a DataMatrix ECC 200 code.  The printer picks the
+// number of rows and columns needed to fit the data.
+//
+// Data can be up to 1555 bytes, or 47 bytes for a rectangle.
+func (p Printer) DataMatrix(data string, opts DataMatrixOptions) error {
+	errMsg := "could not print DataMatrix code: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	if opts.Size != 0 {
+		err := checkRange(opts.Size, 2, 16, "size")
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	// The largest rectangle is 16x48 which holds 47 bytes
+	maxLength := 1555
+	if opts.Rectangle {
+		maxLength = 47
+	}
+
+	err := checkRange(len(data), 1, maxLength, "data length")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 666: select square or rectangle with automatic rows and columns
+	err = p.symbolCode('6', 'B', '0'+boolToByte(opts.Rectangle), 0, 0)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 667: set the module size
+	if opts.Size != 0 {
+		err = p.symbolCode('6', 'C', byte(opts.Size))
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	// Function 680: store the data in the symbol storage area
+	err = p.symbolCode('6', 'P', append([]byte{'0'}, data...)...)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 681: print the symbol data in the symbol storage area
+	err = p.symbolCode('6', 'Q', '0')
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
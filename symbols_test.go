@@ -0,0 +1,198 @@
+package escpos_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// symbol builds a GS ( k function with the cn and fn bytes
+func symbol(cn, fn byte, params ...byte) []byte {
+	length := len(params) + 2
+	return append([]byte{escpos.GS, '(', 'k', byte(length), byte(length >> 8), cn, fn}, params...)
+}
+
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestPDF417(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		columns int
+		rows    int
+		ecLevel int
+		opts    escpos.PDF417Options
+		want    []byte
+	}{
+		{
+			name: "printer defaults",
+			data: "AB",
+			want: join(
+				symbol('0', 'A', 0),
+				symbol('0', 'B', 0),
+				symbol('0', 'E', '0', '0'),
+				symbol('0', 'F', 0),
+				symbol('0', 'P', '0', 'A', 'B'),
+				symbol('0', 'Q', '0'),
+			),
+		},
+		{
+			name:    "every setting",
+			data:    "AB",
+			columns: 3,
+			rows:    10,
+			ecLevel: 1,
+			opts:    escpos.PDF417Options{ModuleWidth: 3, RowHeight: 4, Truncated: true},
+			want: join(
+				symbol('0', 'A', 3),
+				symbol('0', 'B', 10),
+				symbol('0', 'C', 3),
+				symbol('0', 'D', 4),
+				symbol('0', 'E', '0', '1'),
+				symbol('0', 'F', 1),
+				symbol('0', 'P', '0', 'A', 'B'),
+				symbol('0', 'Q', '0'),
+			),
+		},
+		{
+			name:    "most data",
+			data:    strings.Repeat("A", 1108),
+			ecLevel: 0,
+			want: join(
+				symbol('0', 'A', 0),
+				symbol('0', 'B', 0),
+				symbol('0', 'E', '0', '0'),
+				symbol('0', 'F', 0),
+				symbol('0', 'P', append([]byte{'0'}, strings.Repeat("A", 1108)...)...),
+				symbol('0', 'Q', '0'),
+			),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PDF417(c.data, c.columns, c.rows, c.ecLevel, c.opts)
+			if err != nil {
+				t.Fatalf("could not print PDF417: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent %v instead of %v", sink.Commands(), c.want)
+			}
+		})
+	}
+}
+
+func TestPDF417Errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		columns int
+		rows    int
+		ecLevel int
+		opts    escpos.PDF417Options
+	}{
+		{name: "no data"},
+		{name: "too much data", data: strings.Repeat("A", 1109)},
+		{name: "too much data for the error correction", data: strings.Repeat("A", 497), ecLevel: 8},
+		{name: "too much data for the size", data: strings.Repeat("A", 32), columns: 3, rows: 10},
+		{name: "no room for error correction", data: "A", columns: 1, rows: 3},
+		{name: "too many columns", data: "A", columns: 31},
+		{name: "too few rows", data: "A", rows: 2},
+		{name: "too many rows", data: "A", rows: 91},
+		{name: "error correction level", data: "A", ecLevel: 9},
+		{name: "module width", data: "A", opts: escpos.PDF417Options{ModuleWidth: 1}},
+		{name: "row height", data: "A", opts: escpos.PDF417Options{RowHeight: 9}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PDF417(c.data, c.columns, c.rows, c.ecLevel, c.opts)
+			if err == nil {
+				t.Fatalf("PDF417 should not be sent")
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("bad PDF417 sent %v", sink.Commands())
+			}
+		})
+	}
+}
+
+func TestDataMatrix(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		opts escpos.DataMatrixOptions
+		want []byte
+	}{
+		{
+			name: "printer defaults",
+			data: "AB",
+			want: join(
+				symbol('6', 'B', '0', 0, 0),
+				symbol('6', 'P', '0', 'A', 'B'),
+				symbol('6', 'Q', '0'),
+			),
+		},
+		{
+			name: "rectangle with size",
+			data: "AB",
+			opts: escpos.DataMatrixOptions{Size: 4, Rectangle: true},
+			want: join(
+				symbol('6', 'B', '1', 0, 0),
+				symbol('6', 'C', 4),
+				symbol('6', 'P', '0', 'A', 'B'),
+				symbol('6', 'Q', '0'),
+			),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.DataMatrix(c.data, c.opts)
+			if err != nil {
+				t.Fatalf("could not print DataMatrix: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent %v instead of %v", sink.Commands(), c.want)
+			}
+		})
+	}
+}
+
+func TestDataMatrixErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		opts escpos.DataMatrixOptions
+	}{
+		{name: "no data"},
+		{name: "too much data", data: strings.Repeat("A", 1556)},
+		{name: "too much data for a rectangle", data: strings.Repeat("A", 48), opts: escpos.DataMatrixOptions{Rectangle: true}},
+		{name: "size too small", data: "A", opts: escpos.DataMatrixOptions{Size: 1}},
+		{name: "size too big", data: "A", opts: escpos.DataMatrixOptions{Size: 17}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.DataMatrix(c.data, c.opts)
+			if err == nil {
+				t.Fatalf("DataMatrix should not be sent")
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("bad DataMatrix sent %v", sink.Commands())
+			}
+		})
+	}
+}
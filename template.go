@@ -0,0 +1,88 @@
+package escpos
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs returns the functions PrintTemplate adds to templates.  A width
+// of 0 is the columns of the profile.
+func templateFuncs(columns int) template.FuncMap {
+	width := func(w int) int {
+		if w <= 0 {
+			return columns
+		}
+		return w
+	}
+
+	// pad only pads, text that is too long is left to the overflow mode
+	pad := func(w int, v any, align Justification) string {
+		text := fmt.Sprint(v)
+		w = width(w)
+		if len([]rune(text)) >= w {
+			return text
+		}
+		return strings.TrimRight(padText(text, w, align), " ")
+	}
+
+	return template.FuncMap{
+		"right": func(w int, v any) string {
+			return pad(w, v, RightJustify)
+		},
+		"center": func(w int, v any) string {
+			return pad(w, v, CenterJustify)
+		},
+		"divider": func(char string) string {
+			if char == "" {
+				char = "-"
+			}
+			return strings.Repeat(char, width(0)/len([]rune(char)))
+		},
+		"pair": func(left, right any) string {
+			l, r := fmt.Sprint(left), fmt.Sprint(right)
+			space := width(0) - len([]rune(l)) - len([]rune(r))
+			if space < 1 {
+				space = 1
+			}
+			return l + strings.Repeat(" ", space) + r
+		},
+	}
+}
+
+// PrintTemplate executes the text/template with the data and prints the result
+// with Printfw, so lines longer than Columns() are truncated or wrapped
+// depending on SetOverflow.  Nothing is printed when the template can't be
+// parsed or executed.
+//
+// These functions can be used in the template, where a width of 0 is
+// Columns():
+//
+//	right WIDTH VALUE    right aligns the value, like {{.Total | right 0}}
+//	center WIDTH VALUE   centers the value
+//	divider CHAR         repeats CHAR across the line, "" is "-"
+//	pair LEFT RIGHT      puts LEFT at the start of the line and RIGHT at the end
+func (p Printer) PrintTemplate(tmpl string, data any) error {
+	errMsg := "could not print template: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	t, err := template.New("receipt").Funcs(templateFuncs(p.Columns())).Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	var buf bytes.Buffer
+	err = t.Execute(&buf, data)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.Printfw(0, "%s", buf.String())
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package escpos_test
+
+import (
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+type templateItem struct {
+	Name  string
+	Price string
+}
+
+type templateReceipt struct {
+	Store string
+	Items []templateItem
+	Total string
+}
+
+const receiptTemplate = `{{center 0 .Store}}
+{{divider "="}}
+{{range .Items}}{{pair .Name .Price}}
+{{end}}{{divider ""}}
+{{.Total | right 0}}
+`
+
+var receipt = templateReceipt{
+	Store: "Corner Shop",
+	Items: []templateItem{{"Coffee", "3.50"}, {"Blueberry muffin", "2.25"}},
+	Total: "5.75",
+}
+
+func TestPrintTemplate(t *testing.T) {
+	profile := escpos.ProfileHoin
+	profile.FontAColumns = 24
+	sink, printer := newPrinter(profile)
+
+	err := printer.PrintTemplate(receiptTemplate, receipt)
+	if err != nil {
+		t.Fatalf("could not print template: %v", err)
+	}
+
+	want := "      Corner Shop\n" +
+		"========================\n" +
+		"Coffee              3.50\n" +
+		"Blueberry muffin    2.25\n" +
+		"------------------------\n" +
+		"                    5.75\n"
+	if string(sink.Bytes()) != want {
+		t.Fatalf("template printed %q instead of %q", sink.Bytes(), want)
+	}
+}
+
+func TestPrintTemplateErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		tmpl string
+	}{
+		{"unclosed action", "{{.Missing"},
+		{"missing field", "{{.Store.Name}}"},
+		{"missing argument", "{{right 0}}"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintTemplate(c.tmpl, receipt)
+			if err == nil {
+				t.Fatalf("template %q printed", c.tmpl)
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("failed template printed %q", sink.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,620 @@
+package escpos
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Column is a cell of a line printed by PrintColumns
+type Column struct {
+	Text string `json:"text"`
+	// Width is the number of characters in the column.  Columns with a width
+	// of 0 split the characters left over from the other columns.
+	Width int `json:"width,omitempty"`
+	// Weight is the share of the left over characters for a column with a
+	// width of 0.  A weight of 0 counts as 1.
+	Weight int           `json:"weight,omitempty"`
+	Align  Justification `json:"align,omitempty"`
+}
+
+// padText pads or truncates the text to width characters
+func padText(text string, width int, align Justification) string {
+	runes := []rune(text)
+	if len(runes) >= width {
+		return string(runes[:width])
+	}
+
+	space := width - len(runes)
+	switch align {
+	case RightJustify:
+		return strings.Repeat(" ", space) + text
+	case CenterJustify:
+		left := space / 2
+		return strings.Repeat(" ", left) + text + strings.Repeat(" ", space-left)
+	default:
+		return text + strings.Repeat(" ", space)
+	}
+}
+
+// columnWidths works out the width of each column on a line of width
+// characters
+func columnWidths(width int, cols ...Column) ([]int, error) {
+	left := width
+	weights := 0
+	for i, col := range cols {
+		err := checkEnum(col.Align, LeftJustify, CenterJustify, RightJustify)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", i, err)
+		}
+
+		if col.Width < 0 || col.Weight < 0 {
+			return nil, fmt.Errorf("column %d cannot have a negative width or weight", i)
+		}
+
+		if col.Width > 0 {
+			left -= col.Width
+		} else if col.Weight > 0 {
+			weights += col.Weight
+		} else {
+			weights++
+		}
+	}
+
+	if left < 0 {
+		return nil, fmt.Errorf("column widths are more than the line width of %d", width)
+	}
+
+	widths := make([]int, len(cols))
+	shared, total := left, weights
+	for i, col := range cols {
+		w := col.Width
+		if w == 0 {
+			weight := col.Weight
+			if weight == 0 {
+				weight = 1
+			}
+
+			// Give the rounding left overs to the last shared column
+			w = shared * weight / total
+			weights -= weight
+			if weights == 0 {
+				w = left
+			}
+			left -= w
+		}
+		widths[i] = w
+	}
+
+	return widths, nil
+}
+
+// formatColumns lays out the columns on a line of width characters
+func formatColumns(width int, cols ...Column) (string, error) {
+	widths, err := columnWidths(width, cols...)
+	if err != nil {
+		return "", err
+	}
+
+	var line strings.Builder
+	for i, col := range cols {
+		line.WriteString(padText(col.Text, widths[i], col.Align))
+	}
+
+	return line.String(), nil
+}
+
+// PrintColumns prints a line with the text of each column padded or truncated
+// to fit the column.  The width is the number of characters in a line for the
+// current font, a width of 0 uses Columns().
+//
+// For an item name on the left and the price on the right:
+//
+//	p.PrintColumns(48, Column{Text: "Coffee"}, Column{Text: "$3.50", Width: 8, Align: RightJustify})
+func (p Printer) PrintColumns(width int, cols ...Column) error {
+	errMsg := "could not print columns: %w"
+
+	if width == 0 {
+		width = p.Columns()
+	}
+
+	line, err := formatColumns(width, cols...)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.Println(line)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// wrapText splits the text into lines of at most width characters.  Words are
+// separated by any amount of white space and words longer than the width are
+// broken up.  Newlines in the text are kept.
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = nil
+			}
+
+			for len(runes) > width {
+				if len(line) > 0 {
+					lines = append(lines, string(line))
+					line = nil
+				}
+				lines = append(lines, string(runes[:width]))
+				runes = runes[width:]
+			}
+
+			if len(runes) == 0 {
+				continue
+			}
+
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// normalizeNewlines changes CRLF and CR line endings to LF
+func normalizeNewlines(text string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+}
+
+// PrintWrapped prints the text wrapped to lines of width characters.  The
+// width is the number of characters in a line for the current font, a width
+// of 0 uses Columns().  LF, CR, and CRLF in the text all start a new line,
+// and the lines end with the line ending from SetNewline.
+func (p Printer) PrintWrapped(text string, width int) error {
+	errMsg := "could not print wrapped text: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	if width == 0 {
+		width = p.Columns()
+	}
+
+	err := checkRange(width, 1, 255, "width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	for _, line := range wrapText(normalizeNewlines(text), width) {
+		err = p.Println(line)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
+
+// PrintLarge prints a line of large text, like a queue number, with the
+// width and height multipliers from 1 to 8 and the justification.  The
+// character size and justification are put back to what they were after the
+// line is printed.
+func (p Printer) PrintLarge(text string, widthMult, heightMult int, align Justification) error {
+	errMsg := "could not print large text: %w"
+
+	err := checkRange(widthMult, 1, 8, "width multiplier")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkRange(heightMult, 1, 8, "height multiplier")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	width, height, justify := 0, 0, LeftJustify
+	if p.config != nil {
+		width, height, justify = p.config.charWidth, p.config.charHeight, p.config.justify
+	}
+
+	err = p.SetCharacterSize(widthMult-1, heightMult-1)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.Justify(align)
+	if err == nil {
+		err = p.Println(text)
+	}
+
+	// Put the size and justification back even when printing failed
+	justifyErr := p.Justify(justify)
+	sizeErr := p.SetCharacterSize(width, height)
+
+	for _, err := range []error{err, justifyErr, sizeErr} {
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+	return nil
+}
+
+// Overflow selects what Printfw does with lines that are too long
+type Overflow int
+
+const (
+	// OverflowTruncate cuts long lines and ends them with "..."
+	OverflowTruncate Overflow = iota
+	// OverflowWrap wraps long lines at the words like PrintWrapped
+	OverflowWrap
+)
+
+// SetOverflow sets what Printfw does with lines that are longer than the
+// width.  The default is OverflowTruncate.
+func (p Printer) SetOverflow(mode Overflow) error {
+	err := checkEnum(mode, OverflowTruncate, OverflowWrap)
+	if err != nil {
+		return fmt.Errorf("could not set overflow: %w", err)
+	}
+
+	if p.config == nil {
+		return nil
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.overflow = mode
+	return nil
+}
+
+// fitLine truncates or wraps a line that is longer than width characters
+func fitLine(line string, width int, mode Overflow) []string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return []string{line}
+	}
+
+	if mode == OverflowWrap {
+		return wrapText(line, width)
+	}
+
+	if width <= 3 {
+		return []string{string(runes[:width])}
+	}
+	return []string{string(runes[:width-3]) + "..."}
+}
+
+// Printfw formats like Printf and makes sure no line is longer than width
+// characters so the printer never wraps in the middle of a value.  Lines that
+// are too long are truncated or wrapped depending on SetOverflow, and lines
+// that fit are printed as they are, with their spacing kept.  The width is the
+// number of characters in a line for the current font, a width of 0 uses
+// Columns().
+//
+// Like Printf no line feed is added at the end.
+func (p Printer) Printfw(width int, format string, a ...any) error {
+	errMsg := "could not print formatted text: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	if width == 0 {
+		width = p.Columns()
+	}
+
+	err := checkRange(width, 1, 255, "width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	mode := OverflowTruncate
+	if p.config != nil {
+		mode = p.config.overflow
+	}
+
+	var lines []string
+	for _, line := range strings.Split(normalizeNewlines(fmt.Sprintf(format, a...)), "\n") {
+		lines = append(lines, fitLine(line, width, mode)...)
+	}
+
+	err = p.Print(strings.Join(lines, p.newline()))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// Number of lines PrintReader prints between flushes when buffering is on
+const readerFlushLines = 32
+
+// PrintReader prints each line read from r wrapped to lines of width
+// characters like PrintWrapped.  Lines can end with LF or CRLF and the last
+// line doesn't need a line ending.  They are printed with the line ending from
+// SetNewline.  A width of 0 uses Columns().
+//
+// The reader is read a line at a time so it can be a file or a stream that
+// is longer than what fits in memory, but a single line can't be longer than
+// 1MB.  When buffering is on the printer is flushed every 32 lines so the
+// paper keeps moving.  Other goroutines can print between the lines.
+func (p Printer) PrintReader(r io.Reader, width int) error {
+	errMsg := "could not print reader: %w"
+
+	if width == 0 {
+		width = p.Columns()
+	}
+
+	err := checkRange(width, 1, 255, "width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+
+	count := 0
+	for scanner.Scan() {
+		err = p.PrintWrapped(scanner.Text(), width)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+
+		count++
+		if count%readerFlushLines == 0 {
+			err = p.Flush()
+			if err != nil {
+				return fmt.Errorf(errMsg, err)
+			}
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// printReversed prints the text with reverse printing on and puts reverse
+// printing back to how it was
+func (p Printer) printReversed(text string) error {
+	p, unlock := p.lock()
+	defer unlock()
+
+	if p.config != nil && p.config.reverse {
+		return p.Print(text)
+	}
+
+	err := p.SetReversePrinting(true)
+	if err != nil {
+		return err
+	}
+
+	err = p.Print(text)
+	if err != nil {
+		return err
+	}
+
+	return p.SetReversePrinting(false)
+}
+
+// PrintReversed prints the text as white on black with a space on each side,
+// like a highlighted label.  No line feed is added.  Reverse printing is left
+// the way it was before.
+func (p Printer) PrintReversed(text string) error {
+	err := p.printReversed(" " + text + " ")
+	if err != nil {
+		return fmt.Errorf("could not print reversed text: %w", err)
+	}
+	return nil
+}
+
+// PrintBox prints the text as a white on black line that is width characters
+// wide, with the text aligned inside it.  A width of 0 uses Columns().  Text
+// longer than the width is cut off.  Reverse printing is left the way it
+// was before.
+func (p Printer) PrintBox(text string, width int, align Justification) error {
+	errMsg := "could not print box: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	if width == 0 {
+		width = p.Columns()
+	}
+
+	err := checkRange(width, 1, 255, "width")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = checkEnum(align, LeftJustify, CenterJustify, RightJustify)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.printReversed(padText(text, width, align))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	err = p.LF()
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
+}
+
+// TableColumn is a column of a Table
+type TableColumn struct {
+	// Header is the text at the top of the column
+	Header string
+	// Width and Weight work the same as they do for Column
+	Width  int
+	Weight int
+	Align  Justification
+}
+
+// Table is a table printed by PrintTable
+type Table struct {
+	// Width is the number of characters in a line, a width of 0 uses
+	// Columns()
+	Width   int
+	Columns []TableColumn
+	// Rows are the cells of each row.  Rows can have fewer cells than
+	// there are columns, and the missing cells are left empty.
+	Rows [][]string
+	// Divider prints a line of dashes between the headers and the rows
+	Divider bool
+	// Border draws a box around the table and lines between the columns and
+	// between the headers and the rows with +, -, and |.  The border takes 1
+	// character between each column and on each side out of Width.
+	Border bool
+}
+
+// tableLines lays out the table as the lines to print
+func tableLines(t Table) ([]string, error) {
+	cols := make([]Column, len(t.Columns))
+	header := false
+	for i, col := range t.Columns {
+		cols[i] = Column{Width: col.Width, Weight: col.Weight, Align: col.Align}
+		header = header || col.Header != ""
+	}
+
+	width := t.Width
+	if t.Border {
+		width -= len(cols) + 1
+	}
+
+	widths, err := columnWidths(width, cols...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, w := range widths {
+		if w < 1 {
+			return nil, fmt.Errorf("column %d has no room on a line of %d", i, t.Width)
+		}
+	}
+
+	// edge is put between the cells and on each side when there is a border
+	edge := ""
+	if t.Border {
+		edge = "|"
+	}
+
+	// rule is the line of a border between the headers, the rows, and the
+	// top and bottom of the table
+	var rule strings.Builder
+	rule.WriteString("+")
+	for _, w := range widths {
+		rule.WriteString(strings.Repeat("-", w) + "+")
+	}
+
+	// row wraps each cell to its column and lines up the wrapped lines
+	row := func(cells []string) []string {
+		wrapped := make([][]string, len(cols))
+		height := 1
+		for i := range cols {
+			if i < len(cells) {
+				wrapped[i] = wrapText(cells[i], widths[i])
+			}
+			if len(wrapped[i]) > height {
+				height = len(wrapped[i])
+			}
+		}
+
+		lines := make([]string, height)
+		for y := range lines {
+			var line strings.Builder
+			line.WriteString(edge)
+			for i, col := range cols {
+				text := ""
+				if y < len(wrapped[i]) {
+					text = wrapped[i][y]
+				}
+				line.WriteString(padText(text, widths[i], col.Align) + edge)
+			}
+			lines[y] = line.String()
+		}
+		return lines
+	}
+
+	var lines []string
+	if t.Border {
+		lines = append(lines, rule.String())
+	}
+
+	if header {
+		headers := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			headers[i] = col.Header
+		}
+		lines = append(lines, row(headers)...)
+	}
+
+	switch {
+	case t.Border:
+		if header {
+			lines = append(lines, rule.String())
+		}
+	case t.Divider:
+		lines = append(lines, strings.Repeat("-", t.Width))
+	}
+
+	for i, cells := range t.Rows {
+		if len(cells) > len(cols) {
+			return nil, fmt.Errorf("row %d has %d cells but there are only %d columns", i, len(cells), len(cols))
+		}
+		lines = append(lines, row(cells)...)
+	}
+
+	if t.Border {
+		lines = append(lines, rule.String())
+	}
+
+	return lines, nil
+}
+
+// PrintTable prints the table with each cell wrapped to fit its column like
+// PrintWrapped.  When a cell wraps onto more lines, the other cells of the
+// row are padded with empty lines so the next row still lines up.
+//
+// The headers are printed when any column has one.  With Border set the
+// table is drawn in a box and the headers always have a line under them, so
+// Divider isn't needed.
+func (p Printer) PrintTable(t Table) error {
+	errMsg := "could not print table: %w"
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	if t.Width == 0 {
+		t.Width = p.Columns()
+	}
+
+	lines, err := tableLines(t)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	for _, line := range lines {
+		err = p.Println(line)
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+package escpos_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestPrintTable(t *testing.T) {
+	columns := []escpos.TableColumn{
+		{Header: "Item"},
+		{Header: "Price", Width: 6, Align: escpos.RightJustify},
+	}
+	rows := [][]string{
+		{"Blueberry muffin with extra on top", "$8.50"},
+		{"Tea", "$2.00"},
+	}
+
+	cases := []struct {
+		name  string
+		table escpos.Table
+		want  []string
+	}{
+		{
+			name:  "wrapped cell",
+			table: escpos.Table{Width: 20, Columns: columns, Rows: rows},
+			want: []string{
+				"Item           Price",
+				"Blueberry      $8.50",
+				"muffin with         ",
+				"extra on top        ",
+				"Tea            $2.00",
+			},
+		},
+		{
+			name:  "divider",
+			table: escpos.Table{Width: 20, Columns: columns, Rows: rows[1:], Divider: true},
+			want: []string{
+				"Item           Price",
+				"--------------------",
+				"Tea            $2.00",
+			},
+		},
+		{
+			name:  "border",
+			table: escpos.Table{Width: 20, Columns: columns, Rows: rows, Border: true},
+			want: []string{
+				"+-----------+------+",
+				"|Item       | Price|",
+				"+-----------+------+",
+				"|Blueberry  | $8.50|",
+				"|muffin with|      |",
+				"|extra on   |      |",
+				"|top        |      |",
+				"|Tea        | $2.00|",
+				"+-----------+------+",
+			},
+		},
+		{
+			name:  "border without headers",
+			table: escpos.Table{Width: 12, Columns: []escpos.TableColumn{{}, {}}, Rows: [][]string{{"a", "b"}}, Border: true, Divider: true},
+			want: []string{
+				"+----+-----+",
+				"|a   |b    |",
+				"+----+-----+",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintTable(c.table)
+			if err != nil {
+				t.Fatalf("could not print table: %v", err)
+			}
+
+			got := strings.Split(strings.TrimSuffix(string(sink.Bytes()), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Fatalf("table was\n%s\ninstead of\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPrintTableErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		table escpos.Table
+	}{
+		{"too many cells", escpos.Table{Width: 20, Columns: []escpos.TableColumn{{}}, Rows: [][]string{{"a", "b"}}}},
+		{"columns too wide", escpos.Table{Width: 10, Columns: []escpos.TableColumn{{Width: 6}, {Width: 6}}}},
+		{"border too wide", escpos.Table{Width: 12, Columns: []escpos.TableColumn{{Width: 6}, {Width: 6}}, Border: true}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+			if printer.PrintTable(c.table) == nil {
+				t.Fatalf("table should not be printed")
+			}
+			if len(sink.Bytes()) != 0 {
+				t.Fatalf("bad table sent %q", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestPrintColumns(t *testing.T) {
+	cases := []struct {
+		name  string
+		width int
+		cols  []escpos.Column
+		want  string
+	}{
+		{
+			name:  "item and price",
+			width: 32,
+			cols:  []escpos.Column{{Text: "Coffee"}, {Text: "$3.50", Width: 8, Align: escpos.RightJustify}},
+			want:  "Coffee                     $3.50",
+		},
+		{
+			name:  "truncated item",
+			width: 32,
+			cols:  []escpos.Column{{Text: "A very long item name that won't fit"}, {Text: "$12.00", Width: 8, Align: escpos.RightJustify}},
+			want:  "A very long item name th  $12.00",
+		},
+		{
+			// The truncated cell keeps whole runes
+			name:  "multibyte truncated",
+			width: 10,
+			cols:  []escpos.Column{{Text: "Crème brûlée"}, {Text: "$9", Width: 3, Align: escpos.RightJustify}},
+			want:  "Crème b $9",
+		},
+		{
+			name:  "weights",
+			width: 12,
+			cols:  []escpos.Column{{Text: "a", Weight: 2}, {Text: "b", Align: escpos.CenterJustify}},
+			want:  "a        b  ",
+		},
+		{
+			name:  "width from the font",
+			width: 0,
+			cols:  []escpos.Column{{Text: "x"}, {Text: "y", Align: escpos.RightJustify}},
+			want:  "x" + strings.Repeat(" ", escpos.ProfileHoin.FontAColumns-2) + "y",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintColumns(c.width, c.cols...)
+			if err != nil {
+				t.Fatalf("could not print columns: %v", err)
+			}
+			if got := string(sink.Bytes()); got != c.want+"\n" {
+				t.Fatalf("printed %q instead of %q", got, c.want+"\n")
+			}
+		})
+	}
+}
+
+func TestPrintColumnsErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		cols []escpos.Column
+	}{
+		{"too wide", []escpos.Column{{Text: "a", Width: 20}, {Text: "b", Width: 13}}},
+		{"negative width", []escpos.Column{{Text: "a", Width: -1}}},
+		{"negative weight", []escpos.Column{{Text: "a", Weight: -1}}},
+		{"unknown alignment", []escpos.Column{{Text: "a", Align: escpos.Justification(3)}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintColumns(32, c.cols...)
+			if err == nil {
+				t.Fatalf("columns did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent %q after failing", sink.Bytes())
+			}
+		})
+	}
+}
+
+func TestSetCharacterSpacing(t *testing.T) {
+	cases := []struct {
+		name string
+		set  func(escpos.Printer) error
+		want []byte
+	}{
+		{"none", func(p escpos.Printer) error { return p.SetCharacterSpacing(0) }, []byte{escpos.ESC, ' ', 0}},
+		{"spacing", func(p escpos.Printer) error { return p.SetCharacterSpacing(6) }, []byte{escpos.ESC, ' ', 6}},
+		{"most", func(p escpos.Printer) error { return p.SetCharacterSpacing(255) }, []byte{escpos.ESC, ' ', 255}},
+		{"reset", escpos.Printer.ResetCharacterSpacing, []byte{escpos.ESC, ' ', 0}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := c.set(printer)
+			if err != nil {
+				t.Fatalf("could not set character spacing: %v", err)
+			}
+			if !bytes.Equal(sink.Bytes(), c.want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestSetCharacterSpacingErrors(t *testing.T) {
+	for _, dots := range []int{-1, 256} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetCharacterSpacing(dots)
+		if err == nil {
+			t.Fatalf("spacing of %d did not fail", dots)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+func TestSetColor(t *testing.T) {
+	for _, c := range []struct {
+		color escpos.PrintColor
+		want  byte
+	}{
+		{escpos.Color1, '1'},
+		{escpos.Color2, '2'},
+	} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetColor(c.color)
+		if err != nil {
+			t.Fatalf("could not set color %d: %v", c.color, err)
+		}
+
+		want := []byte{escpos.GS, '(', 'N', 2, 0, '0', c.want}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+		}
+	}
+
+	for _, color := range []escpos.PrintColor{0, 3} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetColor(color)
+		if err == nil {
+			t.Fatalf("color %d did not fail", color)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+func TestPrintfw(t *testing.T) {
+	cases := []struct {
+		overflow escpos.Overflow
+		format   string
+		args     []any
+		want     string
+	}{
+		{escpos.OverflowTruncate, "%-12s%8.2f\n", []any{"Coffee", 3.5}, "Coffee          3.50\n"},
+		{escpos.OverflowTruncate, "%s %.2f", []any{"Extra large caramel latte", 6.25}, "Extra large caram..."},
+		{escpos.OverflowTruncate, "%s\n%s\n", []any{"Short", "A line that is too long"}, "Short\nA line that is to...\n"},
+		{escpos.OverflowWrap, "%s %.2f", []any{"Extra large caramel latte", 6.25}, "Extra large caramel\nlatte 6.25"},
+		{escpos.OverflowWrap, "%-12s%8.2f\n", []any{"Coffee", 3.5}, "Coffee          3.50\n"},
+		{escpos.OverflowWrap, "Total:\n%s\n", []any{"twenty dollars and fifty cents"}, "Total:\ntwenty dollars and\nfifty cents\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetOverflow(c.overflow)
+			if err != nil {
+				t.Fatalf("could not set overflow: %v", err)
+			}
+
+			err = printer.Printfw(20, c.format, c.args...)
+			if err != nil {
+				t.Fatalf("could not print: %v", err)
+			}
+			if string(sink.Bytes()) != c.want {
+				t.Fatalf("Printfw(%q) printed %q instead of %q", c.format, sink.Bytes(), c.want)
+			}
+		})
+	}
+}
+
+func TestPrintfwErrors(t *testing.T) {
+	for _, width := range []int{-1, 256} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.Printfw(width, "%s", "Coffee")
+		if err == nil {
+			t.Fatalf("Printfw(%d) did not fail", width)
+		}
+		if len(sink.Bytes()) != 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+}
+
+func TestPrintLarge(t *testing.T) {
+	sink, printer := escpos.NewCapturePrinter()
+
+	err := printer.SetCharacterSize(1, 0)
+	if err != nil {
+		t.Fatalf("could not set character size: %v", err)
+	}
+
+	err = printer.Justify(escpos.RightJustify)
+	if err != nil {
+		t.Fatalf("could not justify: %v", err)
+	}
+
+	sink.Reset()
+	err = printer.PrintLarge("42", 4, 4, escpos.CenterJustify)
+	if err != nil {
+		t.Fatalf("could not print large text: %v", err)
+	}
+
+	// The size and justification are put back after the text
+	want := []string{
+		"CHARACTER SIZE 3x3",
+		"JUSTIFY center",
+		`PRINT "42"`,
+		"LF",
+		"JUSTIFY right",
+		"CHARACTER SIZE 1x0",
+	}
+	if got := sink.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("large text sent %q instead of %q", got, want)
+	}
+}
+
+func TestPrintLargeErrors(t *testing.T) {
+	cases := []struct {
+		name          string
+		width, height int
+	}{
+		{"width of 9", 9, 1},
+		{"height of 0", 1, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.PrintLarge("0", c.width, c.height, escpos.LeftJustify)
+			if err == nil {
+				t.Fatalf("multipliers outside 1 to 8 did not fail")
+			}
+			if len(sink.Bytes()) > 0 {
+				t.Fatalf("sent %q after failing", sink.Bytes())
+			}
+		})
+	}
+}
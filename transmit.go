@@ -1,6 +1,204 @@
 package escpos
 
-import "fmt"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// readDeadliner is implemented by connections like net.Conn and os.File
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// ErrStatusTimeout is returned when the printer doesn't send back a status
+// within the read timeout from SetReadTimeout.  It wraps ErrTimeout, so
+// errors.Is(err, ErrTimeout) matches it too.
+var ErrStatusTimeout = fmt.Errorf("status read timed out: %w", ErrTimeout)
+
+// SetReadTimeout sets how long to wait for the printer to send back a status
+// before failing with ErrStatusTimeout.  A timeout of 0 waits forever, which is the
+// default.
+//
+// Connections with a SetReadDeadline method like net.Conn use the deadline.
+// Other connections, like USB devices that can't have a deadline, are read in
+// a goroutine that keeps waiting after the timeout.  Whatever it reads later
+// is returned by the next status read so it doesn't get lost, but the
+// goroutine only stops when the connection sends something or is closed.
+func (p Printer) SetReadTimeout(d time.Duration) {
+	if p.config == nil {
+		return
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	p.config.readTimeout = d
+}
+
+// readResult is the result of a read running in a goroutine
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// readDeadline returns when a read started now should time out, or the zero
+// time when there is no read timeout
+func (p Printer) readDeadline() time.Time {
+	if p.config == nil || p.config.readTimeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(p.config.readTimeout)
+}
+
+// readBefore reads from the printer and fails with ErrStatusTimeout if
+// nothing is read before the deadline.  A zero deadline waits forever.
+func (p Printer) readBefore(b []byte, deadline time.Time) (int, error) {
+	return p.readUntil(context.Background(), b, deadline)
+}
+
+// ReadContext reads from the printer like Read, but stops waiting when the
+// context is done and fails with ctx.Err(), so a printer that doesn't answer
+// isn't locked forever.
+//
+// Connections with a SetReadDeadline method have the read stopped by moving
+// the deadline.  Other connections are read in a goroutine that keeps waiting
+// like SetReadTimeout, and whatever it reads later is returned by the next
+// read so it doesn't get lost.
+func (p Printer) ReadContext(ctx context.Context, b []byte) (int, error) {
+	p, unlock := p.lock()
+	defer unlock()
+
+	return p.readUntil(ctx, b, time.Time{})
+}
+
+// readUntil is readBefore that also stops when the context is done
+func (p Printer) readUntil(ctx context.Context, b []byte, deadline time.Time) (int, error) {
+	if p.config == nil || (deadline.IsZero() && ctx.Done() == nil && p.config.pendingRead == nil) {
+		return p.Read(b)
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return 0, fmt.Errorf("could not read from printer: %w", err)
+	}
+
+	if conn, ok := p.dst.(readDeadliner); ok && p.config.pendingRead == nil {
+		err := conn.SetReadDeadline(deadline)
+		if err == nil {
+			n, err := p.readWithDeadline(ctx, conn, b)
+			conn.SetReadDeadline(time.Time{})
+			if err != nil && ctx.Err() != nil {
+				return n, fmt.Errorf("could not read from printer: %w", ctx.Err())
+			}
+			if errors.Is(err, ErrTimeout) {
+				return n, fmt.Errorf("could not read from printer: %w", ErrStatusTimeout)
+			}
+			return n, err
+		}
+		if !errors.Is(err, os.ErrNoDeadline) {
+			return 0, fmt.Errorf("could not read from printer: %w", err)
+		}
+	}
+
+	if p.config.pendingRead == nil {
+		err := p.Flush()
+		if err != nil {
+			return 0, fmt.Errorf("could not read from printer: %w", err)
+		}
+
+		pending := make(chan readResult, 1)
+		go func(data []byte) {
+			n, err := p.dst.Read(data)
+			pending <- readResult{data[:n], err}
+		}(make([]byte, len(b)))
+		p.config.pendingRead = pending
+	}
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var done <-chan struct{}
+	if p.config.ctx != nil {
+		done = p.config.ctx.Done()
+	}
+
+	select {
+	case result := <-p.config.pendingRead:
+		p.config.pendingRead = nil
+		n := copy(b, result.data)
+		if result.err != nil {
+			return n, fmt.Errorf("could not read from printer: %w", result.err)
+		}
+		return n, nil
+	case <-timeout:
+		return 0, fmt.Errorf("could not read from printer: %w", ErrStatusTimeout)
+	case <-done:
+		return 0, fmt.Errorf("could not read from printer: %w", p.config.ctx.Err())
+	case <-ctx.Done():
+		return 0, fmt.Errorf("could not read from printer: %w", ctx.Err())
+	}
+}
+
+// readWithDeadline reads from a connection that has its deadline set, and moves
+// the deadline to the past to stop the read when the context is done.  It
+// only returns once the context can't move the deadline anymore, so the
+// deadline can be cleared after it.
+func (p Printer) readWithDeadline(ctx context.Context, conn readDeadliner, b []byte) (int, error) {
+	if ctx.Done() == nil {
+		return p.Read(b)
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			conn.SetReadDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	n, err := p.Read(b)
+	close(stop)
+	<-stopped
+	return n, err
+}
+
+// transmit sends the command and reads the 1 byte response
+func (p Printer) transmit(cmd []byte) (byte, error) {
+	p, unlock := p.lock()
+	defer unlock()
+
+	// The command and the response use a buffer from the pool since the
+	// status is read after every band of an image
+	buf := getScratch()
+	defer putScratch(buf)
+
+	*buf = append(*buf, cmd...)
+	_, err := p.Write(*buf)
+	if err != nil {
+		return 0, err
+	}
+
+	b := append((*buf)[:0], 0)
+	_, err = p.readBefore(b, p.readDeadline())
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
 
 func (p Printer) realTimeStatusTransmission(n int) (byte, error) {
 	errMsg := "could not transmit real-time status: %w"
@@ -10,22 +208,122 @@ func (p Printer) realTimeStatusTransmission(n int) (byte, error) {
 		return 0, fmt.Errorf(errMsg, err)
 	}
 
-	_, err = p.Write([]byte{DLE, 0x04, byte(n)})
+	b, err := p.transmit([]byte{DLE, 0x04, byte(n)})
 	if err != nil {
 		return 0, fmt.Errorf(errMsg, err)
 	}
 
-	b := make([]byte, 1)
-	_, err = p.Read(b)
+	return b, nil
+}
+
+// StatusType selects which status is sent back by the printer
+type StatusType int
+
+const (
+	StatusPrinter StatusType = iota + 1
+	StatusOffline
+	StatusError
+	StatusPaperSensor
+)
+
+// Status is the real-time status sent back by the printer.  Only the fields
+// for the requested StatusType are set.
+type Status struct {
+	Type StatusType
+	// Raw is the byte that was sent back
+	Raw byte
+
+	// StatusPrinter
+	DrawerOpen, Offline bool
+
+	// StatusOffline
+	CoverOpen, FeedButton, PrintingStopped, ErrorOccured bool
+
+	// StatusError
+	Mechanical, AutoCutter, UnRecoverable, AutoRecoverable bool
+
+	// StatusPaperSensor
+	PaperNearEnd, PaperEnd bool
+}
+
+// RealtimeStatus requests the status with DLE EOT n.  The printer sends the
+// status right away even when it is busy or has an error.
+func (p Printer) RealtimeStatus(n StatusType) (Status, error) {
+	errMsg := "could not get real-time status: %w"
+
+	err := checkEnum(n, StatusPrinter, StatusOffline, StatusError, StatusPaperSensor)
 	if err != nil {
-		return 0, fmt.Errorf(errMsg, err)
+		return Status{}, fmt.Errorf(errMsg, err)
 	}
 
-	return b[0], nil
+	b, err := p.realTimeStatusTransmission(int(n))
+	if err != nil {
+		return Status{}, fmt.Errorf(errMsg, err)
+	}
+
+	status := Status{Type: n, Raw: b}
+	switch n {
+	case StatusPrinter:
+		status.DrawerOpen = b&0b0000_0100 == 0b0000_0100
+		status.Offline = b&0b0000_1000 == 0b0000_1000
+	case StatusOffline:
+		status.CoverOpen = b&0b0000_0100 == 0b0000_0100
+		status.FeedButton = b&0b0000_1000 == 0b0000_1000
+		status.PrintingStopped = b&0b0010_0000 == 0b0010_0000
+		status.ErrorOccured = b&0b0100_0000 == 0b0100_0000
+	case StatusError:
+		status.Mechanical = b&0b0000_0100 == 0b0000_0100
+		status.AutoCutter = b&0b0000_1000 == 0b0000_1000
+		status.UnRecoverable = b&0b0010_0000 == 0b0010_0000
+		status.AutoRecoverable = b&0b0100_0000 == 0b0100_0000
+	case StatusPaperSensor:
+		status.PaperNearEnd = b&0b0000_1100 == 0b0000_1100
+		status.PaperEnd = b&0b0110_0000 == 0b0110_0000
+	}
+
+	return status, nil
+}
+
+// RealtimeRequestType selects what the printer does for RealtimeRequest
+type RealtimeRequestType int
+
+const (
+	// RequestRecover recovers from a recoverable error and starts printing
+	// again from the line where the error happened
+	RequestRecover RealtimeRequestType = iota + 1
+	// RequestRecoverClear recovers from a recoverable error and clears the
+	// receive and print buffers
+	RequestRecoverClear
+)
+
+// RealtimeRequest sends DLE ENQ n to recover from an error like an auto
+// cutter jam after it has been fixed.  Like RealtimeStatus the printer runs it
+// right away even when it is busy or has an error, so it is sent straight to
+// the printer even when buffering is on.
+//
+// Use RealtimeStatus with StatusError to check that the error is recoverable
+// before sending the request, and again afterwards to check that the error is
+// gone.  The printer ignores the request when there is no recoverable error.
+func (p Printer) RealtimeRequest(req RealtimeRequestType) error {
+	errMsg := "could not send real-time request: %w"
+
+	err := checkEnum(req, RequestRecover, RequestRecoverClear)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	_, err = p.write([]byte{DLE, 0x05, byte(req)})
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+	return nil
 }
 
 type PrinterStatus struct {
-	DrawerOpen bool
+	DrawerOpen, Offline bool
 }
 
 func (p Printer) TransmitPrinterStatus() (PrinterStatus, error) {
@@ -35,7 +333,8 @@ func (p Printer) TransmitPrinterStatus() (PrinterStatus, error) {
 	}
 
 	return PrinterStatus{
-		DrawerOpen: b&0b0100 == 0b0100,
+		DrawerOpen: b&0b0000_0100 == 0b0000_0100,
+		Offline:    b&0b0000_1000 == 0b0000_1000,
 	}, nil
 }
 
@@ -58,7 +357,9 @@ func (p Printer) TransmitOfflineStatus() (OfflineStatus, error) {
 }
 
 type ErrorStatus struct {
-	AutoCutter, UnRecoverable, AutoRecoverable bool
+	Mechanical, AutoCutter, UnRecoverable, AutoRecoverable bool
+	// Raw is the byte that was sent back
+	Raw byte
 }
 
 func (p Printer) TransmitErrorStatus() (ErrorStatus, error) {
@@ -68,9 +369,11 @@ func (p Printer) TransmitErrorStatus() (ErrorStatus, error) {
 	}
 
 	return ErrorStatus{
+		Mechanical:      b&0b0000_0100 == 0b0000_0100,
 		AutoCutter:      b&0b0000_1000 == 0b0000_1000,
 		UnRecoverable:   b&0b0010_0000 == 0b0010_0000,
 		AutoRecoverable: b&0b0100_0000 == 0b0100_0000,
+		Raw:             b,
 	}, nil
 }
 
@@ -89,3 +392,154 @@ func (p Printer) TransmitPaperSensorStatus() (PaperSensorStatus, error) {
 		RollEnd: b&0b0110_0000 == 0b0110_0000,
 	}, nil
 }
+
+// PaperStatus is the roll paper sensor status sent back by GS r 1
+type PaperStatus struct {
+	// Adequate is set when the paper is not near the end or out
+	Adequate, NearEnd, End bool
+	// Raw is the byte that was sent back
+	Raw byte
+}
+
+// TransmitPaperStatus requests the roll paper sensor status with GS r 1.
+// Unlike TransmitPaperSensorStatus the printer answers after it has finished
+// the commands sent before it, so the status is for after those commands
+// have printed.  ErrStatusTimeout is returned when the printer doesn't
+// answer within the read timeout.
+func (p Printer) TransmitPaperStatus() (PaperStatus, error) {
+	b, err := p.transmit([]byte{GS, 'r', 1})
+	if err != nil {
+		return PaperStatus{}, fmt.Errorf("could not transmit paper status: %w", err)
+	}
+
+	status := PaperStatus{
+		NearEnd: b&0b0000_0011 == 0b0000_0011,
+		End:     b&0b0000_1100 == 0b0000_1100,
+		Raw:     b,
+	}
+	status.Adequate = !status.NearEnd && !status.End
+	return status, nil
+}
+
+// DrawerStatus is the drawer kick-out connector status sent back by GS r 2
+type DrawerStatus struct {
+	// Open is set when pin 3 of the drawer kick-out connector is high
+	Open bool
+	// Raw is the byte that was sent back
+	Raw byte
+}
+
+// TransmitDrawerStatus requests the drawer kick-out connector status with
+// GS r 2.  ErrStatusTimeout is returned when the printer doesn't answer
+// within the read timeout.
+func (p Printer) TransmitDrawerStatus() (DrawerStatus, error) {
+	b, err := p.transmit([]byte{GS, 'r', 2})
+	if err != nil {
+		return DrawerStatus{}, fmt.Errorf("could not transmit drawer status: %w", err)
+	}
+
+	return DrawerStatus{
+		Open: b&0b0000_0001 == 0b0000_0001,
+		Raw:  b,
+	}, nil
+}
+
+// PrinterIDType selects the printer ID sent back by TransmitPrinterID
+type PrinterIDType int
+
+const (
+	// PrinterIDModel is the model ID, sent back as 1 byte
+	PrinterIDModel PrinterIDType = 1
+	// PrinterIDTypeID is the type ID with bits for things like the auto cutter,
+	// sent back as 1 byte
+	PrinterIDTypeID PrinterIDType = 2
+	// PrinterIDROMVersion is the version ID of the ROM, sent back as 1 byte
+	PrinterIDROMVersion PrinterIDType = 3
+
+	// PrinterIDFirmware is the firmware version
+	PrinterIDFirmware PrinterIDType = 65
+	// PrinterIDMaker is the name of the maker
+	PrinterIDMaker PrinterIDType = 66
+	// PrinterIDModelName is the name of the model
+	PrinterIDModelName PrinterIDType = 67
+	// PrinterIDSerial is the serial number
+	PrinterIDSerial PrinterIDType = 68
+)
+
+// TransmitPrinterID requests a printer ID with GS I n, which can be used to
+// pick the Profile of a printer.  The 1 byte IDs are returned as the number in
+// decimal, like "32", while the other IDs are the text the printer sends back.
+// ErrStatusTimeout is returned when the printer doesn't answer within the
+// read timeout, which models that don't know the ID do.
+func (p Printer) TransmitPrinterID(idType PrinterIDType) (string, error) {
+	errMsg := "could not transmit printer ID: %w"
+
+	err := checkEnum(idType, PrinterIDModel, PrinterIDTypeID, PrinterIDROMVersion,
+		PrinterIDFirmware, PrinterIDMaker, PrinterIDModelName, PrinterIDSerial)
+	if err != nil {
+		return "", fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	if idType < PrinterIDFirmware {
+		b, err := p.transmit([]byte{GS, 'I', byte(idType)})
+		if err != nil {
+			return "", fmt.Errorf(errMsg, err)
+		}
+		return strconv.Itoa(int(b)), nil
+	}
+
+	_, err = p.Write([]byte{GS, 'I', byte(idType)})
+	if err != nil {
+		return "", fmt.Errorf(errMsg, err)
+	}
+
+	// The text is sent as a 0x5F header, the text, and then NUL
+	id, err := p.readUntilNUL()
+	if err != nil {
+		return "", fmt.Errorf(errMsg, err)
+	}
+
+	return string(bytes.TrimPrefix(id, []byte{0x5F})), nil
+}
+
+// readUntilNUL reads a response that ends with NUL and returns it without
+// the NUL.  The whole response has to be read within the read timeout.
+func (p Printer) readUntilNUL() ([]byte, error) {
+	deadline := p.readDeadline()
+
+	var data []byte
+	b := make([]byte, 1)
+	for {
+		_, err := p.readBefore(b, deadline)
+		if err != nil {
+			return nil, err
+		}
+
+		if b[0] == 0 {
+			return data, nil
+		}
+		data = append(data, b[0])
+	}
+}
+
+// Sync waits for the printer to finish the commands already sent to it, so
+// the connection can be closed without cutting off the end of a job.  Any
+// buffered data is flushed, then the paper status is requested with GS r 1,
+// which the printer only answers after the commands before it are done.  Use
+// SetReadTimeout to limit how long it waits.
+//
+// Printers that can't be read from, like NewNullPrinter, return io.EOF on
+// reads so Sync does nothing and returns nil for them.
+func (p Printer) Sync() error {
+	_, err := p.transmit([]byte{GS, 'r', 1})
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not sync printer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,473 @@
+package escpos_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+)
+
+// silentConn never answers a read until it is closed
+type silentConn struct {
+	*io.PipeReader
+}
+
+func (silentConn) Write(b []byte) (int, error) { return len(b), nil }
+
+// answerConn is a net.Conn that takes writes and only answers reads with
+// what is written to the other end
+type answerConn struct {
+	net.Conn
+}
+
+func (answerConn) Write(b []byte) (int, error) { return len(b), nil }
+
+func TestStatusReadTimeout(t *testing.T) {
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+
+	conn, other := net.Pipe()
+	t.Cleanup(func() { conn.Close(); other.Close() })
+
+	cases := []struct {
+		name string
+		conn io.ReadWriteCloser
+	}{
+		{"goroutine", silentConn{r}},
+		{"deadline", answerConn{conn}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			printer := escpos.NewPrinter(c.conn)
+			printer.SetReadTimeout(20 * time.Millisecond)
+
+			_, err := printer.TransmitErrorStatus()
+			if !errors.Is(err, escpos.ErrStatusTimeout) {
+				t.Fatalf("status read returned %v instead of ErrStatusTimeout", err)
+			}
+			if !errors.Is(err, escpos.ErrTimeout) {
+				t.Fatalf("status read returned %v which is not an ErrTimeout", err)
+			}
+		})
+	}
+}
+
+func TestStatusReadLateAnswer(t *testing.T) {
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+
+	printer := escpos.NewPrinter(silentConn{r})
+	printer.SetReadTimeout(20 * time.Millisecond)
+
+	_, err := printer.TransmitErrorStatus()
+	if !errors.Is(err, escpos.ErrStatusTimeout) {
+		t.Fatalf("status read returned %v instead of ErrStatusTimeout", err)
+	}
+
+	// The late answer goes to the next read instead of being lost
+	go w.Write([]byte{0b0001_0010})
+
+	printer.SetReadTimeout(time.Second)
+	_, err = printer.TransmitErrorStatus()
+	if err != nil {
+		t.Fatalf("could not read the late status: %v", err)
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+
+	conn, other := net.Pipe()
+	t.Cleanup(func() { conn.Close(); other.Close() })
+
+	cases := []struct {
+		name   string
+		conn   io.ReadWriteCloser
+		answer func([]byte)
+	}{
+		{"goroutine", silentConn{r}, func(b []byte) { w.Write(b) }},
+		{"deadline", answerConn{conn}, func(b []byte) { other.Write(b) }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			printer := escpos.NewPrinter(c.conn)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			b := make([]byte, 2)
+			_, err := printer.ReadContext(ctx, b)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("read returned %v instead of context.DeadlineExceeded", err)
+			}
+
+			// The printer can still be read after the context is done
+			go c.answer([]byte("ok"))
+
+			ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			n, err := printer.ReadContext(ctx, b)
+			if err != nil || string(b[:n]) != "ok" {
+				t.Fatalf("read %q, %v instead of \"ok\"", b[:n], err)
+			}
+		})
+	}
+}
+
+// statusConn keeps what is written and answers every read with status
+type statusConn struct {
+	written []byte
+	status  byte
+}
+
+func (c *statusConn) Write(b []byte) (int, error) {
+	c.written = append(c.written, b...)
+	return len(b), nil
+}
+
+func (c *statusConn) Read(b []byte) (int, error) {
+	b[0] = c.status
+	return 1, nil
+}
+
+func (c *statusConn) Close() error { return nil }
+
+func TestRealtimeStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		n      escpos.StatusType
+		status byte
+		want   escpos.Status
+	}{
+		{"printer", escpos.StatusPrinter, 0b0001_0010, escpos.Status{}},
+		{"drawer open offline", escpos.StatusPrinter, 0b0001_1110, escpos.Status{DrawerOpen: true, Offline: true}},
+		{"cover open", escpos.StatusOffline, 0b0001_0110, escpos.Status{CoverOpen: true}},
+		{"stopped with an error", escpos.StatusOffline, 0b0111_1010, escpos.Status{FeedButton: true, PrintingStopped: true, ErrorOccured: true}},
+		{"auto cutter", escpos.StatusError, 0b0001_1010, escpos.Status{AutoCutter: true}},
+		{"mechanical unrecoverable", escpos.StatusError, 0b0011_0110, escpos.Status{Mechanical: true, UnRecoverable: true}},
+		{"auto recoverable", escpos.StatusError, 0b0101_0010, escpos.Status{AutoRecoverable: true}},
+		{"paper near end", escpos.StatusPaperSensor, 0b0001_1110, escpos.Status{PaperNearEnd: true}},
+		{"paper end", escpos.StatusPaperSensor, 0b0111_1110, escpos.Status{PaperNearEnd: true, PaperEnd: true}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conn := &statusConn{status: c.status}
+			printer := escpos.NewPrinter(conn)
+
+			got, err := printer.RealtimeStatus(c.n)
+			if err != nil {
+				t.Fatalf("could not get status: %v", err)
+			}
+
+			want := []byte{escpos.DLE, 0x04, byte(c.n)}
+			if !bytes.Equal(conn.written, want) {
+				t.Fatalf("sent % x instead of % x", conn.written, want)
+			}
+
+			c.want.Type, c.want.Raw = c.n, c.status
+			if got != c.want {
+				t.Fatalf("status was %+v instead of %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRealtimeStatusErrors(t *testing.T) {
+	t.Run("unknown type", func(t *testing.T) {
+		conn := &statusConn{}
+		_, err := escpos.NewPrinter(conn).RealtimeStatus(escpos.StatusType(5))
+		if err == nil {
+			t.Fatalf("status type 5 did not fail")
+		}
+		if len(conn.written) > 0 {
+			t.Fatalf("sent % x after failing", conn.written)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		r, w := io.Pipe()
+		t.Cleanup(func() { w.Close() })
+
+		printer := escpos.NewPrinter(silentConn{r})
+		printer.SetReadTimeout(20 * time.Millisecond)
+
+		_, err := printer.RealtimeStatus(escpos.StatusPaperSensor)
+		if !errors.Is(err, escpos.ErrStatusTimeout) {
+			t.Fatalf("status returned %v instead of ErrStatusTimeout", err)
+		}
+	})
+}
+
+func TestRealtimeRequest(t *testing.T) {
+	for _, req := range []escpos.RealtimeRequestType{escpos.RequestRecover, escpos.RequestRecoverClear} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.RealtimeRequest(req)
+		if err != nil {
+			t.Fatalf("could not send request %d: %v", req, err)
+		}
+
+		want := []byte{escpos.DLE, 0x05, byte(req)}
+		if !bytes.Equal(sink.Bytes(), want) {
+			t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+		}
+	}
+}
+
+func TestRealtimeRequestBuffered(t *testing.T) {
+	// The request skips the buffer so it gets to a printer that has stopped
+	// for an error
+	sink, printer := escpos.NewCapturePrinter()
+	printer.SetBuffered(true)
+
+	err := printer.Print("waiting")
+	if err != nil {
+		t.Fatalf("could not print: %v", err)
+	}
+
+	err = printer.RealtimeRequest(escpos.RequestRecoverClear)
+	if err != nil {
+		t.Fatalf("could not send request: %v", err)
+	}
+
+	want := []byte{escpos.DLE, 0x05, 2}
+	if !bytes.Equal(sink.Bytes(), want) {
+		t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+	}
+}
+
+func TestRealtimeRequestErrors(t *testing.T) {
+	for _, req := range []escpos.RealtimeRequestType{0, 3} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.RealtimeRequest(req)
+		if err == nil {
+			t.Fatalf("request %d did not fail", req)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+
+	err := escpos.NewPrinter(brokenConn{}).RealtimeRequest(escpos.RequestRecover)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+func TestTransmitPaperStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		status byte
+		want   escpos.PaperStatus
+	}{
+		{"adequate", 0x00, escpos.PaperStatus{Adequate: true}},
+		{"near end", 0x03, escpos.PaperStatus{NearEnd: true, Raw: 0x03}},
+		{"end", 0x0C, escpos.PaperStatus{End: true, Raw: 0x0C}},
+		{"near end and end", 0x0F, escpos.PaperStatus{NearEnd: true, End: true, Raw: 0x0F}},
+		// Only one of the two bits isn't enough
+		{"one bit", 0x05, escpos.PaperStatus{Adequate: true, Raw: 0x05}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conn := &statusConn{status: c.status}
+
+			status, err := escpos.NewPrinter(conn).TransmitPaperStatus()
+			if err != nil {
+				t.Fatalf("could not get paper status: %v", err)
+			}
+			if status != c.want {
+				t.Fatalf("decoded %+v instead of %+v", status, c.want)
+			}
+
+			want := []byte{escpos.GS, 'r', 1}
+			if !bytes.Equal(conn.written, want) {
+				t.Fatalf("sent % x instead of % x", conn.written, want)
+			}
+		})
+	}
+}
+
+func TestTransmitDrawerStatus(t *testing.T) {
+	for _, c := range []struct {
+		status byte
+		want   escpos.DrawerStatus
+	}{
+		{0x00, escpos.DrawerStatus{}},
+		{0x01, escpos.DrawerStatus{Open: true, Raw: 0x01}},
+		{0x10, escpos.DrawerStatus{Raw: 0x10}},
+	} {
+		conn := &statusConn{status: c.status}
+
+		status, err := escpos.NewPrinter(conn).TransmitDrawerStatus()
+		if err != nil {
+			t.Fatalf("could not get drawer status: %v", err)
+		}
+		if status != c.want {
+			t.Fatalf("decoded %+v instead of %+v", status, c.want)
+		}
+
+		want := []byte{escpos.GS, 'r', 2}
+		if !bytes.Equal(conn.written, want) {
+			t.Fatalf("sent % x instead of % x", conn.written, want)
+		}
+	}
+}
+
+func TestTransmitSensorTimeout(t *testing.T) {
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+
+	printer := escpos.NewPrinter(silentConn{r})
+	printer.SetReadTimeout(20 * time.Millisecond)
+
+	_, err := printer.TransmitPaperStatus()
+	if !errors.Is(err, escpos.ErrStatusTimeout) {
+		t.Fatalf("paper status returned %v instead of ErrStatusTimeout", err)
+	}
+
+	_, err = printer.TransmitDrawerStatus()
+	if !errors.Is(err, escpos.ErrStatusTimeout) {
+		t.Fatalf("drawer status returned %v instead of ErrStatusTimeout", err)
+	}
+}
+
+// eventConn records what is done to it and answers every read with 0
+type eventConn struct {
+	events *[]string
+}
+
+func (c eventConn) Write(b []byte) (int, error) {
+	*c.events = append(*c.events, "write")
+	return len(b), nil
+}
+
+func (c eventConn) Read(b []byte) (int, error) {
+	*c.events = append(*c.events, "read")
+	b[0] = 0
+	return 1, nil
+}
+
+func (c eventConn) Close() error {
+	*c.events = append(*c.events, "close")
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	var events []string
+	printer := escpos.NewPrinter(eventConn{&events})
+
+	for _, step := range []func() error{
+		func() error { return printer.Println("Job") },
+		printer.Sync,
+		printer.Close,
+	} {
+		err := step()
+		if err != nil {
+			t.Fatalf("could not print and sync: %v", err)
+		}
+	}
+
+	want := []string{"write", "write", "read", "close"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events were %v instead of %v", events, want)
+	}
+}
+
+func TestSyncNullPrinter(t *testing.T) {
+	err := escpos.NewNullPrinter().Sync()
+	if err != nil {
+		t.Fatalf("sync on a null printer should do nothing: %v", err)
+	}
+}
+
+func TestSyncError(t *testing.T) {
+	err := escpos.NewPrinter(brokenConn{}).Sync()
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
+
+// idConn answers GS I requests with canned responses
+type idConn struct {
+	responses map[byte][]byte
+	reply     []byte
+}
+
+func (c *idConn) Write(b []byte) (int, error) {
+	if len(b) == 3 && b[0] == escpos.GS && b[1] == 'I' {
+		c.reply = append(c.reply, c.responses[b[2]]...)
+	}
+	return len(b), nil
+}
+
+func (c *idConn) Read(b []byte) (int, error) {
+	if len(c.reply) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.reply)
+	c.reply = c.reply[n:]
+	return n, nil
+}
+
+func (c *idConn) Close() error { return nil }
+
+func TestTransmitPrinterID(t *testing.T) {
+	printer := escpos.NewPrinter(&idConn{responses: map[byte][]byte{
+		1:  {0x20},
+		3:  {0x07},
+		65: append([]byte("_1.01 ESC/POS"), 0),
+		67: append([]byte("_TM-T88V"), 0),
+		68: {0x5F, '_', 'A', '1', 0},
+	}})
+
+	cases := []struct {
+		idType escpos.PrinterIDType
+		want   string
+	}{
+		{escpos.PrinterIDModel, "32"},
+		{escpos.PrinterIDROMVersion, "7"},
+		{escpos.PrinterIDFirmware, "1.01 ESC/POS"},
+		{escpos.PrinterIDModelName, "TM-T88V"},
+		{escpos.PrinterIDSerial, "_A1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			got, err := printer.TransmitPrinterID(c.idType)
+			if err != nil {
+				t.Fatalf("could not get printer ID %d: %v", c.idType, err)
+			}
+			if got != c.want {
+				t.Fatalf("printer ID %d was %q instead of %q", c.idType, got, c.want)
+			}
+		})
+	}
+}
+
+func TestTransmitPrinterIDErrors(t *testing.T) {
+	printer := escpos.NewPrinter(&idConn{})
+
+	// The maker has no response so the read returns EOF
+	_, err := printer.TransmitPrinterID(escpos.PrinterIDMaker)
+	if err == nil {
+		t.Fatalf("printer ID without a response did not fail")
+	}
+
+	_, err = printer.TransmitPrinterID(4)
+	if err == nil {
+		t.Fatalf("printer ID 4 was sent")
+	}
+}
@@ -0,0 +1,71 @@
+package escpos
+
+import "fmt"
+
+// DensityLevel is the print density from -6 for the lightest to 6 for the
+// darkest.  Each level is about 5% from the normal density of 0.
+type DensityLevel int
+
+const (
+	DensityLightest DensityLevel = -6
+	DensityNormal   DensityLevel = 0
+	DensityDarkest  DensityLevel = 6
+)
+
+// userSetting sends a GS ( E user setting command
+func (p Printer) userSetting(fn byte, params ...byte) error {
+	length := len(params) + 1
+	data := append([]byte{GS, '(', 'E', byte(length), byte(length >> 8), fn}, params...)
+
+	_, err := p.Write(data)
+	if err != nil {
+		return fmt.Errorf("could not send user setting function %d: %w", fn, err)
+	}
+	return nil
+}
+
+// SetPrintDensity changes the print density stored in the printer with the
+// GS ( E user setting commands.  The printer is put in user setting mode, the
+// density is changed, and then user setting mode is ended which restarts the
+// printer.
+//
+// The printer sends back a 3 byte notice when it goes into user setting mode,
+// which will be read by the next status command unless it is read first.
+//
+// The density is written to the NV memory of the printer so it stays after
+// the printer is turned off.  Writing NV memory wears it out, so only change
+// the density when it needs to change and not for every receipt.  Not all
+// printers have every level.
+func (p Printer) SetPrintDensity(level DensityLevel) error {
+	errMsg := "could not set print density: %w"
+
+	err := checkRange(int(level), int(DensityLightest), int(DensityDarkest), "density level")
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	p, unlock := p.lock()
+	defer unlock()
+
+	// Function 1: change into the user setting mode
+	err = p.userSetting(1, 'I', 'N')
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 5: set a=5 print density, negative levels are sent as two's
+	// complement like 65530 for -6
+	n := uint16(int16(level))
+	err = p.userSetting(5, 5, byte(n), byte(n>>8))
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	// Function 2: end the user setting mode session
+	err = p.userSetting(2, 'O', 'U', 'T')
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package escpos_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/joeyak/go-escpos"
+)
+
+func TestSetPrintDensity(t *testing.T) {
+	start := []byte{escpos.GS, '(', 'E', 3, 0, 1, 'I', 'N'}
+	end := []byte{escpos.GS, '(', 'E', 4, 0, 2, 'O', 'U', 'T'}
+
+	cases := []struct {
+		name   string
+		level  escpos.DensityLevel
+		nL, nH byte
+	}{
+		{"lightest", escpos.DensityLightest, 0xFA, 0xFF},
+		{"lighter", -1, 0xFF, 0xFF},
+		{"normal", escpos.DensityNormal, 0, 0},
+		{"darker", 1, 1, 0},
+		{"darkest", escpos.DensityDarkest, 6, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sink, printer := escpos.NewCapturePrinter()
+
+			err := printer.SetPrintDensity(c.level)
+			if err != nil {
+				t.Fatalf("could not set density: %v", err)
+			}
+
+			want := append(append(append([]byte{}, start...), escpos.GS, '(', 'E', 4, 0, 5, 5, c.nL, c.nH), end...)
+			if !bytes.Equal(sink.Bytes(), want) {
+				t.Fatalf("sent % x instead of % x", sink.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSetPrintDensityErrors(t *testing.T) {
+	for _, level := range []escpos.DensityLevel{escpos.DensityLightest - 1, escpos.DensityDarkest + 1} {
+		sink, printer := escpos.NewCapturePrinter()
+
+		err := printer.SetPrintDensity(level)
+		if err == nil {
+			t.Fatalf("level %d did not fail", level)
+		}
+		if len(sink.Bytes()) > 0 {
+			t.Fatalf("sent % x after failing", sink.Bytes())
+		}
+	}
+
+	err := escpos.NewPrinter(brokenConn{}).SetPrintDensity(escpos.DensityNormal)
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v instead of %v", err, errBroken)
+	}
+}
@@ -0,0 +1,107 @@
+package escpos
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Number of polls in a row that have to fail before WatchStatus sends the
+// error, so a single dropped response isn't reported
+const watchErrorLimit = 3
+
+// StatusEvent is sent by WatchStatus when the status of the printer changes
+type StatusEvent struct {
+	Printer, Offline, Error, PaperSensor Status
+	// Err is set when the status could not be read for several polls in a
+	// row.  The status fields are empty when Err is set.
+	Err error
+}
+
+// readStatus reads all the real-time statuses of the printer
+func (p Printer) readStatus() (StatusEvent, error) {
+	p, unlock := p.lock()
+	defer unlock()
+
+	var event StatusEvent
+	for _, s := range []struct {
+		typ    StatusType
+		status *Status
+	}{
+		{StatusPrinter, &event.Printer},
+		{StatusOffline, &event.Offline},
+		{StatusError, &event.Error},
+		{StatusPaperSensor, &event.PaperSensor},
+	} {
+		status, err := p.RealtimeStatus(s.typ)
+		if err != nil {
+			return StatusEvent{}, err
+		}
+		*s.status = status
+	}
+	return event, nil
+}
+
+// WatchStatus polls the real-time status of the printer every interval and
+// sends an event on the channel when the status changes.  The first status
+// read is always sent.  The channel is closed when the context is done.
+//
+// A poll that fails is retried on the next interval.  When 3 polls in a row
+// fail an event with Err set is sent, and the next status that is read is
+// sent once the printer responds again.
+//
+// The status is read with DLE EOT from another goroutine.  Each poll holds the
+// printer lock, so it waits for a method that is running like PrintImage24 to
+// finish and is never sent in the middle of its commands.  Reading the status
+// flushes the printer, so don't watch a buffered printer while it is building
+// a receipt.
+func (p Printer) WatchStatus(ctx context.Context, interval time.Duration) (<-chan StatusEvent, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("could not watch status: interval must be more than 0")
+	}
+
+	events := make(chan StatusEvent)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last StatusEvent
+		sent, failures := false, 0
+		for {
+			event, err := p.readStatus()
+			if err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+
+			send := false
+			switch {
+			case err != nil && failures == watchErrorLimit:
+				event = StatusEvent{Err: fmt.Errorf("could not watch status: %w", err)}
+				send = true
+			case err == nil && (!sent || event != last):
+				send = true
+			}
+
+			if send {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+				last, sent = event, true
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,244 @@
+package escpos_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joeyak/go-escpos"
+)
+
+var errDropped = errors.New("dropped response")
+
+// poll is how the printer answers one poll of WatchStatus
+type poll struct {
+	coverOpen bool
+	err       error
+}
+
+// scriptConn answers DLE EOT status requests with the polls in order.  Each
+// poll reads all 4 statuses, or fails on the first read when err is set.  The
+// last poll is repeated when the script runs out, and repeats counts how many
+// times it was answered.
+type scriptConn struct {
+	mu      sync.Mutex
+	polls   []poll
+	status  byte
+	repeats int
+}
+
+func (c *scriptConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(b) == 3 && b[0] == escpos.DLE && b[1] == 0x04 {
+		c.status = b[2]
+	}
+	return len(b), nil
+}
+
+func (c *scriptConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next := c.polls[0]
+	if next.err != nil || c.status == byte(escpos.StatusPaperSensor) {
+		if len(c.polls) > 1 {
+			c.polls = c.polls[1:]
+		} else {
+			c.repeats++
+		}
+	}
+	if next.err != nil {
+		return 0, next.err
+	}
+
+	b[0] = 0b0001_0010
+	if c.status == byte(escpos.StatusOffline) && next.coverOpen {
+		b[0] |= 0b0000_0100
+	}
+	return 1, nil
+}
+
+func (c *scriptConn) Close() error { return nil }
+
+func (c *scriptConn) done() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The event from the first time the last poll was answered is sent
+	// before the next poll starts
+	return c.repeats >= 2
+}
+
+// watch returns what WatchStatus sends for the polls
+func watch(t *testing.T, polls ...poll) []escpos.StatusEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn := &scriptConn{polls: polls}
+	events, err := escpos.NewPrinter(conn).WatchStatus(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatalf("could not watch status: %v", err)
+	}
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+
+	var got []escpos.StatusEvent
+	for {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		case <-ticker.C:
+			if conn.done() {
+				return got
+			}
+		case <-timeout:
+			t.Fatalf("polls were not done after 5 seconds")
+		}
+	}
+}
+
+func TestWatchStatusChanges(t *testing.T) {
+	closed, open := poll{}, poll{coverOpen: true}
+	events := watch(t, closed, closed, open, open, open, closed, closed)
+
+	var covers []bool
+	for _, event := range events {
+		if event.Err != nil {
+			t.Fatalf("unexpected error event: %v", event.Err)
+		}
+		covers = append(covers, event.Offline.CoverOpen)
+	}
+
+	want := []bool{false, true, false}
+	if len(covers) != len(want) {
+		t.Fatalf("cover open events were %v instead of %v", covers, want)
+	}
+	for i := range want {
+		if covers[i] != want[i] {
+			t.Fatalf("cover open events were %v instead of %v", covers, want)
+		}
+	}
+}
+
+func TestWatchStatusErrors(t *testing.T) {
+	closed, dropped := poll{}, poll{err: errDropped}
+	repeat := func(p poll, n int) []poll {
+		polls := make([]poll, n)
+		for i := range polls {
+			polls[i] = p
+		}
+		return polls
+	}
+
+	cases := []struct {
+		name  string
+		polls []poll
+		// errs is which events are errors
+		errs []bool
+	}{
+		{
+			name:  "fewer errors than the limit",
+			polls: append(append([]poll{closed}, repeat(dropped, escpos.WatchErrorLimit-1)...), closed),
+			errs:  []bool{false},
+		},
+		{
+			name:  "errors up to the limit",
+			polls: append(append([]poll{closed}, repeat(dropped, escpos.WatchErrorLimit)...), closed),
+			errs:  []bool{false, true, false},
+		},
+		{
+			name:  "errors past the limit are sent once",
+			polls: append(append([]poll{closed}, repeat(dropped, escpos.WatchErrorLimit*3)...), closed),
+			errs:  []bool{false, true, false},
+		},
+		{
+			name:  "printer never answers",
+			polls: repeat(dropped, escpos.WatchErrorLimit),
+			errs:  []bool{true},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			events := watch(t, c.polls...)
+
+			var errs []bool
+			for _, event := range events {
+				if event.Err != nil && !errors.Is(event.Err, errDropped) {
+					t.Fatalf("error event %v is not the read error", event.Err)
+				}
+				errs = append(errs, event.Err != nil)
+			}
+
+			if len(errs) != len(c.errs) {
+				t.Fatalf("error events were %v instead of %v", errs, c.errs)
+			}
+			for i := range errs {
+				if errs[i] != c.errs[i] {
+					t.Fatalf("error events were %v instead of %v", errs, c.errs)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchStatusCancel(t *testing.T) {
+	cases := []struct {
+		name string
+		// read is if the first event is read before cancelling, so the
+		// watcher is waiting on the ticker instead of sending
+		read bool
+	}{
+		{"while waiting to poll", true},
+		{"while sending an event", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			printer := escpos.NewPrinter(&scriptConn{polls: []poll{{}}})
+			events, err := printer.WatchStatus(ctx, time.Hour)
+			if err != nil {
+				t.Fatalf("could not watch status: %v", err)
+			}
+
+			if c.read {
+				<-events
+			} else {
+				// Give the watcher time to read the status and wait to
+				// send it
+				time.Sleep(10 * time.Millisecond)
+			}
+			cancel()
+
+			timeout := time.After(time.Second)
+			for {
+				select {
+				case _, ok := <-events:
+					if !ok {
+						return
+					}
+				case <-timeout:
+					t.Fatalf("channel was not closed after the context was cancelled")
+				}
+			}
+		})
+	}
+}
+
+func TestWatchStatusInterval(t *testing.T) {
+	_, err := escpos.NewNullPrinter().WatchStatus(context.Background(), 0)
+	if err == nil {
+		t.Fatalf("an interval of 0 should fail")
+	}
+}